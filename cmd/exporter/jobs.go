@@ -0,0 +1,181 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/apiclient"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/circuit"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/explorer"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/lcd"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/stake"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/tendermint"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/amount"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/collectors"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/config"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/push"
+)
+
+// jobDeps 为跨配置重载保持不变的共享依赖。
+type jobDeps struct {
+	log       *slog.Logger
+	m         *metrics.Metrics
+	chainHead *collectors.ChainHead
+	// blockCursor 为 BlockFollower 已处理的高度，跨重载共享：重建后从断点继续，避免重放区块导致 counter 重复累加
+	blockCursor *collectors.BlockCursor
+	// breakers 按 source 熔断，跨重载共享以保留各数据源的状态
+	breakers *circuit.Breakers
+	// limiters 为 explorer/stake API 按 base URL 的限速，跨重载共享以保留令牌桶状态
+	limiters *apiclient.RateLimiters
+	// stakeKey/explorerKey 为可热更新的 API Key：轮换 key 时原地替换，不计入 fingerprint，不重建 job
+	stakeKey, explorerKey *apiclient.APIKey
+	// validatorDetails 为验证人详情（commission）缓存，跨重载共享：重建 stake collector 后不必重新逐个补齐
+	validatorDetails *collectors.ValidatorDetailCache
+	// transport 为全部 HTTP adapters 共享的带指标 RoundTripper（同时复用连接池）
+	transport http.RoundTripper
+	// proposals 为已计入 biya_proposals_total 的提案数，跨重载共享以避免重复计数
+	proposals *collectors.ProposalCounter
+	// slashing 保存惩罚事件的高水位与已计入的事件，跨重载共享以避免重复计数
+	slashing *collectors.SlashingIngester
+	// version/commit 为构建信息，用于 OTLP resource 属性
+	version, commit string
+}
+
+// buildJobs 根据配置构建全部 adapters 与 collectors。
+// 每个 job 的 Fingerprint 只包含它实际依赖的配置段，热加载时据此判断是否需要重建。
+func buildJobs(cfg config.Config, d jobDeps) []collectors.Job {
+	logger, m := d.log, d.m
+
+	// adapters
+	retry := apiclient.RetryOptions{MaxRetries: cfg.HTTPClient.Retry.MaxRetries, MinBackoff: cfg.HTTPClient.Retry.MinBackoff, MaxBackoff: cfg.HTTPClient.Retry.MaxBackoff}
+	stakeCli := stake.NewClient(cfg.Stake.BaseURL, cfg.Stake.APIKey, cfg.HTTPClient.Timeout).WithCircuit(d.breakers).WithRetry(retry).WithRateLimit(d.limiters).WithTransport(d.transport).WithAPIKey(d.stakeKey)
+	explorerCli := explorer.NewClient(cfg.Explorer.BaseURL, cfg.Explorer.APIKey, cfg.HTTPClient.Timeout).WithCircuit(d.breakers).WithRetry(retry).WithRateLimit(d.limiters).WithTransport(d.transport).WithAPIKey(d.explorerKey)
+	// API Key 由共享的 apiclient.APIKey 热更新，fingerprint 中不包含它（轮换 key 不产生抓取空档）
+	stakeFP, explorerFP := cfg.Stake, cfg.Explorer
+	stakeFP.APIKey, explorerFP.APIKey = "", ""
+	denom := amount.Denom{Base: cfg.Chain.Denom.Base, Display: cfg.Chain.Denom.Display, Exponent: cfg.Chain.Denom.Exponent}
+	lcdCli := lcd.NewClient(cfg.Node.LCDBaseURL, cfg.HTTPClient.Timeout).WithCircuit(d.breakers).WithTransport(d.transport)
+	// 多节点：每个具名 RPC 一个 client；mempool/逐块拉取只需要一个节点，使用第一个（主节点）。
+	chainNodes := make([]collectors.ChainNode, 0, len(cfg.Node.TendermintRPCBaseURL))
+	for _, ep := range cfg.Node.TendermintRPCBaseURL {
+		chainNodes = append(chainNodes, collectors.ChainNode{Name: ep.Name, Client: tendermint.NewClient(ep.URL, cfg.HTTPClient.Timeout).WithCircuit(d.breakers).WithTransport(d.transport)})
+	}
+	if len(chainNodes) == 0 {
+		// 未配置 RPC 时保留一个空地址节点：请求会失败并体现在 source_up，与历史行为一致。
+		chainNodes = append(chainNodes, collectors.ChainNode{Name: config.DefaultEndpointName, Client: tendermint.NewClient("", cfg.HTTPClient.Timeout).WithCircuit(d.breakers).WithTransport(d.transport)})
+	}
+	tmCli := chainNodes[0].Client
+	lcdEnabled := cfg.Node.LCDBaseURL != ""
+
+	// collectors（按类型分组：node / stake / explorer）
+	// 注意：这里仅调整代码结构以便维护；不修改 job 名称与 interval，避免影响指标 source label。
+	// 每个 collector 通过 m.Owned(job 名) 写指标，scheduler 据此清理该 job 不再刷新的 series。
+	realtimeChain := collectors.NewJob("realtime_chain", cfg.ScrapeIntervals.Realtime, collectors.NewRealtimeChainCollector(logger, m.Owned("realtime_chain"), chainNodes, d.chainHead, cfg.Mock))
+	realtimeChain.Fingerprint = fingerprint(cfg.Node.TendermintRPCBaseURL, cfg.HTTPClient, cfg.Mock)
+	minuteChain := collectors.NewJob("minute_chain", cfg.ScrapeIntervals.Minute, collectors.NewMinuteChainCollector(logger, m.Owned("minute_chain"), tmCli, cfg.Mock, cfg.Node.MempoolCapacity))
+	minuteChain.Fingerprint = fingerprint(cfg.Node.TendermintRPCBaseURL.Primary(), cfg.Node.MempoolCapacity, cfg.HTTPClient, cfg.Mock)
+	// 逐块跟随主节点：交易数/TPS、出块时间、出块者、验证人签名、gas 都基于同一批区块计算
+	// 推送驱动的区块同样由 follower 处理，因此 subscribers 的指标统一归属 realtime_blocks
+	blocksM := m.Owned("realtime_blocks")
+	follower := collectors.NewBlockFollower(logger, blocksM, tmCli, cfg.Node.BlockFollower.Concurrency, cfg.Node.BlockFollower.MaxBlocksPerRun).WithCursor(d.blockCursor).Subscribe(
+		collectors.NewTxStatsSubscriber(logger, blocksM, cfg.Mock),
+		collectors.NewBlockTimeSubscriber(logger, blocksM, cfg.Mock, 100),
+		collectors.NewProposerSubscriber(logger, blocksM),
+		collectors.NewValidatorSigningSubscriber(logger, blocksM, tmCli, stakeCli),
+		collectors.NewGasSubscriber(logger, blocksM, tmCli, cfg.Mock, cfg.Node.GasWindowBlocks),
+	)
+	// realtime_blocks 与 realtime_stream 共享同一个 follower，必须一起重建，因此使用相同的 fingerprint
+	// stake 只用于签名统计的地址映射（base_url），限速与 API Key 都是共享状态热更新，不计入
+	blocksFingerprint := fingerprint(cfg.Node.TendermintRPCBaseURL.Primary(), cfg.Node.GasWindowBlocks, cfg.Node.BlockFollower, cfg.Node.WebSocket,
+		cfg.Stake.BaseURL, cfg.HTTPClient.Timeout, cfg.HTTPClient.Retry, cfg.Mock)
+	realtimeBlocks := collectors.NewJob("realtime_blocks", cfg.ScrapeIntervals.Realtime, follower)
+	realtimeBlocks.Fingerprint = blocksFingerprint
+	nodeJobs := []collectors.Job{realtimeChain, minuteChain, realtimeBlocks}
+
+	// WebSocket 订阅为可选加速通道：新区块推送时立即驱动 follower，断线时由上面的轮询兜底
+	if cfg.Node.WebSocket.Enabled {
+		wsURL := cfg.Node.WebSocket.URL
+		if wsURL == "" {
+			wsURL = cfg.Node.TendermintRPCBaseURL.Primary().URL
+		}
+		ws := tendermint.NewWSClient(wsURL, cfg.HTTPClient.Timeout)
+		realtimeStream := collectors.NewJob("realtime_stream", cfg.ScrapeIntervals.Realtime, collectors.NewChainStream(logger, m.Owned("realtime_stream"), ws, follower, d.chainHead))
+		realtimeStream.Fingerprint = blocksFingerprint
+		nodeJobs = append(nodeJobs, realtimeStream)
+	}
+
+	// LCD 为可选数据源：未配置 lcd_base_url 时不注册 job，避免 scheduler 因为永远失败而无法 ready。
+	stakeCollector := collectors.NewRealtimeStakeCollector(logger, m.Owned("realtime_stake"), stakeCli).
+		WithValidatorPaging(cfg.Stake.ValidatorsPageSize, cfg.Stake.ValidatorsMaxPages).
+		WithDenom(denom).
+		WithValidatorDetails(d.validatorDetails).
+		WithSlashing(d.slashing, collectors.SlashingOptions{
+			PageSize:        cfg.Stake.Slashing.PageSize,
+			MaxPages:        cfg.Stake.Slashing.MaxPages,
+			InitialLookback: cfg.Stake.Slashing.InitialLookback,
+			Overlap:         cfg.Stake.Slashing.Overlap,
+		})
+	if lcdEnabled {
+		stakeCollector.UseLCDStakedRatio()
+		minuteLCD := collectors.NewJob("minute_lcd", cfg.ScrapeIntervals.Minute, collectors.NewMinuteLCDCollector(logger, m.Owned("minute_lcd"), lcdCli).WithDenom(denom))
+		minuteLCD.Fingerprint = fingerprint(cfg.Node.LCDBaseURL, cfg.Chain.Denom, cfg.HTTPClient)
+		nodeJobs = append(nodeJobs, minuteLCD)
+	}
+
+	realtimeStake := collectors.NewJob("realtime_stake", cfg.ScrapeIntervals.Realtime, stakeCollector)
+	realtimeStake.Fingerprint = fingerprint(stakeFP, cfg.Chain.Denom, cfg.HTTPClient, lcdEnabled)
+	governanceCollector := collectors.NewMinuteGovernanceCollector(logger, m.Owned("minute_governance"), stakeCli).
+		WithPaging(cfg.Stake.Governance.PageSize, cfg.Stake.Governance.MaxPages).
+		WithRecentWindow(cfg.Stake.Governance.RecentWindow).
+		WithDenom(denom).
+		WithProposalCounter(d.proposals)
+	minuteGovernance := collectors.NewJob("minute_governance", cfg.ScrapeIntervals.Minute, governanceCollector)
+	minuteGovernance.Fingerprint = fingerprint(cfg.Stake.BaseURL, cfg.Stake.Governance, cfg.Chain.Denom, cfg.HTTPClient)
+	stakeJobs := []collectors.Job{realtimeStake, minuteGovernance}
+
+	explorerCollector := collectors.NewRealtimeExplorerCollector(logger, m.Owned("realtime_explorer"), explorerCli, cfg.Mock).TrackChainHead(d.chainHead)
+	if cfg.Explorer.Stream {
+		explorerCollector.UseStreams()
+	}
+	realtimeExplorer := collectors.NewJob("realtime_explorer", cfg.ScrapeIntervals.Realtime, explorerCollector)
+	realtimeExplorer.Fingerprint = fingerprint(explorerFP, cfg.HTTPClient, cfg.Mock)
+	explorerJobs := []collectors.Job{realtimeExplorer}
+
+	jobs := make([]collectors.Job, 0, len(nodeJobs)+len(stakeJobs)+len(explorerJobs)+2)
+	jobs = append(jobs, nodeJobs...)
+	jobs = append(jobs, stakeJobs...)
+	jobs = append(jobs, explorerJobs...)
+
+	// 推送模式：与 /metrics 抓取并存，配置了 endpoint 才启用（重建 job 会丢弃尚未发出的队列）
+	if len(cfg.RemoteWrite.Endpoints) > 0 {
+		remoteWrite := collectors.NewJob("remote_write", cfg.RemoteWrite.Interval, push.NewRemoteWriter(logger, m.Owned("remote_write"), cfg.RemoteWrite))
+		remoteWrite.Fingerprint = fingerprint(cfg.RemoteWrite)
+		jobs = append(jobs, remoteWrite)
+	}
+	if cfg.OTLP.Endpoint != "" {
+		otlp := collectors.NewJob("otlp", cfg.OTLP.Interval, push.NewOTLPExporter(logger, m.Owned("otlp"), cfg.OTLP, d.version, d.commit))
+		otlp.Fingerprint = fingerprint(cfg.OTLP)
+		jobs = append(jobs, otlp)
+	}
+
+	for i := range jobs {
+		sc := cfg.Scheduler.For(jobs[i].Name)
+		jobs[i].Timeout, jobs[i].Jitter, jobs[i].MaxConcurrentRuns = sc.Timeout, sc.Jitter, sc.MaxConcurrentRuns
+	}
+	return jobs
+}
+
+// fingerprint 对 job 依赖的配置段做摘要（包含 API Key，因此只输出 hash，不落日志原文）。
+func fingerprint(parts ...any) string {
+	b, err := json.Marshal(parts)
+	if err != nil {
+		// 配置结构均可序列化；万一失败则返回空串，等价于“总是视为未变化”
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/apiclient"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/circuit"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/collectors"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/config"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+func TestBuildJobs_RebuiltFollowerResumesFromSharedCursor(t *testing.T) {
+	t.Parallel()
+
+	var head atomic.Int64
+	head.Store(5)
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	rpc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, _ := strconv.ParseInt(r.URL.Query().Get("height"), 10, 64)
+		switch r.URL.Path {
+		case "/status":
+			_ = json.NewEncoder(w).Encode(map[string]any{"result": map[string]any{
+				"sync_info": map[string]any{"latest_block_height": strconv.FormatInt(head.Load(), 10), "latest_block_time": base},
+			}})
+		case "/block":
+			_ = json.NewEncoder(w).Encode(map[string]any{"result": map[string]any{"block": map[string]any{
+				"header": map[string]any{"height": strconv.FormatInt(h, 10), "time": base.Add(time.Duration(h) * 5 * time.Second), "proposer_address": "AAAA"},
+				"data":   map[string]any{"txs": []string{"tx"}},
+			}}})
+		case "/block_results":
+			_ = json.NewEncoder(w).Encode(map[string]any{"result": map[string]any{"txs_results": []any{map[string]any{"code": 0, "gas_used": "10"}}}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer rpc.Close()
+
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+	_, m := metrics.New("biya", "dev", "none")
+	deps := jobDeps{
+		log:         logger,
+		m:           m,
+		chainHead:   collectors.NewChainHead(),
+		blockCursor: collectors.NewBlockCursor(),
+		breakers:    circuit.NewBreakers(logger, m, circuit.Options{}),
+		limiters:    apiclient.NewRateLimiters(0, 0),
+	}
+	cfg := config.Default()
+	cfg.Mock.Enabled = false
+	cfg.Node.TendermintRPCBaseURL = config.RPCEndpoints{{Name: "default", URL: rpc.URL}}
+
+	blocksJob := func(cfg config.Config) collectors.Job {
+		for _, j := range buildJobs(cfg, deps) {
+			if j.Name == "realtime_blocks" {
+				return j
+			}
+		}
+		t.Fatalf("realtime_blocks job not built")
+		return collectors.Job{}
+	}
+	first := blocksJob(cfg)
+	if err := first.Run(context.Background()); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+
+	// 重载改动 stake 配置：follower 被重建，但应从共享 cursor 继续，不重放已处理的区块
+	reloaded := cfg
+	reloaded.Stake.BaseURL = "http://stake.invalid"
+	second := blocksJob(reloaded)
+	if second.Fingerprint == first.Fingerprint {
+		t.Fatalf("expected stake base_url change to rebuild realtime_blocks")
+	}
+	if err := second.Run(context.Background()); err != nil {
+		t.Fatalf("run after reload: %v", err)
+	}
+	out := m.RenderText()
+	for _, want := range []string{
+		"\nbiya_blocks_total 5\n",
+		"\nbiya_tx_total{status=\"success\"} 5\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+
+	// 只改 follower 不使用的 stake 字段时不应重建
+	paged := cfg
+	paged.Stake.ValidatorsPageSize = 7
+	if blocksJob(paged).Fingerprint != first.Fingerprint {
+		t.Fatalf("stake paging change should not rebuild realtime_blocks")
+	}
+
+	head.Store(7)
+	if err := second.Run(context.Background()); err != nil {
+		t.Fatalf("run after new blocks: %v", err)
+	}
+	if out := m.RenderText(); !strings.Contains(out, "\nbiya_blocks_total 7\n") {
+		t.Fatalf("expected only new heights to be counted:\n%s", out)
+	}
+}
+
+func TestBuildJobs_APIKeyRotationKeepsFingerprints(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+	_, m := metrics.New("biya", "dev", "none")
+	deps := jobDeps{
+		log:              logger,
+		m:                m,
+		chainHead:        collectors.NewChainHead(),
+		blockCursor:      collectors.NewBlockCursor(),
+		breakers:         circuit.NewBreakers(logger, m, circuit.Options{}),
+		limiters:         apiclient.NewRateLimiters(0, 0),
+		stakeKey:         apiclient.NewAPIKey("old"),
+		explorerKey:      apiclient.NewAPIKey("old"),
+		validatorDetails: collectors.NewValidatorDetailCache(time.Minute, 1, 1),
+	}
+	cfg := config.Default()
+	cfg.Mock.Enabled = false
+	cfg.Node.WebSocket.Enabled = true
+	cfg.Node.LCDBaseURL = "http://lcd.invalid"
+	cfg.Explorer.Stream = true
+	cfg.Stake.APIKey = "old"
+	cfg.Explorer.APIKey = "old"
+
+	before := map[string]string{}
+	for _, j := range buildJobs(cfg, deps) {
+		before[j.Name] = j.Fingerprint
+	}
+
+	// 只轮换 API Key：由共享的 APIKey 原地更新，任何 job 都不应被重建
+	rotated := cfg
+	rotated.Stake.APIKey = "new"
+	rotated.Explorer.APIKey = "new"
+	after := buildJobs(rotated, deps)
+	if len(after) != len(before) {
+		t.Fatalf("job set changed: before=%v after=%d jobs", before, len(after))
+	}
+	for _, j := range after {
+		if fp, ok := before[j.Name]; !ok || fp != j.Fingerprint {
+			t.Fatalf("api_key rotation changed fingerprint of %s", j.Name)
+		}
+	}
+}
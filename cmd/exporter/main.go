@@ -7,12 +7,14 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
-	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/explorer"
-	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/stake"
-	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/tendermint"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/apiclient"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/circuit"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/httpmetrics"
 	"github.com/biya-coin/biya-dex-backend-exporter/internal/collectors"
 	"github.com/biya-coin/biya-dex-backend-exporter/internal/config"
 	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
@@ -26,11 +28,11 @@ var (
 )
 
 func main() {
-	var cfgPath string
-	flag.StringVar(&cfgPath, "config", "", "config file path (.yaml/.yml/.json). optional; if empty uses defaults only")
+	var cfgPaths stringList
+	flag.Var(&cfgPaths, "config", "config file path (.yaml/.yml/.json); repeatable, later files override earlier ones. optional; if empty uses defaults + BIYA_EXPORTER_* env only")
 	flag.Parse()
 
-	cfg, err := config.Load(cfgPath)
+	cfg, err := config.Load(cfgPaths...)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "load config failed:", err)
 		os.Exit(1)
@@ -46,35 +48,33 @@ func main() {
 	)
 
 	reg, m := metrics.New(cfg.Chain.ChainID, version, commit)
-
-	// adapters
-	stakeCli := stake.NewClient(cfg.Stake.BaseURL, cfg.Stake.APIKey, cfg.HTTPClient.Timeout)
-	tmCli := tendermint.NewClient(cfg.Node.TendermintRPCBaseURL, cfg.HTTPClient.Timeout)
-	explorerCli := explorer.NewClient(cfg.Explorer.BaseURL, cfg.Explorer.APIKey, cfg.HTTPClient.Timeout)
-
-	// collectors（按类型分组：node / stake / explorer）
-	// 注意：这里仅调整代码结构以便维护；不修改 job 名称与 interval，避免影响指标 source label。
-	nodeJobs := []collectors.Job{
-		collectors.NewJob("realtime_chain", cfg.ScrapeIntervals.Realtime, collectors.NewRealtimeChainCollector(logger, m, tmCli, cfg.Mock)),
-		collectors.NewJob("minute_chain", cfg.ScrapeIntervals.Minute, collectors.NewMinuteChainCollector(logger, m, tmCli, cfg.Mock, cfg.Node.MempoolCapacity)),
+	m.SetSeriesTTL(cfg.Metrics.SeriesTTL)
+	m.SetFallbackPolicy(fallbackPolicy(cfg.Metrics.Fallback))
+
+	breakers := circuit.NewBreakers(logger, m, circuitOptions(cfg.CircuitBreaker))
+	limiters := apiclient.NewRateLimiters(cfg.HTTPClient.RateLimit.RequestsPerSecond, cfg.HTTPClient.RateLimit.Burst)
+	details := collectors.NewValidatorDetailCache(cfg.Stake.ValidatorDetails.TTL, cfg.Stake.ValidatorDetails.Concurrency, cfg.Stake.ValidatorDetails.MaxPerRun)
+	deps := jobDeps{
+		log:              logger,
+		m:                m,
+		chainHead:        collectors.NewChainHead(),
+		blockCursor:      collectors.NewBlockCursor(),
+		breakers:         breakers,
+		limiters:         limiters,
+		stakeKey:         apiclient.NewAPIKey(cfg.Stake.APIKey),
+		explorerKey:      apiclient.NewAPIKey(cfg.Explorer.APIKey),
+		validatorDetails: details,
+		transport:        httpmetrics.NewTransport(m, nil),
+		proposals:        collectors.NewProposalCounter(),
+		slashing:         collectors.NewSlashingIngester(logger, cfg.Stake.Slashing.StateFile),
+		version:          version,
+		commit:           commit,
 	}
+	s := collectors.NewScheduler(logger, m, buildJobs(cfg, deps))
+	m.SetGauge("biya_exporter_config_last_reload_success", nil, 1)
+	m.SetGauge("biya_exporter_config_last_reload_success_timestamp_seconds", nil, float64(time.Now().Unix()))
 
-	stakeJobs := []collectors.Job{
-		collectors.NewJob("realtime_stake", cfg.ScrapeIntervals.Realtime, collectors.NewRealtimeStakeCollector(logger, m, stakeCli)),
-	}
-
-	// explorer jobs：当前 explorer/indexer 指标仍以内置 mock 方式由 node collectors 兜底，
-	// 后续接入真实 explorer client 后，可在这里新增独立 collector。
-	explorerJobs := []collectors.Job{
-		collectors.NewJob("realtime_explorer", cfg.ScrapeIntervals.Realtime, collectors.NewRealtimeExplorerCollector(logger, m, explorerCli, cfg.Mock)),
-	}
-
-	jobs := make([]collectors.Job, 0, len(nodeJobs)+len(stakeJobs)+len(explorerJobs))
-	jobs = append(jobs, nodeJobs...)
-	jobs = append(jobs, stakeJobs...)
-	jobs = append(jobs, explorerJobs...)
-
-	s := collectors.NewScheduler(logger, m, jobs)
+	rl := &reloader{paths: cfgPaths, current: cfg, deps: deps, sched: s}
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -86,7 +86,25 @@ func main() {
 		}
 	}()
 
+	// SIGHUP：重新加载配置（与 POST /-/reload 等价）
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				_ = rl.Reload(ctx)
+			}
+		}
+	}()
+
 	httpSrv := server.New(cfg.HTTP.ListenAddr, reg, s.Ready)
+	if cfg.HTTP.EnableLifecycle {
+		httpSrv.OnReload(rl.Reload)
+	}
 	if err := httpSrv.Start(ctx); err != nil {
 		logger.Error("http server stopped with error", "err", err)
 		os.Exit(1)
@@ -99,3 +117,80 @@ func main() {
 	case <-time.After(100 * time.Millisecond):
 	}
 }
+
+// reloader 负责配置热加载：重新读取并校验配置，成功后把 job 变更交给 scheduler。
+// 加载失败时保持当前配置继续运行，只更新 reload 指标并返回错误。
+type reloader struct {
+	mu      sync.Mutex
+	paths   []string
+	current config.Config
+	deps    jobDeps
+	sched   *collectors.Scheduler
+}
+
+func (r *reloader) Reload(_ context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	log, m := r.deps.log, r.deps.m
+	cfg, err := config.Load(r.paths...)
+	if err != nil {
+		m.SetGauge("biya_exporter_config_last_reload_success", nil, 0)
+		log.Error("config reload failed, keep running with previous config", "err", err)
+		return err
+	}
+
+	// 以下配置在进程生命周期内固定（监听地址、chain_id label、日志 handler），变更需重启生效。
+	if cfg.HTTP.ListenAddr != r.current.HTTP.ListenAddr {
+		log.Warn("http.listen_addr changed; restart required to take effect", "current", r.current.HTTP.ListenAddr, "new", cfg.HTTP.ListenAddr)
+	}
+	if cfg.HTTP.EnableLifecycle != r.current.HTTP.EnableLifecycle {
+		log.Warn("http.enable_lifecycle changed; restart required to take effect", "current", r.current.HTTP.EnableLifecycle, "new", cfg.HTTP.EnableLifecycle)
+	}
+	if cfg.Chain.ChainID != r.current.Chain.ChainID {
+		log.Warn("chain.chain_id changed; restart required to take effect", "current", r.current.Chain.ChainID, "new", cfg.Chain.ChainID)
+	}
+	if cfg.Log.Level != r.current.Log.Level {
+		log.Warn("log.level changed; restart required to take effect", "current", r.current.Log.Level, "new", cfg.Log.Level)
+	}
+	if cfg.Stake.Slashing.StateFile != r.current.Stake.Slashing.StateFile {
+		log.Warn("stake.slashing.state_file changed; restart required to take effect", "current", r.current.Stake.Slashing.StateFile, "new", cfg.Stake.Slashing.StateFile)
+	}
+
+	m.SetSeriesTTL(cfg.Metrics.SeriesTTL)
+	m.SetFallbackPolicy(fallbackPolicy(cfg.Metrics.Fallback))
+	r.deps.breakers.Configure(circuitOptions(cfg.CircuitBreaker))
+	r.deps.limiters.Configure(cfg.HTTPClient.RateLimit.RequestsPerSecond, cfg.HTTPClient.RateLimit.Burst)
+	r.deps.stakeKey.Set(cfg.Stake.APIKey)
+	r.deps.explorerKey.Set(cfg.Explorer.APIKey)
+	r.deps.validatorDetails.Configure(cfg.Stake.ValidatorDetails.TTL, cfg.Stake.ValidatorDetails.Concurrency, cfg.Stake.ValidatorDetails.MaxPerRun)
+	r.sched.Apply(buildJobs(cfg, r.deps))
+	r.current = cfg
+	m.SetGauge("biya_exporter_config_last_reload_success", nil, 1)
+	m.SetGauge("biya_exporter_config_last_reload_success_timestamp_seconds", nil, float64(time.Now().Unix()))
+	log.Info("config reloaded")
+	return nil
+}
+
+func circuitOptions(c config.CircuitBreakerConfig) circuit.Options {
+	return circuit.Options{FailureThreshold: c.FailureThreshold, MinBackoff: c.MinBackoff, MaxBackoff: c.MaxBackoff}
+}
+
+// fallbackPolicy 转换 metrics.fallback（取值已在 config.Load 中校验）。
+func fallbackPolicy(c config.FallbackConfig) (metrics.FallbackPolicy, map[string]metrics.FallbackPolicy) {
+	per := make(map[string]metrics.FallbackPolicy, len(c.Metrics))
+	for metric, p := range c.Metrics {
+		per[metric] = metrics.FallbackPolicy(p)
+	}
+	return metrics.FallbackPolicy(c.Default), per
+}
+
+// stringList 支持重复传入同一个 flag（例如 -config base.yaml -config prod.yaml）。
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
@@ -8,7 +8,11 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/circuit"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/httpmetrics"
 )
 
 // Envelope 为 Biya API 的通用响应包装：
@@ -27,31 +31,85 @@ type Envelope struct {
 }
 
 type Client struct {
-	baseURL string
-	apiKey  string
-	http    *http.Client
+	baseURL  string
+	apiKey   *APIKey
+	http     *http.Client
+	breakers *circuit.Breakers
+	retry    RetryOptions
+	limiter  *RateLimiters
 }
 
 func New(baseURL, apiKey string, timeout time.Duration) *Client {
 	return &Client{
 		baseURL: strings.TrimRight(baseURL, "/"),
-		apiKey:  strings.TrimSpace(apiKey),
+		apiKey:  NewAPIKey(apiKey),
 		http: &http.Client{
 			Timeout: timeout,
 		},
 	}
 }
 
+// APIKey 为可热更新的 Bearer token：同一上游的 client 共享一个实例，配置重载时通过 Set 轮换，
+// 不需要重建 client 与 collector（轮换 key 不产生抓取空档，也不丢失 collector 的内部状态）。
+type APIKey struct {
+	v atomic.Pointer[string]
+}
+
+func NewAPIKey(key string) *APIKey {
+	k := &APIKey{}
+	k.Set(key)
+	return k
+}
+
+// Set 替换 key，之后发出的请求立即使用新值（已建立的流式连接在重连时生效）。
+func (k *APIKey) Set(key string) {
+	key = strings.TrimSpace(key)
+	k.v.Store(&key)
+}
+
+func (k *APIKey) get() string {
+	return *k.v.Load()
+}
+
+// WithAPIKey 使用共享的 APIKey（跨配置重载保持不变），替换构造时传入的 key。
+func (c *Client) WithAPIKey(k *APIKey) *Client {
+	if k != nil {
+		c.apiKey = k
+	}
+	return c
+}
+
 func (c *Client) GetJSON(ctx context.Context, path string, q url.Values, out any) error {
 	return c.doJSON(ctx, http.MethodGet, path, q, out)
 }
 
-func (c *Client) doJSON(ctx context.Context, method, path string, q url.Values, out any) error {
+// OpenStream 发起长连接 GET（SSE / chunked JSON），返回未读取的响应，由调用方负责关闭 Body。
+// 流式响应不受 client timeout 限制（否则会被定期截断），生命周期由 ctx 控制。
+func (c *Client) OpenStream(ctx context.Context, path string, q url.Values) (*http.Response, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, path, q)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream, application/json")
+
+	stream := &http.Client{Transport: c.http.Transport}
+	resp, err := stream.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("http %d from %s", resp.StatusCode, req.URL.String())
+	}
+	return resp, nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, q url.Values) (*http.Request, error) {
 	if c.baseURL == "" {
-		return fmt.Errorf("api base url is empty")
+		return nil, fmt.Errorf("api base url is empty")
 	}
 	if path == "" || !strings.HasPrefix(path, "/") {
-		return fmt.Errorf("invalid api path: %q", path)
+		return nil, fmt.Errorf("invalid api path: %q", path)
 	}
 
 	u := c.baseURL + path
@@ -59,24 +117,63 @@ func (c *Client) doJSON(ctx context.Context, method, path string, q url.Values,
 		u += "?" + q.Encode()
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	req, err := http.NewRequestWithContext(httpmetrics.WithEndpoint(ctx, path), method, u, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	req.Header.Set("Accept", "application/json")
 	// 允许 apiKey 为空：有些环境/接口可能不强制鉴权；若上游需要鉴权则会返回 401/403，由调用方通过 source_up 体现。
-	if strings.TrimSpace(c.apiKey) != "" {
-		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if key := c.apiKey.get(); key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
 	}
+	return req, nil
+}
 
-	resp, err := c.http.Do(req)
+// WithCircuit 启用按 source 熔断（source 由调用方通过 circuit.WithSource 标记在 ctx 上）。
+// 流式接口（OpenStream）自带重连退避，不经过熔断。
+func (c *Client) WithCircuit(b *circuit.Breakers) *Client {
+	c.breakers = b
+	return c
+}
+
+// WithTransport 替换底层 RoundTripper（例如 httpmetrics.Transport），流式请求同样经过它。
+func (c *Client) WithTransport(rt http.RoundTripper) *Client {
+	c.http.Transport = rt
+	return c
+}
+
+// WithRetry 启用 GET 请求的重试，见 RetryOptions。
+func (c *Client) WithRetry(opts RetryOptions) *Client {
+	c.retry = opts
+	return c
+}
+
+// WithRateLimit 让每次请求（包括重试）先从 base URL 对应的令牌桶取令牌。
+func (c *Client) WithRateLimit(l *RateLimiters) *Client {
+	c.limiter = l
+	return c
+}
+
+// doJSON 在熔断保护下执行请求：一次调用内的全部重试只计为一次成功/失败。
+func (c *Client) doJSON(ctx context.Context, method, path string, q url.Values, out any) error {
+	return c.breakers.Do(ctx, func() error { return c.retryJSON(ctx, method, path, q, out) })
+}
+
+func (c *Client) roundTripJSON(ctx context.Context, method, path string, q url.Values, out any) error {
+	req, err := c.newRequest(ctx, method, path, q)
 	if err != nil {
 		return err
 	}
+	u := req.URL.String()
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return &transportError{err: err}
+	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("http %d from %s", resp.StatusCode, u)
+		return &statusError{code: resp.StatusCode, url: u, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
 	}
 
 	b, err := io.ReadAll(resp.Body)
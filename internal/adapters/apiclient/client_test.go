@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -124,3 +127,126 @@ func TestClient_GetJSON_EmptyAPIKey(t *testing.T) {
 		t.Fatalf("expected ok=true")
 	}
 }
+
+func TestClient_SharedAPIKeyRotatesWithoutRebuild(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	var seen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Get("Authorization"))
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"code":0,"data":{}}`))
+	}))
+	defer srv.Close()
+
+	key := NewAPIKey("old")
+	c := New(srv.URL, "ignored", 2*time.Second).WithAPIKey(key)
+	get := func() {
+		if err := c.GetJSON(context.Background(), "/x", nil, nil); err != nil {
+			t.Fatalf("GetJSON err: %v", err)
+		}
+	}
+	get()
+	key.Set(" new ")
+	get()
+	key.Set("")
+	get()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"Bearer old", "Bearer new", ""}; strings.Join(seen, ",") != strings.Join(want, ",") {
+		t.Fatalf("Authorization headers = %q, want %q", seen, want)
+	}
+}
+
+func TestClient_GetJSON_RetriesTransientFailures(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			_ = json.NewEncoder(w).Encode(map[string]any{"code": 0, "data": map[string]any{"ok": true}})
+		}
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "", 2*time.Second).WithRetry(RetryOptions{MaxRetries: 3, MinBackoff: time.Millisecond, MaxBackoff: 2 * time.Second})
+	var out struct {
+		OK bool `json:"ok"`
+	}
+	start := time.Now()
+	if err := c.GetJSON(context.Background(), "/x", nil, &out); err != nil || !out.OK {
+		t.Fatalf("GetJSON err = %v, out = %+v", err, out)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("calls = %d, want 3", n)
+	}
+	if d := time.Since(start); d < time.Second {
+		t.Fatalf("Retry-After not honoured, took %s", d)
+	}
+}
+
+func TestClient_GetJSON_DoesNotRetryClientErrorsOrLongRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path == "/throttled" {
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "", 2*time.Second).WithRetry(RetryOptions{MaxRetries: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Second})
+	err := c.GetJSON(context.Background(), "/x", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "http 403 from ") {
+		t.Fatalf("err = %v", err)
+	}
+	if err := c.GetJSON(context.Background(), "/throttled", nil, nil); err == nil {
+		t.Fatalf("expected 429 error")
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("calls = %d, want 2 (no retries)", n)
+	}
+}
+
+func TestRateLimiters_SharedBucketPerBaseURL(t *testing.T) {
+	t.Parallel()
+
+	l := NewRateLimiters(2, 2)
+	now := time.Unix(1735689600, 0)
+	l.now = func() time.Time { return now }
+
+	for i, want := range []time.Duration{0, 0, 500 * time.Millisecond, time.Second} {
+		if d, _ := l.reserve("https://stake"); d != want {
+			t.Fatalf("reserve #%d delay = %s, want %s", i, d, want)
+		}
+	}
+	// 其它 base URL 有独立的桶
+	if d, _ := l.reserve("https://explorer"); d != 0 {
+		t.Fatalf("other base url should not wait, delay = %s", d)
+	}
+	// 1.5s 后补回 3 个令牌，排队的两个请求已消耗完毕
+	now = now.Add(1500 * time.Millisecond)
+	if d, _ := l.reserve("https://stake"); d != 0 {
+		t.Fatalf("refilled bucket should not wait, delay = %s", d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l.Configure(0.001, 1)
+	if err := l.Wait(ctx, "https://stake"); err == nil {
+		t.Fatalf("cancelled wait should fail")
+	}
+}
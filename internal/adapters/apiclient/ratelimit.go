@@ -0,0 +1,90 @@
+package apiclient
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiters 为按 base URL 的令牌桶限速：同一上游（同一个 API Key 配额）的所有 client 共享一个桶，
+// 分页追赶时请求会被平滑到配置的速率，而不是在短时间内打满配额被上游限流。
+// 跨配置重载共享（桶内令牌不因 reload 重置），参数通过 Configure 更新。
+type RateLimiters struct {
+	mu     sync.Mutex
+	rps    float64
+	burst  int
+	now    func() time.Time
+	byBase map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiters 创建限速器；rps <= 0 表示不限速。burst 为桶容量（允许的瞬时并发），<=0 时取 1。
+func NewRateLimiters(rps float64, burst int) *RateLimiters {
+	return &RateLimiters{rps: rps, burst: max(burst, 1), now: time.Now, byBase: map[string]*bucket{}}
+}
+
+// Configure 更新速率与桶容量（配置热加载），已有桶的令牌数按新容量截断。
+func (l *RateLimiters) Configure(rps float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rps = rps
+	l.burst = max(burst, 1)
+	for _, b := range l.byBase {
+		b.tokens = min(b.tokens, float64(l.burst))
+	}
+}
+
+// Wait 阻塞到 baseURL 的桶中有可用令牌，或 ctx 结束。l 为 nil 时不限速。
+func (l *RateLimiters) Wait(ctx context.Context, baseURL string) error {
+	if l == nil {
+		return nil
+	}
+	delay, ok := l.reserve(baseURL)
+	if !ok || delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		// 未使用的令牌归还，避免取消的请求挤占后续请求的配额
+		l.cancel(baseURL)
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reserve 预占一个令牌并返回需要等待的时间；令牌数可以为负，表示已排队的请求。
+func (l *RateLimiters) reserve(baseURL string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rps <= 0 {
+		return 0, false
+	}
+	now := l.now()
+	b, ok := l.byBase[baseURL]
+	if !ok {
+		b = &bucket{tokens: float64(l.burst), last: now}
+		l.byBase[baseURL] = b
+	}
+	b.tokens = min(b.tokens+now.Sub(b.last).Seconds()*l.rps, float64(l.burst))
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0, true
+	}
+	return time.Duration(-b.tokens / l.rps * float64(time.Second)), true
+}
+
+func (l *RateLimiters) cancel(baseURL string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b, ok := l.byBase[baseURL]; ok {
+		b.tokens = min(b.tokens+1, float64(l.burst))
+	}
+}
@@ -0,0 +1,103 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RetryOptions 为 GET 请求（幂等，可安全重放）的重试参数：网络错误、5xx、429 才重试，
+// 等待时间在 [backoff/2, backoff] 内随机（避免多个 collector 同时重试），每次翻倍直到 MaxBackoff。
+// 上游给出 Retry-After 时按其等待；要求的等待超过 MaxBackoff 或 ctx 剩余时间时直接返回错误，交给下一个采集周期。
+type RetryOptions struct {
+	// MaxRetries 为首次请求之外的最大重试次数；0 表示不重试
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// statusError 为非 2xx 响应；错误文本保持 "http <code> from <url>"。
+type statusError struct {
+	code       int
+	url        string
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string { return fmt.Sprintf("http %d from %s", e.code, e.url) }
+
+// transportError 为请求未拿到响应（连接失败、超时、连接被重置等）。
+type transportError struct{ err error }
+
+func (e *transportError) Error() string { return e.err.Error() }
+func (e *transportError) Unwrap() error { return e.err }
+
+func (c *Client) retryJSON(ctx context.Context, method, path string, q url.Values, out any) error {
+	backoff := c.retry.MinBackoff
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.Wait(ctx, c.baseURL); err != nil {
+			return err
+		}
+		err := c.roundTripJSON(ctx, method, path, q, out)
+		if err == nil || ctx.Err() != nil || method != http.MethodGet || attempt >= c.retry.MaxRetries {
+			return err
+		}
+		wait, ok := c.retryDelay(err, backoff)
+		if !ok {
+			return err
+		}
+		if deadline, has := ctx.Deadline(); has && time.Until(deadline) < wait {
+			return err
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, c.retry.MaxBackoff)
+	}
+}
+
+// retryDelay 判断 err 是否可重试并返回等待时间。
+func (c *Client) retryDelay(err error, backoff time.Duration) (time.Duration, bool) {
+	var se *statusError
+	if errors.As(err, &se) {
+		if se.code != http.StatusTooManyRequests && se.code < 500 {
+			return 0, false
+		}
+		if se.retryAfter > 0 {
+			// 尊重上游的配额窗口：等不起就不重试，而不是提前重放
+			return se.retryAfter, se.retryAfter <= c.retry.MaxBackoff
+		}
+	} else if te := (*transportError)(nil); !errors.As(err, &te) {
+		// 解析失败、业务 code 错误等重试也不会变
+		return 0, false
+	}
+	if backoff <= 0 {
+		return 0, true
+	}
+	half := backoff / 2
+	return half + time.Duration(rand.Int64N(int64(backoff-half)+1)), true
+}
+
+// parseRetryAfter 解析 Retry-After（秒数或 HTTP 日期）；无法解析时返回 0。
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,223 @@
+// Package circuit 为上游数据源提供熔断：某个 source 连续失败后暂停调用（快速失败），
+// 按指数退避的间隔放行单个探测请求，探测成功才恢复正常调用。
+//
+// source 名与 biya_exporter_source_up 的 source label 一致，由调用方通过 WithSource 写入 ctx；
+// adapters 的 client 在发请求前后调用 Breakers.Do。未标记 source 的调用不受熔断影响。
+package circuit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+// ErrOpen 表示熔断打开期间的快速失败（没有真正发出请求）。
+var ErrOpen = errors.New("circuit open")
+
+// State 为熔断状态，数值即 biya_exporter_source_circuit_state 的取值。
+type State int
+
+const (
+	Closed   State = 0
+	HalfOpen State = 1
+	Open     State = 2
+)
+
+func (s State) String() string {
+	switch s {
+	case HalfOpen:
+		return "half_open"
+	case Open:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+type Options struct {
+	// FailureThreshold 为打开熔断所需的连续失败次数；<=0 关闭熔断
+	FailureThreshold int
+	// MinBackoff 为首次打开的时长，之后每次探测失败翻倍，直到 MaxBackoff
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// Breakers 按 source 名维护熔断器，跨配置重载共享（状态不因 reload 丢失）。
+type Breakers struct {
+	log *slog.Logger
+	m   *metrics.Metrics
+	now func() time.Time
+
+	mu     sync.Mutex
+	opts   Options
+	byName map[string]*breaker
+}
+
+type breaker struct {
+	state     State
+	failures  int
+	backoff   time.Duration
+	openUntil time.Time
+	// probing 为 half-open 状态下是否已有探测请求在进行（同一时刻只放行一个）
+	probing bool
+}
+
+func NewBreakers(log *slog.Logger, m *metrics.Metrics, opts Options) *Breakers {
+	return &Breakers{log: log, m: m, now: time.Now, opts: opts, byName: map[string]*breaker{}}
+}
+
+// Configure 更新熔断参数（配置热加载），已有熔断器的状态保留。
+func (b *Breakers) Configure(opts Options) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.opts = opts
+}
+
+type sourceKey struct{}
+
+// WithSource 标记 ctx 中后续请求所属的 source（与 biya_exporter_source_up 的 source label 相同）。
+func WithSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+// Source 返回 ctx 上标记的 source；未标记时为空串。
+func Source(ctx context.Context) string {
+	s, _ := ctx.Value(sourceKey{}).(string)
+	return s
+}
+
+// Do 在 ctx 所属 source 的熔断器保护下执行 fn。熔断打开时不调用 fn，直接返回包装了 ErrOpen 的错误。
+// 调用方自身取消（ctx 结束）导致的失败不计入 source 的失败次数。b 为 nil 时直接执行 fn。
+func (b *Breakers) Do(ctx context.Context, fn func() error) error {
+	source := Source(ctx)
+	if b == nil || source == "" {
+		return fn()
+	}
+	if err := b.allow(source); err != nil {
+		return err
+	}
+	err := fn()
+	if ctx.Err() != nil {
+		b.release(source)
+		return err
+	}
+	b.record(source, err)
+	return err
+}
+
+func (b *Breakers) allow(source string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.opts.FailureThreshold <= 0 {
+		return nil
+	}
+	br := b.getLocked(source)
+	switch br.state {
+	case Open:
+		now := b.now()
+		if now.Before(br.openUntil) {
+			return fmt.Errorf("%w: source %s, next probe in %s", ErrOpen, source, br.openUntil.Sub(now).Round(time.Second))
+		}
+		b.setStateLocked(source, br, HalfOpen)
+		br.probing = true
+		return nil
+	case HalfOpen:
+		if br.probing {
+			return fmt.Errorf("%w: source %s, probe in progress", ErrOpen, source)
+		}
+		br.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (b *Breakers) release(source string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if br, ok := b.byName[source]; ok {
+		br.probing = false
+	}
+}
+
+func (b *Breakers) record(source string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	br := b.getLocked(source)
+	br.probing = false
+	if err == nil {
+		br.failures = 0
+		br.backoff = 0
+		if br.state != Closed {
+			b.setStateLocked(source, br, Closed)
+			b.log.Info("source recovered, circuit closed", "source", source)
+		}
+		return
+	}
+	if b.opts.FailureThreshold <= 0 {
+		return
+	}
+
+	br.failures++
+	switch {
+	case br.state == HalfOpen:
+		br.backoff = min(br.backoff*2, b.opts.MaxBackoff)
+	case br.failures >= b.opts.FailureThreshold:
+		br.backoff = b.opts.MinBackoff
+	default:
+		return
+	}
+	if br.backoff <= 0 {
+		br.backoff = b.opts.MinBackoff
+	}
+	br.openUntil = b.now().Add(br.backoff)
+	wasOpen := br.state == HalfOpen
+	b.setStateLocked(source, br, Open)
+	// 只在状态变化时记录一次，熔断期间的快速失败由调用方降级为 debug
+	if wasOpen {
+		b.log.Warn("source probe failed, circuit reopened", "source", source, "retry_in", br.backoff, "err", err)
+	} else {
+		b.log.Warn("source failing, circuit opened", "source", source, "failures", br.failures, "retry_in", br.backoff, "err", err)
+	}
+}
+
+func (b *Breakers) getLocked(source string) *breaker {
+	br, ok := b.byName[source]
+	if !ok {
+		br = &breaker{}
+		b.byName[source] = br
+		b.m.SetGauge("biya_exporter_source_circuit_state", map[string]string{"source": source}, float64(Closed))
+	}
+	return br
+}
+
+func (b *Breakers) setStateLocked(source string, br *breaker, st State) {
+	br.state = st
+	b.m.SetGauge("biya_exporter_source_circuit_state", map[string]string{"source": source}, float64(st))
+}
+
+// IsOpen 判断 err 是否（只）由熔断快速失败组成：errors.Join 合并的多个错误全部为 ErrOpen 时才返回 true，
+// 混有真实失败时仍按失败处理。
+func IsOpen(err error) bool {
+	if err == nil {
+		return false
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs := joined.Unwrap()
+		if len(errs) == 0 {
+			return false
+		}
+		for _, e := range errs {
+			if !IsOpen(e) {
+				return false
+			}
+		}
+		return true
+	}
+	return errors.Is(err, ErrOpen)
+}
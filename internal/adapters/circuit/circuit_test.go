@@ -0,0 +1,121 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+func TestBreakers_OpenHalfOpenClosedWithBackoff(t *testing.T) {
+	t.Parallel()
+
+	_, m := metrics.New("biya", "dev", "none")
+	b := NewBreakers(slog.New(slog.NewTextHandler(io.Discard, nil)), m, Options{FailureThreshold: 2, MinBackoff: 10 * time.Second, MaxBackoff: 15 * time.Second})
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	ctx := WithSource(context.Background(), "stake_statistics")
+	calls := 0
+	failing := func() error { calls++; return errors.New("http 503") }
+	ok := func() error { calls++; return nil }
+	state := func() string {
+		out := m.RenderText()
+		prefix := "biya_exporter_source_circuit_state{source=\"stake_statistics\"} "
+		for _, line := range strings.Split(out, "\n") {
+			if strings.HasPrefix(line, prefix) {
+				return strings.TrimPrefix(line, prefix)
+			}
+		}
+		t.Fatalf("circuit state missing:\n%s", out)
+		return ""
+	}
+
+	_ = b.Do(ctx, failing)
+	if state() != "0" {
+		t.Fatalf("one failure should keep the circuit closed, state = %s", state())
+	}
+	_ = b.Do(ctx, failing)
+	if state() != "2" {
+		t.Fatalf("threshold reached, state = %s", state())
+	}
+
+	// 打开期间快速失败，不调用上游
+	if err := b.Do(ctx, failing); !errors.Is(err, ErrOpen) || calls != 2 {
+		t.Fatalf("open circuit should fail fast: err = %v, calls = %d", err, calls)
+	}
+
+	// 退避结束后放行一次探测；探测失败重新打开，等待翻倍但不超过 MaxBackoff
+	now = now.Add(10 * time.Second)
+	if err := b.Do(ctx, failing); errors.Is(err, ErrOpen) || calls != 3 {
+		t.Fatalf("probe should reach upstream: err = %v, calls = %d", err, calls)
+	}
+	if state() != "2" {
+		t.Fatalf("failed probe should reopen, state = %s", state())
+	}
+	now = now.Add(14 * time.Second)
+	if err := b.Do(ctx, ok); !errors.Is(err, ErrOpen) {
+		t.Fatalf("backoff should grow to 15s, err = %v", err)
+	}
+
+	// 探测进行中时其它调用仍然快速失败
+	now = now.Add(time.Second)
+	probeDone := make(chan struct{})
+	inProbe := make(chan struct{})
+	go func() {
+		_ = b.Do(ctx, func() error { close(inProbe); <-probeDone; return nil })
+	}()
+	<-inProbe
+	if state() != "1" {
+		t.Fatalf("probing should be half-open, state = %s", state())
+	}
+	if err := b.Do(ctx, ok); !errors.Is(err, ErrOpen) {
+		t.Fatalf("only one probe at a time, err = %v", err)
+	}
+	close(probeDone)
+	deadline := time.Now().Add(2 * time.Second)
+	for state() != "0" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if state() != "0" {
+		t.Fatalf("successful probe should close the circuit, state = %s", state())
+	}
+	if err := b.Do(ctx, ok); err != nil {
+		t.Fatalf("closed circuit should pass through: %v", err)
+	}
+
+	// 未标记 source 的调用不受熔断影响
+	if err := b.Do(context.Background(), failing); errors.Is(err, ErrOpen) {
+		t.Fatalf("unlabelled call should not be guarded")
+	}
+}
+
+func TestIsOpen_OnlyWhenEveryJoinedErrorIsOpen(t *testing.T) {
+	t.Parallel()
+
+	open := errors.New("wrapped: " + ErrOpen.Error())
+	openErr := errors.Join(wrap(ErrOpen), wrap(ErrOpen))
+	if !IsOpen(openErr) {
+		t.Fatalf("all open errors should be reported as open")
+	}
+	if IsOpen(errors.Join(wrap(ErrOpen), open)) {
+		t.Fatalf("a real failure mixed in should not be treated as open")
+	}
+	if IsOpen(nil) {
+		t.Fatalf("nil is not open")
+	}
+}
+
+func wrap(err error) error {
+	return &wrapped{err}
+}
+
+type wrapped struct{ err error }
+
+func (w *wrapped) Error() string { return "source x: " + w.err.Error() }
+func (w *wrapped) Unwrap() error { return w.err }
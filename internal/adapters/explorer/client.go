@@ -4,15 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
 	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/apiclient"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/circuit"
 )
 
 type Client struct {
 	api *apiclient.Client
+
+	// 流式接口（stream.go）断线重连的退避区间
+	streamMinBackoff time.Duration
+	streamMaxBackoff time.Duration
 }
 
 // CursorPage 为文档中常见的分页参数组合（page/pageSize/cursor）。
@@ -33,10 +39,42 @@ func NewClient(baseURL, apiKey string, timeout time.Duration) *Client {
 	// 移除末尾的斜杠，确保 baseURL 格式正确
 	baseURL = strings.TrimRight(baseURL, "/")
 	return &Client{
-		api: apiclient.New(baseURL, apiKey, timeout),
+		api:              apiclient.New(baseURL, apiKey, timeout),
+		streamMinBackoff: time.Second,
+		streamMaxBackoff: 30 * time.Second,
 	}
 }
 
+// WithCircuit 启用按 source 熔断，见 apiclient.Client.WithCircuit。
+func (c *Client) WithCircuit(b *circuit.Breakers) *Client {
+	c.api.WithCircuit(b)
+	return c
+}
+
+// WithTransport 替换底层 RoundTripper，见 apiclient.Client.WithTransport。
+func (c *Client) WithTransport(rt http.RoundTripper) *Client {
+	c.api.WithTransport(rt)
+	return c
+}
+
+// WithRetry 启用 GET 请求重试，见 apiclient.RetryOptions。
+func (c *Client) WithRetry(opts apiclient.RetryOptions) *Client {
+	c.api.WithRetry(opts)
+	return c
+}
+
+// WithAPIKey 使用可热更新的共享 API Key，见 apiclient.APIKey。
+func (c *Client) WithAPIKey(k *apiclient.APIKey) *Client {
+	c.api.WithAPIKey(k)
+	return c
+}
+
+// WithRateLimit 按 base URL 限速，见 apiclient.RateLimiters。
+func (c *Client) WithRateLimit(l *apiclient.RateLimiters) *Client {
+	c.api.WithRateLimit(l)
+	return c
+}
+
 func (c *Client) CheckHealth(ctx context.Context, service string) (json.RawMessage, error) {
 	q := url.Values{}
 	if strings.TrimSpace(service) != "" {
@@ -0,0 +1,302 @@
+package explorer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BlockUpdate 对应 api.explorer.v1.BlockUpdate（/api/v1/stream/blocks 的单条消息）。
+type BlockUpdate struct {
+	Block      BlockDTO `json:"block"`
+	UpdateType string   `json:"update_type"`
+	Timestamp  string   `json:"timestamp"`
+}
+
+// BlockDTO 只取我们用到的字段；数值字段在文档中均为字符串。
+type BlockDTO struct {
+	Height             string `json:"height"`
+	Proposer           string `json:"proposer"`
+	Moniker            string `json:"moniker"`
+	BlockHash          string `json:"block_hash"`
+	NumTxs             string `json:"num_txs"`
+	TotalTxs           string `json:"total_txs"`
+	Timestamp          string `json:"timestamp"`
+	BlockUnixTimestamp string `json:"block_unix_timestamp"`
+}
+
+// TransactionUpdate 对应 api.explorer.v1.TransactionUpdate（/api/v1/stream/transactions 的单条消息）。
+type TransactionUpdate struct {
+	Transaction TransactionDTO `json:"transaction"`
+	UpdateType  string         `json:"update_type"`
+	Timestamp   string         `json:"timestamp"`
+}
+
+type TransactionDTO struct {
+	BlockNumber string `json:"block_number"`
+	Hash        string `json:"hash"`
+	Code        uint32 `json:"code"`
+	GasWanted   string `json:"gas_wanted"`
+	GasUsed     string `json:"gas_used"`
+	TxType      string `json:"tx_type"`
+}
+
+// BlockStream 描述 StreamBlocks 的起点与回调；回调在读取 goroutine 中串行调用。
+type BlockStream struct {
+	// FromHeight 为首次连接的起始高度，0 表示从最新区块开始；重连时自动从最后看到的高度 +1 续传
+	FromHeight  int64
+	OnBlock     func(BlockUpdate)
+	OnConnState func(connected bool, err error)
+}
+
+// TransactionStream 描述 StreamTransactions 的过滤条件与回调。
+type TransactionStream struct {
+	// Types 为空表示全部交易类型
+	Types         []string
+	OnTransaction func(TransactionUpdate)
+	OnConnState   func(connected bool, err error)
+}
+
+// streamIdleTimeout：超过该时间未收到任何消息（含心跳）视为连接已死，主动断开重连。
+const streamIdleTimeout = 2 * time.Minute
+
+// WithStreamBackoff 调整流式接口断线重连的退避区间（默认 1s~30s，主要用于测试）。
+func (c *Client) WithStreamBackoff(min, max time.Duration) *Client {
+	c.streamMinBackoff, c.streamMaxBackoff = min, max
+	return c
+}
+
+// StreamBlocks 持续读取 /api/v1/stream/blocks，直到 ctx 结束（返回 ctx.Err()）。
+// 断线后按指数退避重连，并用 fromHeight=最后高度+1 续传，避免漏块或重复。
+func (c *Client) StreamBlocks(ctx context.Context, s BlockStream) error {
+	next := s.FromHeight
+	query := func() url.Values {
+		q := url.Values{}
+		if next > 0 {
+			q.Set("fromHeight", strconv.FormatInt(next, 10))
+		}
+		return q
+	}
+	return c.runStream(ctx, "/api/v1/stream/blocks", query, s.OnConnState, func(raw json.RawMessage) error {
+		var u BlockUpdate
+		if err := json.Unmarshal(raw, &u); err != nil {
+			return fmt.Errorf("decode block update: %w", err)
+		}
+		if h, err := strconv.ParseInt(u.Block.Height, 10, 64); err == nil {
+			if h < next {
+				// 续传边界上服务端可能重复推送，按高度去重（update_type=update 的同高度更新仍然放行）
+				if u.UpdateType != "update" {
+					return nil
+				}
+			} else {
+				next = h + 1
+			}
+		}
+		if s.OnBlock != nil {
+			s.OnBlock(u)
+		}
+		return nil
+	})
+}
+
+// StreamTransactions 持续读取 /api/v1/stream/transactions，直到 ctx 结束（返回 ctx.Err()）。
+// 该接口不支持续传，重连后从最新交易开始。
+func (c *Client) StreamTransactions(ctx context.Context, s TransactionStream) error {
+	query := func() url.Values {
+		q := url.Values{}
+		for _, t := range s.Types {
+			q.Add("types", t)
+		}
+		return q
+	}
+	return c.runStream(ctx, "/api/v1/stream/transactions", query, s.OnConnState, func(raw json.RawMessage) error {
+		var u TransactionUpdate
+		if err := json.Unmarshal(raw, &u); err != nil {
+			return fmt.Errorf("decode transaction update: %w", err)
+		}
+		if s.OnTransaction != nil {
+			s.OnTransaction(u)
+		}
+		return nil
+	})
+}
+
+func (c *Client) runStream(ctx context.Context, path string, query func() url.Values, onConnState func(bool, error), onMessage func(json.RawMessage) error) error {
+	backoff := c.streamMinBackoff
+	for {
+		received, err := c.readStream(ctx, path, query(), onConnState, onMessage)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if onConnState != nil {
+			onConnState(false, err)
+		}
+		if received {
+			backoff = c.streamMinBackoff
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+		backoff *= 2
+		if backoff > c.streamMaxBackoff {
+			backoff = c.streamMaxBackoff
+		}
+	}
+}
+
+// readStream 建立一次连接并读取到断开，返回是否收到过消息以及断开原因。
+func (c *Client) readStream(ctx context.Context, path string, q url.Values, onConnState func(bool, error), onMessage func(json.RawMessage) error) (bool, error) {
+	resp, err := c.api.OpenStream(ctx, path, q)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if onConnState != nil {
+		onConnState(true, nil)
+	}
+
+	// 空闲超时：服务端静默断开时 Read 可能永远阻塞，超时后关闭 Body 打断读取
+	var idleOnce sync.Once
+	idle := time.AfterFunc(streamIdleTimeout, func() {
+		idleOnce.Do(func() { _ = resp.Body.Close() })
+	})
+	defer idle.Stop()
+
+	dec := newStreamDecoder(resp.Header.Get("Content-Type"), resp.Body)
+	received := false
+	for {
+		raw, err := dec.next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return received, err
+		}
+		idle.Reset(streamIdleTimeout)
+		msg, err := unwrapStreamMessage(raw)
+		if err != nil {
+			return received, err
+		}
+		if msg == nil {
+			continue
+		}
+		received = true
+		if err := onMessage(msg); err != nil {
+			return received, err
+		}
+	}
+}
+
+// unwrapStreamMessage 兼容几种常见的包装：
+//   - grpc-gateway 流：{"result": {...}} / {"error": {...}}
+//   - Biya envelope：{"code":0,"message":"success","data":{...}}
+//   - 裸消息：{...}
+//
+// 返回 nil 表示心跳等可忽略的空消息。
+func unwrapStreamMessage(raw json.RawMessage) (json.RawMessage, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || raw[0] != '{' {
+		return nil, nil
+	}
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &top); err != nil {
+		return nil, fmt.Errorf("decode stream message: %w", err)
+	}
+	if e, ok := top["error"]; ok && len(e) > 0 && string(e) != "null" {
+		return nil, fmt.Errorf("stream error: %s", e)
+	}
+	if r, ok := top["result"]; ok {
+		return r, nil
+	}
+	if _, ok := top["code"]; ok {
+		var env struct {
+			Code    int             `json:"code"`
+			Message string          `json:"message"`
+			Data    json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(raw, &env); err != nil {
+			return nil, err
+		}
+		if env.Code != 0 && env.Code != 200 {
+			return nil, fmt.Errorf("api error code=%d message=%q", env.Code, env.Message)
+		}
+		return env.Data, nil
+	}
+	if len(top) == 0 {
+		return nil, nil
+	}
+	return raw, nil
+}
+
+type streamDecoder interface {
+	next() (json.RawMessage, error)
+}
+
+// newStreamDecoder 按 Content-Type 选择分帧方式：text/event-stream 为 SSE，其余按连续 JSON（NDJSON/chunked）解析。
+func newStreamDecoder(contentType string, r io.Reader) streamDecoder {
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil && mt == "text/event-stream" {
+		return &sseDecoder{r: bufio.NewReader(r)}
+	}
+	return &jsonSeqDecoder{dec: json.NewDecoder(r)}
+}
+
+type jsonSeqDecoder struct {
+	dec *json.Decoder
+}
+
+func (d *jsonSeqDecoder) next() (json.RawMessage, error) {
+	var raw json.RawMessage
+	if err := d.dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// sseDecoder 解析 Server-Sent Events：同一事件的多行 data 以换行拼接，空行结束一个事件；注释与其它字段忽略。
+type sseDecoder struct {
+	r *bufio.Reader
+}
+
+func (d *sseDecoder) next() (json.RawMessage, error) {
+	var data []byte
+	for {
+		line, err := d.r.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if len(data) > 0 {
+				return data, nil
+			}
+			if err == io.EOF {
+				return nil, err
+			}
+			continue
+		}
+		if v, ok := strings.CutPrefix(line, "data:"); ok {
+			if len(data) > 0 {
+				data = append(data, '\n')
+			}
+			data = append(data, strings.TrimPrefix(v, " ")...)
+		}
+		if err == io.EOF {
+			return data, nil
+		}
+	}
+}
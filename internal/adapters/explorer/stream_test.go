@@ -0,0 +1,113 @@
+package explorer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_StreamBlocks_SSEResumesFromLastHeight(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var fromHeights []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Path; got != "/api/v1/stream/blocks" {
+			t.Errorf("path = %q", got)
+		}
+		mu.Lock()
+		fromHeights = append(fromHeights, r.URL.Query().Get("fromHeight"))
+		conn := len(fromHeights)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		if conn == 1 {
+			// 第一次连接：推送 4、5 后断开；data 跨多行、夹带注释与心跳
+			fmt.Fprint(w, ": keepalive\n\n")
+			fmt.Fprint(w, "event: block\ndata: {\"result\":{\"block\":{\"height\":\"4\"},\n")
+			fmt.Fprint(w, "data: \"update_type\":\"new\"}}\n\n")
+			fmt.Fprint(w, "data: {\"result\":{\"block\":{\"height\":\"5\"}}}\r\n\r\n")
+			return
+		}
+		// 续传：服务端重复推送边界上的 5，应被去重
+		fmt.Fprint(w, "data: {\"result\":{\"block\":{\"height\":\"5\"}}}\n\n")
+		fmt.Fprint(w, "data: {\"result\":{\"block\":{\"height\":\"6\"}}}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := NewClient(srv.URL, "", 2*time.Second).WithStreamBackoff(10*time.Millisecond, 50*time.Millisecond)
+	var heights []string
+	var states []bool
+	err := c.StreamBlocks(ctx, BlockStream{
+		OnBlock: func(u BlockUpdate) {
+			heights = append(heights, u.Block.Height)
+			if u.Block.Height == "6" {
+				cancel()
+			}
+		},
+		OnConnState: func(connected bool, err error) {
+			states = append(states, connected)
+		},
+	})
+	if err != context.Canceled {
+		t.Fatalf("StreamBlocks err = %v", err)
+	}
+	if got := fmt.Sprint(heights); got != "[4 5 6]" {
+		t.Fatalf("heights = %s", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if got := fmt.Sprint(fromHeights); got != "[ 6]" {
+		t.Fatalf("fromHeight per connection = %q", got)
+	}
+	if got := fmt.Sprint(states); got != "[true false true]" {
+		t.Fatalf("conn states = %s", got)
+	}
+}
+
+func TestClient_StreamTransactions_ChunkedJSON(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query()["types"]; fmt.Sprint(got) != "[send vote]" {
+			t.Errorf("types = %v", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		// 连续 JSON：换行分隔与直接拼接都要支持，envelope 与裸消息混用
+		fmt.Fprint(w, `{"code":0,"message":"success","data":{"transaction":{"hash":"A","code":0}}}`+"\n")
+		fmt.Fprint(w, `{"transaction":{"hash":"B","code":5}}{"transaction":{"hash":"C","code":0}}`)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := NewClient(srv.URL, "", 2*time.Second)
+	var got []string
+	err := c.StreamTransactions(ctx, TransactionStream{
+		Types: []string{"send", "vote"},
+		OnTransaction: func(u TransactionUpdate) {
+			got = append(got, fmt.Sprintf("%s:%d", u.Transaction.Hash, u.Transaction.Code))
+			if len(got) == 3 {
+				cancel()
+			}
+		},
+	})
+	if err != context.Canceled {
+		t.Fatalf("StreamTransactions err = %v", err)
+	}
+	if s := fmt.Sprint(got); s != "[A:0 B:5 C:0]" {
+		t.Fatalf("transactions = %s", s)
+	}
+}
@@ -0,0 +1,168 @@
+// Package httpmetrics 提供带指标的 http.RoundTripper，供 apiclient、tendermint、lcd 共享：
+// 按 source（与 biya_exporter_source_up 相同）、endpoint（路径模板）与状态码记录上游请求的耗时、次数、响应大小，
+// 请求失败时按错误类型（dns/connect/tls/timeout/...）计数，便于区分“DNS 挂了”“401”与“慢 200”。
+package httpmetrics
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/circuit"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+// Transport 包装 next，记录 biya_exporter_upstream_* 指标。
+// 耗时从发出请求到响应 body 读完（或关闭）为止，慢响应体也会体现在 duration 中。
+type Transport struct {
+	m    *metrics.Metrics
+	next http.RoundTripper
+}
+
+// NewTransport 返回包装 next 的 Transport；next 为 nil 时使用 http.DefaultTransport。
+func NewTransport(m *metrics.Metrics, next http.RoundTripper) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Transport{m: m, next: next}
+}
+
+type endpointKey struct{}
+
+// WithEndpoint 标记请求的路径模板（例如 /api/v1/transaction/stats），作为 endpoint label。
+// adapters 在构造请求时写入；未标记的请求按 URL 路径推导（见 templatePath）。
+func WithEndpoint(ctx context.Context, endpoint string) context.Context {
+	return context.WithValue(ctx, endpointKey{}, endpoint)
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	source := circuit.Source(ctx)
+	if source == "" {
+		source = "unlabelled"
+	}
+	endpoint, _ := ctx.Value(endpointKey{}).(string)
+	if endpoint == "" {
+		endpoint = templatePath(req.URL.Path)
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		t.done(ctx, source, endpoint, "error", time.Since(start), -1, err)
+		return nil, err
+	}
+	code := strconv.Itoa(resp.StatusCode)
+	resp.Body = &countingBody{ReadCloser: resp.Body, done: func(n int64, readErr error) {
+		t.done(ctx, source, endpoint, code, time.Since(start), n, readErr)
+	}}
+	return resp, nil
+}
+
+// done 记录一次请求的结果；size < 0 表示没有响应。err 非空时（包括读 body 失败）计入错误类型。
+func (t *Transport) done(ctx context.Context, source, endpoint, code string, d time.Duration, size int64, err error) {
+	labels := map[string]string{"source": source, "endpoint": endpoint, "code": code}
+	t.m.IncCounter("biya_exporter_upstream_requests_total", labels)
+	t.m.ObserveHistogramMetric("biya_exporter_upstream_request_duration_seconds", labels, nil, d.Seconds())
+	if size >= 0 {
+		t.m.ObserveHistogramMetric("biya_exporter_upstream_response_size_bytes", map[string]string{"source": source, "endpoint": endpoint}, nil, float64(size))
+	}
+	if err != nil {
+		t.m.IncCounter("biya_exporter_upstream_request_errors_total", map[string]string{"source": source, "endpoint": endpoint, "class": classify(ctx, err)})
+	}
+}
+
+// countingBody 统计读取的字节数，在读到 EOF、读失败或 Close 时回调一次 done。
+type countingBody struct {
+	io.ReadCloser
+	n    int64
+	once sync.Once
+	done func(n int64, err error)
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	switch {
+	case err == io.EOF:
+		b.finish(nil)
+	case err != nil:
+		b.finish(err)
+	}
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	b.finish(nil)
+	return b.ReadCloser.Close()
+}
+
+func (b *countingBody) finish(err error) {
+	b.once.Do(func() { b.done(b.n, err) })
+}
+
+// classify 把请求错误归类为低基数的 class label。
+func classify(ctx context.Context, err error) string {
+	var dnsErr *net.DNSError
+	var certErr *tls.CertificateVerificationError
+	var unknownAuth x509.UnknownAuthorityError
+	var hostErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var opErr *net.OpError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled) && ctx.Err() != nil:
+		return "canceled"
+	case errors.As(err, &dnsErr):
+		return "dns"
+	case errors.As(err, &certErr), errors.As(err, &unknownAuth), errors.As(err, &hostErr),
+		errors.As(err, &invalidErr), errors.As(err, &recordErr), errors.As(err, &alertErr):
+		return "tls"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return "connect"
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return "connection"
+	default:
+		return "other"
+	}
+}
+
+// templatePath 为未标记 endpoint 的请求推导路径模板：看起来像 ID 的段（含数字的长段、纯数字）替换为 {id}，避免高基数。
+func templatePath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	parts := strings.Split(p, "/")
+	for i, seg := range parts {
+		if looksLikeID(seg) {
+			parts[i] = "{id}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+func looksLikeID(seg string) bool {
+	if seg == "" {
+		return false
+	}
+	digits := 0
+	for _, r := range seg {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	return digits == len(seg) || (digits > 0 && len(seg) >= 16)
+}
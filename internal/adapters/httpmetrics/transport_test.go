@@ -0,0 +1,100 @@
+package httpmetrics
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/circuit"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+func TestTransport_RecordsTemplatedEndpointCodeAndSize(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = io.WriteString(w, strings.Repeat("x", 300))
+	}))
+	defer srv.Close()
+
+	_, m := metrics.New("biya", "dev", "none")
+	cli := &http.Client{Transport: NewTransport(m, nil)}
+	get := func(ctx context.Context, path string, auth bool) {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+path, nil)
+		if auth {
+			req.Header.Set("Authorization", "Bearer k")
+		}
+		resp, err := cli.Do(req)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}
+
+	ctx := WithEndpoint(circuit.WithSource(context.Background(), "explorer_transaction_stats"), "/api/v1/transaction/stats")
+	get(ctx, "/demo/api/v1/transaction/stats?x=1", true)
+	get(ctx, "/demo/api/v1/transaction/stats?x=2", false)
+	// 未标记 endpoint 时按路径推导，ID 段折叠为 {id}
+	get(context.Background(), "/cosmos/gov/v1/proposals/42", true)
+
+	// 连接失败：没有响应，按错误类型计数
+	dead := httptest.NewServer(http.NotFoundHandler())
+	deadURL := dead.URL
+	dead.Close()
+	req, _ := http.NewRequestWithContext(circuit.WithSource(context.Background(), "tendermint_status"), http.MethodGet, deadURL+"/status", nil)
+	if _, err := cli.Do(req); err == nil {
+		t.Fatalf("expected connection error")
+	}
+
+	out := m.RenderText()
+	for _, want := range []string{
+		"\nbiya_exporter_upstream_requests_total{source=\"explorer_transaction_stats\",endpoint=\"/api/v1/transaction/stats\",code=\"200\"} 1\n",
+		"\nbiya_exporter_upstream_requests_total{source=\"explorer_transaction_stats\",endpoint=\"/api/v1/transaction/stats\",code=\"401\"} 1\n",
+		"\nbiya_exporter_upstream_request_duration_seconds_count{source=\"explorer_transaction_stats\",endpoint=\"/api/v1/transaction/stats\",code=\"200\"} 1\n",
+		"\nbiya_exporter_upstream_response_size_bytes_bucket{source=\"explorer_transaction_stats\",endpoint=\"/api/v1/transaction/stats\",le=\"256\"} 1\n",
+		"\nbiya_exporter_upstream_response_size_bytes_sum{source=\"explorer_transaction_stats\",endpoint=\"/api/v1/transaction/stats\"} 300\n",
+		"\nbiya_exporter_upstream_requests_total{source=\"unlabelled\",endpoint=\"/cosmos/gov/v1/proposals/{id}\",code=\"200\"} 1\n",
+		"\nbiya_exporter_upstream_requests_total{source=\"tendermint_status\",endpoint=\"/status\",code=\"error\"} 1\n",
+		"\nbiya_exporter_upstream_request_errors_total{source=\"tendermint_status\",endpoint=\"/status\",class=\"connect\"} 1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, tc := range []struct {
+		ctx  context.Context
+		err  error
+		want string
+	}{
+		{context.Background(), &net.DNSError{Err: "no such host", Name: "rpc.invalid", IsNotFound: true}, "dns"},
+		{context.Background(), fmt.Errorf("get: %w", x509.UnknownAuthorityError{}), "tls"},
+		{context.Background(), context.DeadlineExceeded, "timeout"},
+		{canceled, context.Canceled, "canceled"},
+		{context.Background(), &net.OpError{Op: "dial", Err: errors.New("connection refused")}, "connect"},
+		{context.Background(), io.ErrUnexpectedEOF, "connection"},
+		{context.Background(), errors.New("boom"), "other"},
+	} {
+		if got := classify(tc.ctx, tc.err); got != tc.want {
+			t.Fatalf("classify(%v) = %q, want %q", tc.err, got, tc.want)
+		}
+	}
+}
@@ -6,13 +6,18 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/circuit"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/httpmetrics"
 )
 
 type Client struct {
-	baseURL string
-	http    *http.Client
+	baseURL  string
+	http     *http.Client
+	breakers *circuit.Breakers
 }
 
 func NewClient(baseURL string, timeout time.Duration) *Client {
@@ -25,32 +30,105 @@ func NewClient(baseURL string, timeout time.Duration) *Client {
 }
 
 func (c *Client) StakingPool(ctx context.Context) (*StakingPoolResponse, error) {
+	var out StakingPoolResponse
+	if err := c.getJSON(ctx, "/cosmos/staking/v1beta1/pool", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) StakingParams(ctx context.Context) (*StakingParamsResponse, error) {
+	var out StakingParamsResponse
+	if err := c.getJSON(ctx, "/cosmos/staking/v1beta1/params", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) SlashingParams(ctx context.Context) (*SlashingParamsResponse, error) {
+	var out SlashingParamsResponse
+	if err := c.getJSON(ctx, "/cosmos/slashing/v1beta1/params", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) MintParams(ctx context.Context) (*MintParamsResponse, error) {
+	var out MintParamsResponse
+	if err := c.getJSON(ctx, "/cosmos/mint/v1beta1/params", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) Inflation(ctx context.Context) (*InflationResponse, error) {
+	var out InflationResponse
+	if err := c.getJSON(ctx, "/cosmos/mint/v1beta1/inflation", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SupplyOf 读取指定 denom 的总供应量（raw units 字符串）。
+// 使用 supply/by_denom（denom 放在 query 中，兼容 factory/... 等含 / 的 denom），一次请求即可，
+// 不需要翻页遍历链上全部 denom（IBC/peggy/factory 等可能很多）。
+func (c *Client) SupplyOf(ctx context.Context, denom string) (string, error) {
+	q := url.Values{}
+	q.Set("denom", denom)
+	var out SupplyOfResponse
+	if err := c.getJSON(ctx, "/cosmos/bank/v1beta1/supply/by_denom", q, &out); err != nil {
+		return "", err
+	}
+	if out.Amount.Amount == "" {
+		return "", fmt.Errorf("lcd supply: denom %q not found", denom)
+	}
+	return out.Amount.Amount, nil
+}
+
+// WithCircuit 启用按 source 熔断（source 由调用方通过 circuit.WithSource 标记在 ctx 上）。
+func (c *Client) WithCircuit(b *circuit.Breakers) *Client {
+	c.breakers = b
+	return c
+}
+
+// WithTransport 替换底层 RoundTripper（例如 httpmetrics.Transport）。
+func (c *Client) WithTransport(rt http.RoundTripper) *Client {
+	c.http.Transport = rt
+	return c
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, q url.Values, out any) error {
+	return c.breakers.Do(ctx, func() error { return c.fetchJSON(ctx, path, q, out) })
+}
+
+func (c *Client) fetchJSON(ctx context.Context, path string, q url.Values, out any) error {
 	if c.baseURL == "" {
-		return nil, fmt.Errorf("lcd base url is empty")
+		return fmt.Errorf("lcd base url is empty")
+	}
+	u := c.baseURL + path
+	if q != nil && len(q) > 0 {
+		u += "?" + q.Encode()
 	}
-	u := c.baseURL + "/cosmos/staking/v1beta1/pool"
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+
+	req, err := http.NewRequestWithContext(httpmetrics.WithEndpoint(ctx, path), http.MethodGet, u, nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	req.Header.Set("Accept", "application/json")
 
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("http %d from %s", resp.StatusCode, u)
+		return fmt.Errorf("http %d from %s", resp.StatusCode, u)
 	}
 	b, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	var out StakingPoolResponse
-	if err := json.Unmarshal(b, &out); err != nil {
-		return nil, err
-	}
-	return &out, nil
+	// 兼容性优先：不做 DisallowUnknownFields，避免上游加字段导致 exporter 直接挂掉。
+	return json.Unmarshal(b, out)
 }
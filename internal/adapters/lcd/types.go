@@ -1,8 +1,55 @@
 package lcd
 
+// 注意：Cosmos SDK LCD 返回结构会随版本变化，本类型只取我们用到的字段。
+// 金额/比例类字段在 LCD 中均以字符串返回（大整数或 18 位精度的 Dec），由调用方按需解析。
+
 type StakingPoolResponse struct {
 	Pool struct {
 		BondedTokens string `json:"bonded_tokens"`
 		NotBonded    string `json:"not_bonded_tokens"`
 	} `json:"pool"`
 }
+
+type StakingParamsResponse struct {
+	Params struct {
+		UnbondingTime     string `json:"unbonding_time"`
+		MaxValidators     int    `json:"max_validators"`
+		MaxEntries        int    `json:"max_entries"`
+		HistoricalEntries int    `json:"historical_entries"`
+		BondDenom         string `json:"bond_denom"`
+	} `json:"params"`
+}
+
+type SlashingParamsResponse struct {
+	Params struct {
+		SignedBlocksWindow      string `json:"signed_blocks_window"`
+		MinSignedPerWindow      string `json:"min_signed_per_window"`
+		DowntimeJailDuration    string `json:"downtime_jail_duration"`
+		SlashFractionDoubleSign string `json:"slash_fraction_double_sign"`
+		SlashFractionDowntime   string `json:"slash_fraction_downtime"`
+	} `json:"params"`
+}
+
+type MintParamsResponse struct {
+	Params struct {
+		MintDenom           string `json:"mint_denom"`
+		InflationRateChange string `json:"inflation_rate_change"`
+		InflationMax        string `json:"inflation_max"`
+		InflationMin        string `json:"inflation_min"`
+		GoalBonded          string `json:"goal_bonded"`
+		BlocksPerYear       string `json:"blocks_per_year"`
+	} `json:"params"`
+}
+
+type InflationResponse struct {
+	Inflation string `json:"inflation"`
+}
+
+type Coin struct {
+	Denom  string `json:"denom"`
+	Amount string `json:"amount"`
+}
+
+type SupplyOfResponse struct {
+	Amount Coin `json:"amount"`
+}
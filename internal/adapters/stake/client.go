@@ -4,11 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/apiclient"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/circuit"
 )
 
 type Client struct {
@@ -42,6 +45,36 @@ func NewClient(baseURL, apiKey string, timeout time.Duration) *Client {
 	}
 }
 
+// WithCircuit 启用按 source 熔断，见 apiclient.Client.WithCircuit。
+func (c *Client) WithCircuit(b *circuit.Breakers) *Client {
+	c.api.WithCircuit(b)
+	return c
+}
+
+// WithTransport 替换底层 RoundTripper，见 apiclient.Client.WithTransport。
+func (c *Client) WithTransport(rt http.RoundTripper) *Client {
+	c.api.WithTransport(rt)
+	return c
+}
+
+// WithRetry 启用 GET 请求重试，见 apiclient.RetryOptions。
+func (c *Client) WithRetry(opts apiclient.RetryOptions) *Client {
+	c.api.WithRetry(opts)
+	return c
+}
+
+// WithAPIKey 使用可热更新的共享 API Key，见 apiclient.APIKey。
+func (c *Client) WithAPIKey(k *apiclient.APIKey) *Client {
+	c.api.WithAPIKey(k)
+	return c
+}
+
+// WithRateLimit 按 base URL 限速，见 apiclient.RateLimiters。
+func (c *Client) WithRateLimit(l *apiclient.RateLimiters) *Client {
+	c.api.WithRateLimit(l)
+	return c
+}
+
 func (c *Client) GetValidators(ctx context.Context, page, pageSize int) (*GetValidatorsResponse, error) {
 	if page <= 0 {
 		page = 1
@@ -61,25 +94,63 @@ func (c *Client) GetValidators(ctx context.Context, page, pageSize int) (*GetVal
 	return &out, nil
 }
 
-func (c *Client) GetValidatorsAll(ctx context.Context, pageSize int, maxPages int) ([]Validator, error) {
+// ValidatorList 为分页拉取的验证人全集。
+type ValidatorList struct {
+	Validators []Validator
+	// Total 为上游 pagination.total；无法解析时为 -1
+	Total int
+	// Pages 为实际请求的页数
+	Pages int
+	// Truncated 为 true 表示达到页数预算时上游仍有下一页（Validators 不完整）
+	Truncated bool
+}
+
+// ListValidators 逐页拉取验证人，最多 maxPages 页（<=0 时为 1000）。分页期间集合变化导致的跨页重复按 operatorAddress 去重。
+// 达到页数预算时返回已拉取的部分并标记 Truncated，由调用方决定如何上报。
+func (c *Client) ListValidators(ctx context.Context, pageSize int, maxPages int) (*ValidatorList, error) {
 	if pageSize <= 0 {
 		pageSize = 100
 	}
 	if maxPages <= 0 {
 		maxPages = 1000
 	}
-	var all []Validator
-	for page := 1; page <= maxPages; page++ {
+	out := &ValidatorList{Total: -1}
+	seen := make(map[string]struct{})
+	for page := 1; ; page++ {
+		if page > maxPages {
+			out.Truncated = true
+			return out, nil
+		}
 		resp, err := c.GetValidators(ctx, page, pageSize)
 		if err != nil {
 			return nil, err
 		}
-		all = append(all, resp.Validators...)
-		if !resp.Pagination.HasNext {
-			return all, nil
+		out.Pages++
+		if n, err := strconv.Atoi(strings.TrimSpace(resp.Pagination.Total)); err == nil {
+			out.Total = n
 		}
+		for _, v := range resp.Validators {
+			if _, dup := seen[v.OperatorAddress]; dup && v.OperatorAddress != "" {
+				continue
+			}
+			seen[v.OperatorAddress] = struct{}{}
+			out.Validators = append(out.Validators, v)
+		}
+		if !resp.Pagination.HasNext || len(resp.Validators) == 0 {
+			return out, nil
+		}
+	}
+}
+
+func (c *Client) GetValidatorsAll(ctx context.Context, pageSize int, maxPages int) ([]Validator, error) {
+	list, err := c.ListValidators(ctx, pageSize, maxPages)
+	if err != nil {
+		return nil, err
+	}
+	if list.Truncated {
+		return nil, fmt.Errorf("stake.GetValidatorsAll exceeded maxPages=%d", list.Pages)
 	}
-	return nil, fmt.Errorf("stake.GetValidatorsAll exceeded maxPages=%d", maxPages)
+	return list.Validators, nil
 }
 
 func (c *Client) CheckHealth(ctx context.Context, service string) (json.RawMessage, error) {
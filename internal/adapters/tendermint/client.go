@@ -10,11 +10,15 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/circuit"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/httpmetrics"
 )
 
 type Client struct {
-	baseURL string
-	http    *http.Client
+	baseURL  string
+	http     *http.Client
+	breakers *circuit.Breakers
 }
 
 func NewClient(baseURL string, timeout time.Duration) *Client {
@@ -46,6 +50,69 @@ func (c *Client) Block(ctx context.Context, height int64) (*BlockResponse, error
 	return &out, nil
 }
 
+// BlockResults 返回指定高度的执行结果（每笔交易的 code/gas_wanted/gas_used）；height<=0 表示最新高度。
+func (c *Client) BlockResults(ctx context.Context, height int64) (*BlockResultsResponse, error) {
+	q := url.Values{}
+	if height > 0 {
+		q.Set("height", strconv.FormatInt(height, 10))
+	}
+	var out BlockResultsResponse
+	if err := c.getJSON(ctx, "/block_results", q, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ConsensusParams 返回指定高度生效的共识参数（block.max_gas 等）；height<=0 表示最新高度。
+func (c *Client) ConsensusParams(ctx context.Context, height int64) (*ConsensusParamsResponse, error) {
+	q := url.Values{}
+	if height > 0 {
+		q.Set("height", strconv.FormatInt(height, 10))
+	}
+	var out ConsensusParamsResponse
+	if err := c.getJSON(ctx, "/consensus_params", q, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Validators 返回指定高度 validator set 的一页（CometBFT 单页最多 100 条）。
+func (c *Client) Validators(ctx context.Context, height int64, page, perPage int) (*ValidatorsResponse, error) {
+	q := url.Values{}
+	if height > 0 {
+		q.Set("height", strconv.FormatInt(height, 10))
+	}
+	if page > 0 {
+		q.Set("page", strconv.Itoa(page))
+	}
+	if perPage > 0 {
+		q.Set("per_page", strconv.Itoa(perPage))
+	}
+	var out ValidatorsResponse
+	if err := c.getJSON(ctx, "/validators", q, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ValidatorSet 翻页拉取指定高度的完整 validator set，顺序与该高度 commit 签名的顺序一致。
+func (c *Client) ValidatorSet(ctx context.Context, height int64) ([]Validator, error) {
+	const perPage = 100
+	var all []Validator
+	for page := 1; page <= 100; page++ {
+		resp, err := c.Validators(ctx, height, page, perPage)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, resp.Result.Validators...)
+		total, err := strconv.Atoi(resp.Result.Total)
+		if err != nil || len(all) >= total || len(resp.Result.Validators) == 0 {
+			return all, nil
+		}
+	}
+	return nil, fmt.Errorf("validator set at height %d exceeds 100 pages", height)
+}
+
 func (c *Client) NumUnconfirmedTxs(ctx context.Context) (*NumUnconfirmedTxsResponse, error) {
 	var out NumUnconfirmedTxsResponse
 	if err := c.getJSON(ctx, "/num_unconfirmed_txs", nil, &out); err != nil {
@@ -54,7 +121,23 @@ func (c *Client) NumUnconfirmedTxs(ctx context.Context) (*NumUnconfirmedTxsRespo
 	return &out, nil
 }
 
+// WithCircuit 启用按 source 熔断（source 由调用方通过 circuit.WithSource 标记在 ctx 上）。
+func (c *Client) WithCircuit(b *circuit.Breakers) *Client {
+	c.breakers = b
+	return c
+}
+
+// WithTransport 替换底层 RoundTripper（例如 httpmetrics.Transport）。
+func (c *Client) WithTransport(rt http.RoundTripper) *Client {
+	c.http.Transport = rt
+	return c
+}
+
 func (c *Client) getJSON(ctx context.Context, path string, q url.Values, out any) error {
+	return c.breakers.Do(ctx, func() error { return c.fetchJSON(ctx, path, q, out) })
+}
+
+func (c *Client) fetchJSON(ctx context.Context, path string, q url.Values, out any) error {
 	if c.baseURL == "" {
 		return fmt.Errorf("tendermint rpc base url is empty")
 	}
@@ -63,7 +146,7 @@ func (c *Client) getJSON(ctx context.Context, path string, q url.Values, out any
 		u += "?" + q.Encode()
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	req, err := http.NewRequestWithContext(httpmetrics.WithEndpoint(ctx, path), http.MethodGet, u, nil)
 	if err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ type StatusResponse struct {
 	Result struct {
 		NodeInfo struct {
 			Network string `json:"network"`
+			Moniker string `json:"moniker"`
 		} `json:"node_info"`
 		SyncInfo struct {
 			LatestBlockHeight string    `json:"latest_block_height"`
@@ -26,17 +27,81 @@ type BlockResponse struct {
 			Header struct {
 				Height string    `json:"height"`
 				Time   time.Time `json:"time"`
+				// 出块者的共识地址（hex）
+				ProposerAddress string `json:"proposer_address"`
 			} `json:"header"`
 			Data struct {
 				Txs []string `json:"txs"`
 			} `json:"data"`
+			// LastCommit 为上一个区块（Height-1）的提交签名
+			LastCommit struct {
+				Height     string      `json:"height"`
+				Signatures []CommitSig `json:"signatures"`
+			} `json:"last_commit"`
 		} `json:"block"`
 	} `json:"result"`
 }
 
+// BlockIDFlag 取值（CometBFT types.BlockIDFlag）
+const (
+	BlockIDFlagAbsent = 1
+	BlockIDFlagCommit = 2
+	BlockIDFlagNil    = 3
+)
+
+// CommitSig 为 last_commit 中的一个签名，顺序与该高度的 validator set 一致；
+// absent 的签名 validator_address 为空，需要按下标对应 /validators 的结果。
+type CommitSig struct {
+	BlockIDFlag      int       `json:"block_id_flag"`
+	ValidatorAddress string    `json:"validator_address"`
+	Timestamp        time.Time `json:"timestamp"`
+}
+
+type ValidatorsResponse struct {
+	Result struct {
+		BlockHeight string      `json:"block_height"`
+		Validators  []Validator `json:"validators"`
+		Count       string      `json:"count"`
+		Total       string      `json:"total"`
+	} `json:"result"`
+}
+
+// Validator 为 /validators 返回的共识层验证人；Address 为共识地址（大写 hex）。
+type Validator struct {
+	Address     string `json:"address"`
+	VotingPower string `json:"voting_power"`
+}
+
 type NumUnconfirmedTxsResponse struct {
 	Result struct {
 		NTxs  string `json:"n_txs"`
 		Total string `json:"total"`
 	} `json:"result"`
 }
+
+type BlockResultsResponse struct {
+	Result struct {
+		Height     string     `json:"height"`
+		TxsResults []TxResult `json:"txs_results"`
+	} `json:"result"`
+}
+
+// TxResult 中的 gas 字段在 RPC JSON 中为字符串形式的 int64。
+type TxResult struct {
+	Code      uint32 `json:"code"`
+	GasWanted string `json:"gas_wanted"`
+	GasUsed   string `json:"gas_used"`
+}
+
+type ConsensusParamsResponse struct {
+	Result struct {
+		BlockHeight     string `json:"block_height"`
+		ConsensusParams struct {
+			Block struct {
+				MaxBytes string `json:"max_bytes"`
+				// MaxGas 为 "-1" 表示不限制
+				MaxGas string `json:"max_gas"`
+			} `json:"block"`
+		} `json:"consensus_params"`
+	} `json:"result"`
+}
@@ -0,0 +1,292 @@
+package tendermint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// 常用订阅查询
+const (
+	QueryNewBlock = "tm.event='NewBlock'"
+	QueryTx       = "tm.event='Tx'"
+)
+
+// NewBlockEvent 为 NewBlock 事件中我们关心的字段。
+type NewBlockEvent struct {
+	Height   int64
+	Time     time.Time
+	Proposer string
+	TxCount  int
+}
+
+// TxEvent 为 Tx 事件中我们关心的字段。
+type TxEvent struct {
+	Height int64
+	Index  int
+	Result TxResult
+}
+
+// Event 为一次订阅推送；Block 与 Tx 二者只有一个非 nil。
+type Event struct {
+	Query string
+	Block *NewBlockEvent
+	Tx    *TxEvent
+}
+
+// Subscription 描述 WSClient.Run 的订阅与回调。
+// OnEvent / OnConnState 在 Run 所在 goroutine 中串行调用，回调内不要长时间阻塞。
+type Subscription struct {
+	Queries []string
+	OnEvent func(Event)
+	// OnConnState 在订阅成功（connected=true, err=nil）以及每次连接失败/断开（connected=false）时调用，
+	// 上层据此切换 WebSocket / 轮询。
+	OnConnState func(connected bool, err error)
+}
+
+// WSClient 为 CometBFT /websocket 的订阅客户端：断线后按指数退避重连并重新订阅。
+type WSClient struct {
+	url     string
+	timeout time.Duration
+
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	// pingInterval 为心跳间隔；超过 3 个心跳周期收不到任何消息（含 pong）视为连接已死
+	pingInterval time.Duration
+}
+
+// NewWSClient 由 RPC base url（http/https）推导 ws/wss 的 /websocket 地址。
+func NewWSClient(baseURL string, timeout time.Duration) *WSClient {
+	u := strings.TrimRight(baseURL, "/")
+	switch {
+	case strings.HasPrefix(u, "https://"):
+		u = "wss://" + strings.TrimPrefix(u, "https://")
+	case strings.HasPrefix(u, "http://"):
+		u = "ws://" + strings.TrimPrefix(u, "http://")
+	}
+	if u != "" && !strings.HasSuffix(u, "/websocket") {
+		u += "/websocket"
+	}
+	return &WSClient{url: u, timeout: timeout, minBackoff: time.Second, maxBackoff: 30 * time.Second, pingInterval: 20 * time.Second}
+}
+
+// WithBackoff 调整重连退避区间（主要用于测试）。
+func (c *WSClient) WithBackoff(min, max time.Duration) *WSClient {
+	c.minBackoff, c.maxBackoff = min, max
+	return c
+}
+
+// URL 返回实际连接的 websocket 地址。
+func (c *WSClient) URL() string {
+	return c.url
+}
+
+// Run 持续订阅直到 ctx 结束；每次断线都会回调 OnConnState(false, err) 并在退避后重连、重新订阅。
+// 只有 ctx 结束时才返回（返回 ctx.Err()）。
+func (c *WSClient) Run(ctx context.Context, sub Subscription) error {
+	if c.url == "" {
+		return fmt.Errorf("tendermint websocket url is empty")
+	}
+	backoff := c.minBackoff
+	for {
+		subscribed, err := c.runOnce(ctx, sub)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if sub.OnConnState != nil {
+			sub.OnConnState(false, err)
+		}
+		if subscribed {
+			// 成功订阅过说明服务端可用，重置退避
+			backoff = c.minBackoff
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+		backoff *= 2
+		if backoff > c.maxBackoff {
+			backoff = c.maxBackoff
+		}
+	}
+}
+
+// runOnce 建立一次连接并读取事件直到断开，返回是否曾全部订阅成功以及断开原因。
+func (c *WSClient) runOnce(ctx context.Context, sub Subscription) (bool, error) {
+	dialer := websocket.Dialer{HandshakeTimeout: c.timeout, Proxy: http.ProxyFromEnvironment}
+	conn, _, err := dialer.DialContext(ctx, c.url, nil)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	// ctx 结束时主动关闭连接，打断阻塞中的 ReadMessage
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	// 心跳：服务端静默断开（NAT 超时等）时靠读超时发现，而不是永远阻塞
+	deadline := func() { _ = conn.SetReadDeadline(time.Now().Add(3 * c.pingInterval)) }
+	deadline()
+	conn.SetPongHandler(func(string) error { deadline(); return nil })
+	pingDone := make(chan struct{})
+	defer close(pingDone)
+	go func() {
+		t := time.NewTicker(c.pingInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-pingDone:
+				return
+			case <-t.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.timeout)); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	pending := make(map[int]string, len(sub.Queries))
+	for i, q := range sub.Queries {
+		id := i + 1
+		req := rpcRequest{JSONRPC: "2.0", ID: id, Method: "subscribe", Params: map[string]string{"query": q}}
+		if err := conn.WriteJSON(req); err != nil {
+			return false, err
+		}
+		pending[id] = q
+	}
+
+	subscribed := false
+	for {
+		_, b, err := conn.ReadMessage()
+		if err != nil {
+			return subscribed, err
+		}
+		deadline()
+		var msg rpcMessage
+		if err := json.Unmarshal(b, &msg); err != nil {
+			return subscribed, fmt.Errorf("decode websocket message: %w", err)
+		}
+		if msg.Error != nil {
+			return subscribed, fmt.Errorf("websocket rpc error %d: %s", msg.Error.Code, msg.Error.Message)
+		}
+		if msg.ID != nil {
+			// 订阅应答：全部 query 都确认后才视为连接可用
+			if _, ok := pending[*msg.ID]; ok && msg.Result.Query == "" {
+				delete(pending, *msg.ID)
+				if len(pending) == 0 && !subscribed {
+					subscribed = true
+					if sub.OnConnState != nil {
+						sub.OnConnState(true, nil)
+					}
+				}
+				continue
+			}
+		}
+		ev, ok, err := decodeEvent(msg.Result)
+		if err != nil {
+			return subscribed, err
+		}
+		if ok && sub.OnEvent != nil {
+			sub.OnEvent(ev)
+		}
+	}
+}
+
+type rpcRequest struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      int               `json:"id"`
+	Method  string            `json:"method"`
+	Params  map[string]string `json:"params"`
+}
+
+type rpcMessage struct {
+	// 事件推送的 id 与订阅请求相同（CometBFT 行为）或带 "#event" 后缀，因此用 json.RawMessage 兼容解析
+	RawID  json.RawMessage `json:"id"`
+	ID     *int            `json:"-"`
+	Result rpcEventResult  `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    string `json:"data"`
+	} `json:"error"`
+}
+
+func (m *rpcMessage) UnmarshalJSON(b []byte) error {
+	type plain rpcMessage
+	if err := json.Unmarshal(b, (*plain)(m)); err != nil {
+		return err
+	}
+	var id int
+	if len(m.RawID) > 0 && json.Unmarshal(m.RawID, &id) == nil {
+		m.ID = &id
+	}
+	return nil
+}
+
+type rpcEventResult struct {
+	Query string `json:"query"`
+	Data  struct {
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	} `json:"data"`
+}
+
+func decodeEvent(r rpcEventResult) (Event, bool, error) {
+	switch r.Data.Type {
+	case "tendermint/event/NewBlock":
+		var v struct {
+			Block struct {
+				Header struct {
+					Height          string    `json:"height"`
+					Time            time.Time `json:"time"`
+					ProposerAddress string    `json:"proposer_address"`
+				} `json:"header"`
+				Data struct {
+					Txs []string `json:"txs"`
+				} `json:"data"`
+			} `json:"block"`
+		}
+		if err := json.Unmarshal(r.Data.Value, &v); err != nil {
+			return Event{}, false, fmt.Errorf("decode NewBlock event: %w", err)
+		}
+		h, err := strconv.ParseInt(v.Block.Header.Height, 10, 64)
+		if err != nil {
+			return Event{}, false, fmt.Errorf("decode NewBlock height: %w", err)
+		}
+		return Event{Query: r.Query, Block: &NewBlockEvent{
+			Height:   h,
+			Time:     v.Block.Header.Time,
+			Proposer: v.Block.Header.ProposerAddress,
+			TxCount:  len(v.Block.Data.Txs),
+		}}, true, nil
+	case "tendermint/event/Tx":
+		var v struct {
+			TxResult struct {
+				Height string   `json:"height"`
+				Index  int      `json:"index"`
+				Result TxResult `json:"result"`
+			} `json:"TxResult"`
+		}
+		if err := json.Unmarshal(r.Data.Value, &v); err != nil {
+			return Event{}, false, fmt.Errorf("decode Tx event: %w", err)
+		}
+		h, err := strconv.ParseInt(v.TxResult.Height, 10, 64)
+		if err != nil {
+			return Event{}, false, fmt.Errorf("decode Tx height: %w", err)
+		}
+		return Event{Query: r.Query, Tx: &TxEvent{Height: h, Index: v.TxResult.Index, Result: v.TxResult.Result}}, true, nil
+	default:
+		// 其它事件类型（以及空 result）忽略，避免上游新增事件导致断线
+		return Event{}, false, nil
+	}
+}
@@ -0,0 +1,146 @@
+package tendermint
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWSClient_ReconnectsAndResubscribes(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu      sync.Mutex
+		queries []string
+		conns   int
+	)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/websocket" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		mu.Lock()
+		conns++
+		n := conns
+		mu.Unlock()
+
+		for i := 0; i < 2; i++ {
+			var req struct {
+				ID     int               `json:"id"`
+				Method string            `json:"method"`
+				Params map[string]string `json:"params"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			mu.Lock()
+			queries = append(queries, req.Params["query"])
+			mu.Unlock()
+			_ = conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": map[string]any{}})
+		}
+
+		if n == 1 {
+			// 第一条连接推送一个新区块后断开，客户端应重连并重新订阅
+			_ = conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": 1, "result": map[string]any{
+				"query": QueryNewBlock,
+				"data": map[string]any{"type": "tendermint/event/NewBlock", "value": map[string]any{
+					"block": map[string]any{
+						"header": map[string]any{"height": "7", "time": "2025-01-01T00:00:05Z", "proposer_address": "AAAA"},
+						"data":   map[string]any{"txs": []string{"dHgx", "dHgy"}},
+					},
+				}},
+			}})
+			return
+		}
+		_ = conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": 2, "result": map[string]any{
+			"query": QueryTx,
+			"data": map[string]any{"type": "tendermint/event/Tx", "value": map[string]any{
+				"TxResult": map[string]any{"height": "8", "index": 0, "result": map[string]any{"code": 5, "gas_used": "42"}},
+			}},
+		}})
+		// 保持连接直到客户端退出
+		_, _, _ = conn.ReadMessage()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		evMu   sync.Mutex
+		events []Event
+		states []bool
+	)
+	ws := NewWSClient(srv.URL, 2*time.Second).WithBackoff(10*time.Millisecond, 50*time.Millisecond)
+	if !strings.HasPrefix(ws.URL(), "ws://") || !strings.HasSuffix(ws.URL(), "/websocket") {
+		t.Fatalf("unexpected websocket url %q", ws.URL())
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- ws.Run(ctx, Subscription{
+			Queries: []string{QueryNewBlock, QueryTx},
+			OnEvent: func(ev Event) {
+				evMu.Lock()
+				events = append(events, ev)
+				evMu.Unlock()
+			},
+			OnConnState: func(connected bool, _ error) {
+				evMu.Lock()
+				states = append(states, connected)
+				evMu.Unlock()
+			},
+		})
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		evMu.Lock()
+		n := len(events)
+		evMu.Unlock()
+		if n >= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for events, got %d", n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("Run returned %v, want context.Canceled", err)
+	}
+
+	evMu.Lock()
+	defer evMu.Unlock()
+	if b := events[0].Block; b == nil || b.Height != 7 || b.TxCount != 2 || b.Proposer != "AAAA" {
+		t.Fatalf("unexpected block event %+v", events[0])
+	}
+	if tx := events[1].Tx; tx == nil || tx.Height != 8 || tx.Result.Code != 5 || tx.Result.GasUsed != "42" {
+		t.Fatalf("unexpected tx event %+v", events[1])
+	}
+	if len(states) < 3 || !states[0] || states[1] || !states[2] {
+		t.Fatalf("conn states = %v, want [true false true ...]", states)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	got, _ := json.Marshal(queries)
+	want, _ := json.Marshal([]string{QueryNewBlock, QueryTx, QueryNewBlock, QueryTx})
+	if string(got) != string(want) {
+		t.Fatalf("subscribe queries = %s, want %s", got, want)
+	}
+}
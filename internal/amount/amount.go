@@ -0,0 +1,141 @@
+// Package amount 处理链上金额：上游返回的是基础单位（例如 18 位小数的 abyb）的大整数/小数字符串，
+// 超出 float64 的精确范围。这里用 math/big 精确解析与换算，只在写入指标时才转为 float64。
+package amount
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Denom 为币种元数据：Base 为链上基础单位（bond_denom），Display 为展示单位，
+// 1 Display = 10^Exponent Base。
+type Denom struct {
+	Base     string
+	Display  string
+	Exponent int
+}
+
+// Parse 解析十进制金额字符串（整数或小数，允许前后空白与前导 +/-）。
+func Parse(s string) (*big.Rat, error) {
+	t := strings.TrimSpace(s)
+	if t == "" {
+		return nil, fmt.Errorf("amount: empty value")
+	}
+	// big.Rat.SetString 还接受 0x 前缀、下划线、分数与指数，上游金额不会出现这些写法，出现即视为脏数据
+	if !isDecimal(t) {
+		return nil, fmt.Errorf("amount: invalid decimal %q", s)
+	}
+	r, _ := new(big.Rat).SetString(t)
+	return r, nil
+}
+
+func isDecimal(s string) bool {
+	if s[0] == '+' || s[0] == '-' {
+		s = s[1:]
+	}
+	digits, dot := 0, false
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '.' && !dot:
+			dot = true
+		default:
+			return false
+		}
+	}
+	return digits > 0
+}
+
+// ParseAny 解析 JSON 标量（string / json.Number / 整数），用于字段类型不固定的上游响应。
+// float64 本身已丢失精度，按其十进制表示解析。
+func ParseAny(v any) (*big.Rat, error) {
+	switch x := v.(type) {
+	case string:
+		return Parse(x)
+	case json.Number:
+		return parseNumber(x.String())
+	case float64:
+		if r := new(big.Rat).SetFloat64(x); r != nil {
+			return r, nil
+		}
+		return nil, fmt.Errorf("amount: invalid number %v", x)
+	case int:
+		return new(big.Rat).SetInt64(int64(x)), nil
+	case int64:
+		return new(big.Rat).SetInt64(x), nil
+	case uint64:
+		return new(big.Rat).SetUint64(x), nil
+	case nil:
+		return nil, fmt.Errorf("amount: missing value")
+	default:
+		return nil, fmt.Errorf("amount: unsupported type %T", v)
+	}
+}
+
+// maxExponent 为 JSON 数字指数部分的上限：big.Rat.SetString 会按指数展开为精确值，
+// 1e1000000000 这样的脏数据会耗尽 CPU 与内存。链上金额远小于这个量级。
+const maxExponent = 100
+
+// parseNumber 解析 JSON 数字（十进制，可带指数，例如 1e+21），指数超出 ±maxExponent 时报错。
+func parseNumber(s string) (*big.Rat, error) {
+	mant, exp := s, ""
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		mant, exp = s[:i], s[i+1:]
+	}
+	if mant == "" || !isDecimal(mant) {
+		return nil, fmt.Errorf("amount: invalid number %q", s)
+	}
+	if exp != "" {
+		e, err := strconv.Atoi(exp)
+		if err != nil || e > maxExponent || e < -maxExponent {
+			return nil, fmt.Errorf("amount: exponent out of range in %q", s)
+		}
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("amount: invalid number %q", s)
+	}
+	return r, nil
+}
+
+// ToDisplay 把基础单位金额换算为展示单位：base / 10^Exponent。
+func (d Denom) ToDisplay(base *big.Rat) *big.Rat {
+	return new(big.Rat).Quo(base, d.scale())
+}
+
+// FromDisplay 把展示单位金额换算为基础单位：display * 10^Exponent。
+func (d Denom) FromDisplay(display *big.Rat) *big.Rat {
+	return new(big.Rat).Mul(display, d.scale())
+}
+
+// DisplayFloat 解析基础单位字符串并换算为展示单位的 float64（用于写入 *_byb 指标）。
+func (d Denom) DisplayFloat(base string) (float64, error) {
+	r, err := Parse(base)
+	if err != nil {
+		return 0, err
+	}
+	return Float64(d.ToDisplay(r)), nil
+}
+
+// Float64 返回最接近 r 的 float64。
+func Float64(r *big.Rat) float64 {
+	f, _ := r.Float64()
+	return f
+}
+
+// Ratio 返回 num/den 的 float64；den 为 0 时 ok 为 false。
+func Ratio(num, den *big.Rat) (float64, bool) {
+	if den.Sign() == 0 {
+		return 0, false
+	}
+	return Float64(new(big.Rat).Quo(num, den)), true
+}
+
+func (d Denom) scale() *big.Rat {
+	exp := max(d.Exponent, 0)
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil))
+}
@@ -0,0 +1,104 @@
+package amount
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestDenom_DisplayFloat18Decimals(t *testing.T) {
+	t.Parallel()
+
+	d := Denom{Base: "abyb", Display: "BYB", Exponent: 18}
+	for _, tc := range []struct {
+		in   string
+		want float64
+	}{
+		{"1", 1e-18},
+		{"1000000000000000000", 1},
+		{"123456789000000000000", 123.456789},
+		// 超过 2^53 的尾数：直接转 float64 再除会丢掉低位，这里先精确换算
+		{"1234567890123456789012345678", 1234567890.123456789012345678},
+		{" 2500000000000000000 ", 2.5},
+		{"0.5", 5e-19},
+	} {
+		got, err := d.DisplayFloat(tc.in)
+		if err != nil {
+			t.Fatalf("DisplayFloat(%q) err: %v", tc.in, err)
+		}
+		if got != tc.want {
+			t.Fatalf("DisplayFloat(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+
+	for _, bad := range []string{"", "0x10", "1_000", "1e18", "1/3", "1.2.3", "-", "abc"} {
+		if _, err := d.DisplayFloat(bad); err == nil {
+			t.Fatalf("DisplayFloat(%q) expected error", bad)
+		}
+	}
+}
+
+func TestDenom_ExactArithmeticBeyondFloat64(t *testing.T) {
+	t.Parallel()
+
+	d := Denom{Display: "BYB", Exponent: 18}
+	// 两个值在 float64 下相等，但精确差值为 1 个基础单位
+	a, _ := Parse("9007199254740993000000000000000001")
+	b, _ := Parse("9007199254740993000000000000000000")
+	if Float64(a) != Float64(b) {
+		t.Fatalf("test values should collide in float64")
+	}
+	diff := d.ToDisplay(a)
+	diff.Sub(diff, d.ToDisplay(b))
+	if got := diff.FloatString(18); got != "0.000000000000000001" {
+		t.Fatalf("diff = %s, want 0.000000000000000001", got)
+	}
+	if got := d.FromDisplay(d.ToDisplay(a)); got.Cmp(a) != 0 {
+		t.Fatalf("round trip = %s, want %s", got.FloatString(0), a.FloatString(0))
+	}
+
+	ratio, ok := Ratio(mustParse(t, "1000000000000000000"), mustParse(t, "4000000000000000000"))
+	if !ok || ratio != 0.25 {
+		t.Fatalf("Ratio = %v, %v; want 0.25, true", ratio, ok)
+	}
+	if _, ok := Ratio(a, mustParse(t, "0")); ok {
+		t.Fatalf("Ratio with zero denominator should not be ok")
+	}
+}
+
+func TestParseAny(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		in   any
+		want string
+	}{
+		{"1000000000000000000000", "1000000000000000000000"},
+		{json.Number("1000000000000000000001"), "1000000000000000000001"},
+		{json.Number("1e+21"), "1000000000000000000000"},
+		{int64(42), "42"},
+		{float64(1.5), "3/2"},
+	} {
+		r, err := ParseAny(tc.in)
+		if err != nil {
+			t.Fatalf("ParseAny(%v) err: %v", tc.in, err)
+		}
+		if got := r.RatString(); got != tc.want {
+			t.Fatalf("ParseAny(%v) = %s, want %s", tc.in, got, tc.want)
+		}
+	}
+	for _, bad := range []any{nil, true, "x", json.Number("1e1000000000"), json.Number("1e-1000000000"), json.Number("0x10"), json.Number("1e")} {
+		if _, err := ParseAny(bad); err == nil {
+			t.Fatalf("ParseAny(%v) expected error", bad)
+		}
+	}
+}
+
+func mustParse(t *testing.T, s string) *big.Rat {
+	t.Helper()
+	r, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", s, err)
+	}
+	return r
+}
@@ -0,0 +1,235 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/circuit"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/tendermint"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+// FollowedBlock 为 BlockFollower 分发给订阅者的单个区块（/block + /block_results 的合并视图）。
+type FollowedBlock struct {
+	Height int64
+	Time   time.Time
+	// Proposer 为出块者共识地址（hex）
+	Proposer  string
+	TxCount   int
+	TxResults []tendermint.TxResult
+	// LastCommit 为上一个区块（LastCommitHeight）的签名，顺序与该高度的 validator set 一致
+	LastCommitHeight int64
+	LastCommit       []tendermint.CommitSig
+}
+
+// BlockSubscriber 消费 BlockFollower 拉到的区块。
+// OnBlock 按高度严格递增、不跳块地调用；Flush 在每轮运行结束后调用一次（即使本轮没有新区块、或上游失败），
+// 用于输出窗口类聚合指标或 mock 兜底。
+type BlockSubscriber interface {
+	OnBlock(ctx context.Context, b *FollowedBlock)
+	Flush(ctx context.Context)
+}
+
+// BlockFollower 记住已处理的最高高度，每次运行拉取 (lastHeight, latest] 区间内的全部区块：
+//   - 并发上限 concurrency，避免打爆 RPC
+//   - 单轮最多 maxBlocksPerRun 个区块；落后更多时分多轮追赶（仍不跳块）
+//   - 首次运行没有基线，只回看最近 maxBlocksPerRun 个区块
+//   - 某个高度拉取失败时，只分发它之前的连续区块，下一轮从失败高度重试
+//
+// 已处理高度保存在 BlockCursor 中；热加载重建 follower 时传入同一个 cursor（见 WithCursor），
+// 新 follower 从断点继续，而不是回看 maxBlocksPerRun 个区块把它们再次累加进跨重载保留的 counter。
+type BlockFollower struct {
+	log  *slog.Logger
+	m    *metrics.Metrics
+	tm   *tendermint.Client
+	subs []BlockSubscriber
+
+	concurrency     int
+	maxBlocksPerRun int
+
+	cur *BlockCursor
+}
+
+// BlockCursor 为 BlockFollower 已处理的最高高度。
+// mu 串行化定时轮询（Run）与 WebSocket 推送触发的 Advance；重载期间新旧 follower 共享同一个 cursor，也由它串行化。
+type BlockCursor struct {
+	mu     sync.Mutex
+	height int64
+}
+
+func NewBlockCursor() *BlockCursor {
+	return &BlockCursor{}
+}
+
+func NewBlockFollower(log *slog.Logger, m *metrics.Metrics, tm *tendermint.Client, concurrency, maxBlocksPerRun int) *BlockFollower {
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	if maxBlocksPerRun <= 0 {
+		maxBlocksPerRun = 50
+	}
+	return &BlockFollower{log: log, m: m, tm: tm, concurrency: concurrency, maxBlocksPerRun: maxBlocksPerRun, cur: NewBlockCursor()}
+}
+
+// WithCursor 使用跨重载共享的 cursor（需在 Run 之前调用）。
+func (f *BlockFollower) WithCursor(c *BlockCursor) *BlockFollower {
+	if c != nil {
+		f.cur = c
+	}
+	return f
+}
+
+// Subscribe 注册订阅者（需在 Run 之前调用）。
+func (f *BlockFollower) Subscribe(subs ...BlockSubscriber) *BlockFollower {
+	f.subs = append(f.subs, subs...)
+	return f
+}
+
+func (f *BlockFollower) Run(ctx context.Context) error {
+	f.cur.mu.Lock()
+	defer f.cur.mu.Unlock()
+	defer f.flush(ctx)
+
+	st, err := f.tm.Status(circuit.WithSource(ctx, "tendermint_status_for_blocks"))
+	if err != nil {
+		f.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "tendermint_status_for_blocks"}, 0)
+		return err
+	}
+	f.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "tendermint_status_for_blocks"}, 1)
+	latest, err := strconv.ParseInt(st.Result.SyncInfo.LatestBlockHeight, 10, 64)
+	if err != nil {
+		return err
+	}
+	return f.advance(ctx, latest)
+}
+
+// Advance 在已知最新高度时（例如收到 WebSocket NewBlock 推送）立即补拉到该高度，省去一次 /status。
+func (f *BlockFollower) Advance(ctx context.Context, latest int64) error {
+	f.cur.mu.Lock()
+	defer f.cur.mu.Unlock()
+	defer f.flush(ctx)
+
+	if latest <= f.cur.height {
+		return nil
+	}
+	return f.advance(ctx, latest)
+}
+
+func (f *BlockFollower) advance(ctx context.Context, latest int64) error {
+	from, to := f.nextRange(latest)
+	if from > to {
+		f.writeProgress(latest)
+		return nil
+	}
+
+	blocks, errs := f.fetchRange(ctx, from, to)
+	var fetchErr error
+	for i, b := range blocks {
+		if errs[i] != nil {
+			fetchErr = fmt.Errorf("height %d: %w", from+int64(i), errs[i])
+			warnSourceErr(f.log, "block fetch failed, retry next run", errs[i], "collector", "block_follower", "height", from+int64(i))
+			break
+		}
+		for _, s := range f.subs {
+			s.OnBlock(ctx, b)
+		}
+		f.cur.height = b.Height
+	}
+	f.writeProgress(latest)
+
+	// 只有一个区块都没推进时才视为本轮失败；部分成功时下一轮从断点继续
+	if fetchErr != nil && f.cur.height < from {
+		return fetchErr
+	}
+	return nil
+}
+
+// nextRange 计算本轮要拉取的闭区间 [from, to]。
+func (f *BlockFollower) nextRange(latest int64) (int64, int64) {
+	from := f.cur.height + 1
+	if f.cur.height == 0 || f.cur.height > latest {
+		// 首次运行（或节点回滚/切换到更低高度的节点）：重新建立基线
+		from = latest - int64(f.maxBlocksPerRun) + 1
+	}
+	if from < 1 {
+		from = 1
+	}
+	to := latest
+	if to-from+1 > int64(f.maxBlocksPerRun) {
+		to = from + int64(f.maxBlocksPerRun) - 1
+	}
+	return from, to
+}
+
+// fetchRange 以有限并发拉取 [from, to]，结果按高度顺序返回。
+func (f *BlockFollower) fetchRange(ctx context.Context, from, to int64) ([]*FollowedBlock, []error) {
+	n := int(to - from + 1)
+	blocks := make([]*FollowedBlock, n)
+	errs := make([]error, n)
+
+	sem := make(chan struct{}, f.concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			blocks[i], errs[i] = f.fetchBlock(ctx, from+int64(i))
+		}(i)
+	}
+	wg.Wait()
+	return blocks, errs
+}
+
+func (f *BlockFollower) fetchBlock(ctx context.Context, height int64) (*FollowedBlock, error) {
+	blk, err := f.tm.Block(circuit.WithSource(ctx, "tendermint_block"), height)
+	if err != nil {
+		f.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "tendermint_block"}, 0)
+		return nil, err
+	}
+	f.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "tendermint_block"}, 1)
+
+	res, err := f.tm.BlockResults(circuit.WithSource(ctx, "tendermint_block_results"), height)
+	if err != nil {
+		f.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "tendermint_block_results"}, 0)
+		return nil, err
+	}
+	f.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "tendermint_block_results"}, 1)
+
+	h := blk.Result.Block.Header
+	lc := blk.Result.Block.LastCommit
+	// 创世块没有 last_commit，height 为 "0" 或空
+	lcHeight, _ := strconv.ParseInt(lc.Height, 10, 64)
+	return &FollowedBlock{
+		Height:           height,
+		Time:             h.Time,
+		Proposer:         h.ProposerAddress,
+		TxCount:          len(blk.Result.Block.Data.Txs),
+		TxResults:        res.Result.TxsResults,
+		LastCommitHeight: lcHeight,
+		LastCommit:       lc.Signatures,
+	}, nil
+}
+
+func (f *BlockFollower) writeProgress(latest int64) {
+	chainLabels := map[string]string{"chain_id": f.m.ChainID()}
+	if f.cur.height > 0 {
+		f.m.SetGauge("biya_chain_followed_block_height", chainLabels, float64(f.cur.height))
+	}
+	lag := latest - f.cur.height
+	if lag < 0 {
+		lag = 0
+	}
+	f.m.SetGauge("biya_chain_follower_lag_blocks", chainLabels, float64(lag))
+}
+
+func (f *BlockFollower) flush(ctx context.Context) {
+	for _, s := range f.subs {
+		s.Flush(ctx)
+	}
+}
@@ -0,0 +1,217 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/tendermint"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/config"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+// fakeChain 模拟一个 Tendermint RPC：区块 h 的时间为 base+5h 秒，交易按 txs[h] 给出 (code, gas_used)。
+type fakeChain struct {
+	mu     sync.Mutex
+	head   int64
+	txs    map[int64][][2]int64
+	failAt map[int64]int // 高度 -> 剩余失败次数
+}
+
+func (f *fakeChain) handler() http.Handler {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		h, _ := strconv.ParseInt(r.URL.Query().Get("height"), 10, 64)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/status":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"result": map[string]any{
+					"sync_info": map[string]any{"latest_block_height": strconv.FormatInt(f.head, 10), "latest_block_time": base},
+				},
+			})
+		case "/consensus_params":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"result": map[string]any{
+					"consensus_params": map[string]any{"block": map[string]any{"max_gas": "1000"}},
+				},
+			})
+		case "/block":
+			if f.failAt[h] > 0 {
+				f.failAt[h]--
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			txs := make([]string, len(f.txs[h]))
+			proposer := "AAAA"
+			if h%2 == 0 {
+				proposer = "BBBB"
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"result": map[string]any{
+					"block": map[string]any{
+						"header": map[string]any{
+							"height":           strconv.FormatInt(h, 10),
+							"time":             base.Add(time.Duration(h) * 5 * time.Second),
+							"proposer_address": proposer,
+						},
+						"data": map[string]any{"txs": txs},
+					},
+				},
+			})
+		case "/block_results":
+			results := make([]any, 0, len(f.txs[h]))
+			for _, tx := range f.txs[h] {
+				results = append(results, map[string]any{"code": tx[0], "gas_used": strconv.FormatInt(tx[1], 10)})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"result": map[string]any{"txs_results": results}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+type recordingSubscriber struct {
+	mu      sync.Mutex
+	heights []int64
+	flushes atomic.Int64
+}
+
+func (s *recordingSubscriber) OnBlock(_ context.Context, b *FollowedBlock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.heights = append(s.heights, b.Height)
+}
+
+func (s *recordingSubscriber) seen() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int64(nil), s.heights...)
+}
+
+func (s *recordingSubscriber) Flush(context.Context) { s.flushes.Add(1) }
+
+func TestBlockFollower_SubscribersComputeFromEveryBlock(t *testing.T) {
+	t.Parallel()
+
+	chain := &fakeChain{head: 3, txs: map[int64][][2]int64{
+		1: {{0, 150}, {0, 50}},
+		2: {{0, 300}, {5, 300}},
+		3: {{0, 100}},
+	}}
+	srv := httptest.NewServer(chain.handler())
+	defer srv.Close()
+
+	_, m := metrics.New("biya", "dev", "none")
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+	tm := tendermint.NewClient(srv.URL, 2*time.Second)
+	// mock 开启时，真实数据仍应优先
+	mock := config.MockConfig{Enabled: true}
+	mock.Values.GasUtilizationRatio = 0.7
+	mock.Values.TPSWindow = 99
+
+	f := NewBlockFollower(logger, m, tm, 2, 50).Subscribe(
+		NewTxStatsSubscriber(logger, m, mock),
+		NewBlockTimeSubscriber(logger, m, mock, 100),
+		NewProposerSubscriber(logger, m),
+		NewGasSubscriber(logger, m, tm, mock, 100),
+	)
+	if err := f.Run(context.Background()); err != nil {
+		t.Fatalf("follower run err: %v", err)
+	}
+
+	out := m.RenderText()
+	assertContains(t, out, "\nbiya_blocks_total 3\n")
+	assertContains(t, out, "\nbiya_tx_total{status=\"success\"} 4\n")
+	assertContains(t, out, "\nbiya_tx_total{status=\"failed\"} 1\n")
+	// 区块 2、3 共 3 笔交易，跨度 10s
+	assertContains(t, out, "\nbiya_chain_tps_window{chain_id=\"biya\"} 0.3\n")
+	assertContains(t, out, "\nbiya_chain_tps_block{chain_id=\"biya\"} 0.2\n")
+	assertContains(t, out, "\nbiya_chain_block_time_seconds_avg{chain_id=\"biya\"} 5\n")
+	assertContains(t, out, "\nbiya_tx_confirm_time_seconds_count 2\n")
+	assertContains(t, out, "\nbiya_chain_proposed_blocks_total{chain_id=\"biya\",proposer=\"AAAA\"} 2\n")
+	assertContains(t, out, "\nbiya_chain_proposed_blocks_total{chain_id=\"biya\",proposer=\"BBBB\"} 1\n")
+	assertContains(t, out, "\nbiya_gas_limit_per_block 1000\n")
+	assertContains(t, out, "\nbiya_gas_used_per_block 300\n")
+	assertContains(t, out, "\nbiya_chain_block_gas_utilization_ratio_avg{chain_id=\"biya\"} 0.3\n")
+	assertContains(t, out, "\nbiya_chain_block_gas_utilization_ratio_count{chain_id=\"biya\"} 3\n")
+	assertContains(t, out, "\nbiya_chain_followed_block_height{chain_id=\"biya\"} 3\n")
+	assertContains(t, out, "\nbiya_chain_follower_lag_blocks{chain_id=\"biya\"} 0\n")
+}
+
+func TestBlockFollower_GapFreeWithCatchUpCapAndRetry(t *testing.T) {
+	t.Parallel()
+
+	chain := &fakeChain{head: 2, txs: map[int64][][2]int64{}, failAt: map[int64]int{6: 1}}
+	srv := httptest.NewServer(chain.handler())
+	defer srv.Close()
+
+	_, m := metrics.New("biya", "dev", "none")
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+	rec := &recordingSubscriber{}
+	f := NewBlockFollower(logger, m, tendermint.NewClient(srv.URL, 2*time.Second), 2, 2).Subscribe(rec)
+
+	run := func() {
+		t.Helper()
+		if err := f.Run(context.Background()); err != nil {
+			t.Fatalf("follower run err: %v", err)
+		}
+	}
+	run() // 1..2
+	chain.mu.Lock()
+	chain.head = 7
+	chain.mu.Unlock()
+	run() // 3..4（单轮上限 2）
+	assertContains(t, m.RenderText(), "\nbiya_chain_follower_lag_blocks{chain_id=\"biya\"} 3\n")
+	run() // 5，6 失败
+	run() // 6..7
+
+	want := []int64{1, 2, 3, 4, 5, 6, 7}
+	got := rec.seen()
+	if len(got) != len(want) {
+		t.Fatalf("heights = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("heights = %v, want %v", got, want)
+		}
+	}
+	if got := rec.flushes.Load(); got != 4 {
+		t.Fatalf("flushes = %d, want 4", got)
+	}
+}
+
+func TestProposerSubscriber_FlushKeepsIdleProposersPastTTL(t *testing.T) {
+	t.Parallel()
+
+	_, root := metrics.New("biya", "dev", "none")
+	root.SetSeriesTTL(50 * time.Millisecond)
+	m := root.Owned("realtime_blocks")
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+	s := NewProposerSubscriber(logger, m)
+
+	s.OnBlock(context.Background(), &FollowedBlock{Height: 1, Proposer: "AAAA"})
+	s.OnBlock(context.Background(), &FollowedBlock{Height: 2, Proposer: "BBBB"})
+	s.Flush(context.Background())
+
+	// AAAA 之后长时间不出块：超过 series TTL 后仍应保留累计值
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	s.OnBlock(context.Background(), &FollowedBlock{Height: 3, Proposer: "BBBB"})
+	s.Flush(context.Background())
+	root.ExpireOwned("realtime_blocks", true, start, 0)
+
+	out := root.RenderText()
+	assertContains(t, out, "\nbiya_chain_proposed_blocks_total{chain_id=\"biya\",proposer=\"AAAA\"} 1\n")
+	assertContains(t, out, "\nbiya_chain_proposed_blocks_total{chain_id=\"biya\",proposer=\"BBBB\"} 2\n")
+}
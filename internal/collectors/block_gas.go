@@ -0,0 +1,162 @@
+package collectors
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/circuit"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/tendermint"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/config"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+// gasUtilizationBuckets 为单区块 gas 利用率（0-1）的 histogram buckets。
+var gasUtilizationBuckets = []float64{0.05, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 0.95, 1}
+
+// gasLimitRefresh 为 block.max_gas 的缓存时间：共识参数很少变化，不必每个区块都查一次。
+const gasLimitRefresh = time.Minute
+
+// GasSubscriber 基于 BlockFollower 提供的 block_results 计算真实的区块 gas 使用率：
+// 每个区块 gas_used = sum(txs_results[].gas_used)，利用率 = gas_used / max_gas（来自 /consensus_params），
+// 并在最近 N 个区块上做滚动平均。
+type GasSubscriber struct {
+	log  *slog.Logger
+	m    *metrics.Metrics
+	tm   *tendermint.Client
+	mock config.MockConfig
+
+	gas *gasWindow
+
+	maxGas          int64
+	maxGasFetchedAt time.Time
+}
+
+func NewGasSubscriber(log *slog.Logger, m *metrics.Metrics, tm *tendermint.Client, mock config.MockConfig, windowBlocks int) *GasSubscriber {
+	return &GasSubscriber{log: log, m: m, tm: tm, mock: mock, gas: newGasWindow(windowBlocks)}
+}
+
+func (s *GasSubscriber) OnBlock(ctx context.Context, b *FollowedBlock) {
+	maxGas, ok := s.readMaxGas(ctx, b.Height)
+	if !ok {
+		return
+	}
+	s.gas.observeBlock(s.m, blockGasUsed(b.TxResults), maxGas)
+}
+
+func (s *GasSubscriber) Flush(context.Context) {
+	if s.gas.len() == 0 {
+		s.writeMock()
+		return
+	}
+	s.gas.write(s.m)
+}
+
+// readMaxGas 读取（并缓存）共识参数中的 block.max_gas；max_gas<=0（不限制）时无法计算利用率。
+func (s *GasSubscriber) readMaxGas(ctx context.Context, height int64) (int64, bool) {
+	if !s.maxGasFetchedAt.IsZero() && time.Since(s.maxGasFetchedAt) < gasLimitRefresh {
+		return s.maxGas, s.maxGas > 0
+	}
+	resp, err := s.tm.ConsensusParams(circuit.WithSource(ctx, "tendermint_consensus_params"), height)
+	if err != nil {
+		s.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "tendermint_consensus_params"}, 0)
+		return 0, false
+	}
+	s.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "tendermint_consensus_params"}, 1)
+	s.maxGasFetchedAt = time.Now()
+	maxGas, err := strconv.ParseInt(strings.TrimSpace(resp.Result.ConsensusParams.Block.MaxGas), 10, 64)
+	if err != nil || maxGas <= 0 {
+		s.maxGas = 0
+		s.log.Warn("consensus max_gas is unlimited or invalid, gas utilization unavailable", "collector", "block_gas", "max_gas", resp.Result.ConsensusParams.Block.MaxGas)
+		return 0, false
+	}
+	s.maxGas = maxGas
+	s.m.SetGauge("biya_gas_limit_per_block", nil, float64(maxGas))
+	return maxGas, true
+}
+
+func (s *GasSubscriber) writeMock() {
+	if !s.mock.Enabled {
+		return
+	}
+	v := s.mock.Values.GasUtilizationRatio
+	s.m.SetGauge("biya_chain_block_gas_utilization_ratio_avg", map[string]string{"chain_id": s.m.ChainID()}, v)
+	s.m.SetGauge("biya_gas_utilization_ratio", nil, v)
+}
+
+func blockGasUsed(txs []tendermint.TxResult) int64 {
+	var sum int64
+	for _, tx := range txs {
+		if v, err := strconv.ParseInt(strings.TrimSpace(tx.GasUsed), 10, 64); err == nil {
+			sum += v
+		}
+	}
+	return sum
+}
+
+// gasWindow 维护最近 N 个区块的 gas 使用情况（环形缓冲），并输出滚动平均。
+type gasWindow struct {
+	mu    sync.Mutex
+	size  int
+	used  []int64
+	ratio []float64
+	next  int
+	full  bool
+}
+
+func newGasWindow(size int) *gasWindow {
+	if size <= 0 {
+		size = 100
+	}
+	return &gasWindow{size: size, used: make([]int64, size), ratio: make([]float64, size)}
+}
+
+// observeBlock 记录一个区块，并把该区块的利用率打到 histogram。
+func (w *gasWindow) observeBlock(m *metrics.Metrics, gasUsed, maxGas int64) {
+	ratio := float64(gasUsed) / float64(maxGas)
+	m.ObserveHistogramMetric("biya_chain_block_gas_utilization_ratio", map[string]string{"chain_id": m.ChainID()}, gasUtilizationBuckets, ratio)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.used[w.next] = gasUsed
+	w.ratio[w.next] = ratio
+	w.next = (w.next + 1) % w.size
+	if w.next == 0 {
+		w.full = true
+	}
+}
+
+func (w *gasWindow) len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.full {
+		return w.size
+	}
+	return w.next
+}
+
+func (w *gasWindow) write(m *metrics.Metrics) {
+	w.mu.Lock()
+	n := w.next
+	if w.full {
+		n = w.size
+	}
+	var usedSum int64
+	var ratioSum float64
+	for i := 0; i < n; i++ {
+		usedSum += w.used[i]
+		ratioSum += w.ratio[i]
+	}
+	w.mu.Unlock()
+
+	if n == 0 {
+		return
+	}
+	avgRatio := ratioSum / float64(n)
+	m.SetGauge("biya_chain_block_gas_utilization_ratio_avg", map[string]string{"chain_id": m.ChainID()}, avgRatio)
+	m.SetGauge("biya_gas_utilization_ratio", nil, avgRatio)
+	m.SetGauge("biya_gas_used_per_block", nil, float64(usedSum)/float64(n))
+}
@@ -0,0 +1,59 @@
+package collectors
+
+import (
+	"sync"
+	"time"
+)
+
+// chainHeadMaxAge 为单个来源观测值的有效期：来源停止上报（explorer 故障、WebSocket 关闭等）后，
+// 它最后的高度不再参与参考值计算，避免链重置后旧的高值一直把参考值顶住。
+const chainHeadMaxAge = 5 * time.Minute
+
+// ChainHead 记录各数据源观测到的最新区块高度，用于计算节点落后区块数（biya_node_behind_blocks）。
+// 说明：explorer 与 tendermint 由不同 collector 轮询，这里作为它们之间唯一的共享状态。
+type ChainHead struct {
+	mu      sync.Mutex
+	heights map[string]chainHeadObservation
+	now     func() time.Time
+}
+
+type chainHeadObservation struct {
+	height int64
+	at     time.Time
+}
+
+func NewChainHead() *ChainHead {
+	return &ChainHead{heights: make(map[string]chainHeadObservation), now: time.Now}
+}
+
+// Observe 记录某个来源（节点名或 "explorer"）的最新高度。每个来源只保留最近一次观测（高度回退时同样覆盖），
+// 链重置、测试网重启或某个来源的异常读数都会在下一次观测时被纠正；各来源之间在 Max 中取最大值。
+func (h *ChainHead) Observe(source string, height int64) {
+	if h == nil || height <= 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.heights[source] = chainHeadObservation{height: height, at: h.now()}
+}
+
+// Max 返回有效期内各来源最新观测中的最大高度。
+func (h *ChainHead) Max() int64 {
+	if h == nil {
+		return 0
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	cutoff := h.now().Add(-chainHeadMaxAge)
+	var max int64
+	for source, o := range h.heights {
+		if o.at.Before(cutoff) {
+			delete(h.heights, source)
+			continue
+		}
+		if o.height > max {
+			max = o.height
+		}
+	}
+	return max
+}
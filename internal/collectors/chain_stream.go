@@ -0,0 +1,118 @@
+package collectors
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/tendermint"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+// ChainStream 通过 CometBFT WebSocket 订阅 NewBlock 事件：
+// 收到新区块后立即更新 biya_chain_head_block_height，并驱动 BlockFollower 补拉该区块，
+// 使出块时间、交易计数、gas 等指标的延迟从 realtime 周期（默认 10s）降到亚秒级。
+// 交易计数由 follower 基于 block_results 逐块统计（同样由推送驱动），因此不再单独订阅 Tx 事件。
+//
+// WebSocket 只是加速通道：断线期间 BlockFollower 仍按 realtime 周期轮询兜底，
+// 且 follower 记录已处理高度，推送与轮询不会重复计数。
+type ChainStream struct {
+	log      *slog.Logger
+	m        *metrics.Metrics
+	ws       *tendermint.WSClient
+	follower *BlockFollower
+	head     *ChainHead
+
+	once      sync.Once
+	connected atomic.Bool
+	lastHead  atomic.Int64
+	// advance 为容量 1 的信号：读循环只记录最新高度（lastHead）并非阻塞地通知，
+	// 由 advanceLoop 在独立 goroutine 中驱动 follower 补拉，避免慢 RPC 拖住读循环导致 CometBFT 断开订阅
+	advance chan struct{}
+}
+
+// NewChainStream 创建订阅；head 可为 nil。
+func NewChainStream(log *slog.Logger, m *metrics.Metrics, ws *tendermint.WSClient, follower *BlockFollower, head *ChainHead) *ChainStream {
+	return &ChainStream{log: log, m: m, ws: ws, follower: follower, head: head, advance: make(chan struct{}, 1)}
+}
+
+// Run 首次调用时在 job 的生命周期内启动订阅循环（热加载替换或退出时 ctx 取消，循环随之结束），
+// 之后每次调用只上报连接状态。断线不视为 job 失败：轮询兜底仍在工作。
+func (c *ChainStream) Run(ctx context.Context) error {
+	c.once.Do(func() {
+		lc := jobLifetime(ctx)
+		go c.loop(lc)
+		if c.follower != nil {
+			go c.advanceLoop(lc)
+		}
+	})
+	up := 0.0
+	if c.connected.Load() {
+		up = 1
+	}
+	c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "tendermint_websocket"}, up)
+	return nil
+}
+
+func (c *ChainStream) loop(ctx context.Context) {
+	err := c.ws.Run(ctx, tendermint.Subscription{
+		Queries: []string{tendermint.QueryNewBlock},
+		OnEvent: c.onEvent,
+		OnConnState: func(connected bool, err error) {
+			c.connected.Store(connected)
+			if connected {
+				c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "tendermint_websocket"}, 1)
+				c.log.Info("tendermint websocket subscribed", "collector", "chain_stream", "url", c.ws.URL())
+				return
+			}
+			c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "tendermint_websocket"}, 0)
+			c.log.Warn("tendermint websocket down, fallback to polling", "collector", "chain_stream", "url", c.ws.URL(), "err", err)
+		},
+	})
+	if err != nil && ctx.Err() == nil {
+		c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "tendermint_websocket"}, 0)
+		c.log.Warn("tendermint websocket disabled", "collector", "chain_stream", "err", err)
+	}
+}
+
+// onEvent 在 WebSocket 读循环中调用，不能阻塞：补拉区块交给 advanceLoop。
+func (c *ChainStream) onEvent(ev tendermint.Event) {
+	if ev.Block == nil {
+		return
+	}
+	c.countEvent("NewBlock")
+	h := ev.Block.Height
+	if h > c.lastHead.Load() {
+		c.lastHead.Store(h)
+		c.m.SetGauge("biya_chain_head_block_height", map[string]string{"chain_id": c.m.ChainID()}, float64(h))
+	}
+	c.head.Observe("stream", h)
+	if c.follower == nil {
+		return
+	}
+	select {
+	case c.advance <- struct{}{}:
+	default:
+		// 已有未处理的信号：advanceLoop 醒来时读取的是最新高度，合并即可
+	}
+}
+
+// advanceLoop 串行地把 follower 推进到推送过来的最新高度；follower 忙时到达的多个区块合并为一次补拉。
+func (c *ChainStream) advanceLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.advance:
+		}
+		h := c.lastHead.Load()
+		if err := c.follower.Advance(ctx, h); err != nil && ctx.Err() == nil {
+			c.log.Debug("advance on new block failed, polling will retry", "collector", "chain_stream", "height", h, "err", err)
+		}
+	}
+}
+
+func (c *ChainStream) countEvent(event string) {
+	c.m.IncCounter("biya_chain_stream_events_total", map[string]string{"chain_id": c.m.ChainID(), "event": event})
+}
@@ -0,0 +1,118 @@
+package collectors
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/tendermint"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+	"github.com/gorilla/websocket"
+)
+
+func TestChainStream_NewBlockEventDrivesFollower(t *testing.T) {
+	t.Parallel()
+
+	chain := &fakeChain{head: 3, txs: map[int64][][2]int64{}}
+	upgrader := websocket.Upgrader{}
+	subscribed := make(chan string, 4)
+	mux := http.NewServeMux()
+	mux.Handle("/", chain.handler())
+	mux.HandleFunc("/websocket", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var req struct {
+			ID     int `json:"id"`
+			Params struct {
+				Query string `json:"query"`
+			} `json:"params"`
+		}
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		subscribed <- req.Params.Query
+		_ = conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": map[string]any{}})
+		_ = conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": 1, "result": map[string]any{
+			"query": tendermint.QueryNewBlock,
+			"data": map[string]any{"type": "tendermint/event/NewBlock", "value": map[string]any{
+				"block": map[string]any{"header": map[string]any{"height": "3", "time": "2025-01-01T00:00:15Z"}},
+			}},
+		}})
+		_, _, _ = conn.ReadMessage()
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	_, m := metrics.New("biya", "dev", "none")
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+	rec := &recordingSubscriber{}
+	follower := NewBlockFollower(logger, m, tendermint.NewClient(srv.URL, 2*time.Second), 2, 50).Subscribe(rec)
+	ws := tendermint.NewWSClient(srv.URL, 2*time.Second).WithBackoff(10*time.Millisecond, 50*time.Millisecond)
+	c := NewChainStream(logger, m, ws, follower, NewChainHead())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// 不调用 follower.Run（不轮询）：区块只能由推送驱动
+	if err := c.Run(ctx); err != nil {
+		t.Fatalf("stream run err: %v", err)
+	}
+	waitFor(t, "blocks pushed to follower", func() bool { return len(rec.seen()) == 3 })
+
+	if err := c.Run(ctx); err != nil {
+		t.Fatalf("stream run err: %v", err)
+	}
+	out := m.RenderText()
+	assertContains(t, out, "\nbiya_chain_head_block_height{chain_id=\"biya\"} 3\n")
+	assertContains(t, out, "\nbiya_chain_stream_events_total{chain_id=\"biya\",event=\"NewBlock\"} 1\n")
+	assertContains(t, out, "\nbiya_exporter_source_up{source=\"tendermint_websocket\"} 1\n")
+	assertContains(t, out, "\nbiya_chain_followed_block_height{chain_id=\"biya\"} 3\n")
+	// 交易计数由 follower 逐块统计，只订阅 NewBlock
+	if q := <-subscribed; q != tendermint.QueryNewBlock || len(subscribed) != 0 {
+		t.Fatalf("subscribed to %q (+%d more), want only %q", q, len(subscribed), tendermint.QueryNewBlock)
+	}
+}
+
+func TestChainStream_SlowFollowerDoesNotBlockEvents(t *testing.T) {
+	t.Parallel()
+
+	chain := &fakeChain{head: 5, txs: map[int64][][2]int64{}}
+	srv := httptest.NewServer(chain.handler())
+	defer srv.Close()
+
+	_, m := metrics.New("biya", "dev", "none")
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+	rec := &recordingSubscriber{}
+	follower := NewBlockFollower(logger, m, tendermint.NewClient(srv.URL, 2*time.Second), 2, 50).Subscribe(rec)
+	c := NewChainStream(logger, m, nil, follower, NewChainHead())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.advanceLoop(ctx)
+
+	// follower 正忙（持有 cursor 锁）时，推送仍应立即返回
+	follower.cur.mu.Lock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for h := int64(1); h <= 5; h++ {
+			c.onEvent(tendermint.Event{Block: &tendermint.NewBlockEvent{Height: h}})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("onEvent blocked while the follower was busy")
+	}
+	assertContains(t, m.RenderText(), "\nbiya_chain_head_block_height{chain_id=\"biya\"} 5\n")
+	follower.cur.mu.Unlock()
+
+	// 忙碌期间的推送合并后补拉到最新高度
+	waitFor(t, "follower caught up to the latest pushed height", func() bool { return len(rec.seen()) == 5 })
+}
@@ -0,0 +1,398 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/circuit"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/stake"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/amount"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+// 提案状态（与 Cosmos gov v1 ProposalStatus 及 stake API 的 status 参数一致）
+const (
+	proposalStatusUnspecified = 0
+	proposalStatusDeposit     = 1
+	proposalStatusVoting      = 2
+	proposalStatusPassed      = 3
+	proposalStatusRejected    = 4
+	proposalStatusFailed      = 5
+)
+
+// proposalTitleMaxRunes 为 title label 的最大长度，避免超长标题撑大 series。
+const proposalTitleMaxRunes = 80
+
+// MinuteGovernanceCollector 分页拉取全部治理提案：汇总各状态的提案数，
+// 并只对投票中与最近结束（recentWindow 内）的提案输出单提案指标，控制 series 数量。
+type MinuteGovernanceCollector struct {
+	log *slog.Logger
+	m   *metrics.Metrics
+	api *stake.Client
+
+	pageSize int
+	maxPages int
+	// recentWindow 内结束投票的提案仍输出单提案指标（便于查看最终计票）
+	recentWindow time.Duration
+	// denom 用于把计票（基础单位的投票权重）换算为 BYB
+	denom amount.Denom
+	now   func() time.Time
+
+	// counted 为已计入 biya_proposals_total 的提案数，见 WithProposalCounter
+	counted *ProposalCounter
+}
+
+// ProposalCounter 记录已计入 biya_proposals_total 的提案数；counter 只累加增量。
+// 跨配置重载共享（见 jobDeps），避免重建 collector 后把全部提案再次累加进保留下来的 counter。
+type ProposalCounter struct {
+	mu      sync.Mutex
+	counted int
+}
+
+func NewProposalCounter() *ProposalCounter {
+	return &ProposalCounter{}
+}
+
+// advance 记录最新的提案总数，返回需要累加的增量（总数没有增长时为 0）。
+func (p *ProposalCounter) advance(total int) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if total <= p.counted {
+		return 0
+	}
+	delta := total - p.counted
+	p.counted = total
+	return delta
+}
+
+func NewMinuteGovernanceCollector(log *slog.Logger, m *metrics.Metrics, api *stake.Client) *MinuteGovernanceCollector {
+	return &MinuteGovernanceCollector{
+		log:          log,
+		m:            m,
+		api:          api,
+		pageSize:     100,
+		maxPages:     50,
+		recentWindow: 7 * 24 * time.Hour,
+		denom:        amount.Denom{Display: "BYB", Exponent: 18},
+		now:          time.Now,
+		counted:      NewProposalCounter(),
+	}
+}
+
+// WithProposalCounter 使用跨重载共享的提案计数。
+func (c *MinuteGovernanceCollector) WithProposalCounter(p *ProposalCounter) *MinuteGovernanceCollector {
+	if p != nil {
+		c.counted = p
+	}
+	return c
+}
+
+// WithPaging 设置提案列表的分页大小与单次采集的页数预算。
+func (c *MinuteGovernanceCollector) WithPaging(pageSize, maxPages int) *MinuteGovernanceCollector {
+	if pageSize > 0 {
+		c.pageSize = pageSize
+	}
+	if maxPages > 0 {
+		c.maxPages = maxPages
+	}
+	return c
+}
+
+// WithRecentWindow 设置已结束提案继续输出单提案指标的时间窗口。
+func (c *MinuteGovernanceCollector) WithRecentWindow(d time.Duration) *MinuteGovernanceCollector {
+	c.recentWindow = d
+	return c
+}
+
+// WithDenom 设置计票换算为 BYB 的币种元数据。
+func (c *MinuteGovernanceCollector) WithDenom(d amount.Denom) *MinuteGovernanceCollector {
+	c.denom = d
+	return c
+}
+
+func (c *MinuteGovernanceCollector) Run(ctx context.Context) error {
+	proposals, upstreamTotal, truncated, err := c.listProposals(ctx)
+	if err != nil {
+		c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "stake_governance_proposals"}, 0)
+		c.unavailableAggregates()
+		return err
+	}
+	c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "stake_governance_proposals"}, 1)
+
+	now := c.now()
+	var active, passed, rejected int
+	for i := range proposals {
+		p := &proposals[i]
+		switch p.status {
+		case proposalStatusVoting:
+			active++
+			// 列表中的计票可能只是最终结果（投票期间为 0），投票中的提案按 ID 再查一次拿实时计票
+			c.refreshTally(ctx, p)
+		case proposalStatusPassed:
+			passed++
+		case proposalStatusRejected:
+			rejected++
+		}
+		if !c.tracked(p, now) {
+			continue
+		}
+		c.writeProposal(p)
+	}
+
+	if truncated {
+		// 页数预算不足时计数不完整，宁可按兜底策略处理也不上报偏小的值；
+		// 预算外的提案本次没有刷新，返回错误使 scheduler 跳过清理，不把它们的单提案 series 当作已消失删除
+		c.unavailableAggregates()
+		return fmt.Errorf("governance proposals truncated by page budget (max_pages=%d, fetched %d)", c.maxPages, len(proposals))
+	}
+	c.m.SetGauge("biya_proposals_active", nil, float64(active))
+	c.m.SetGauge("biya_proposals_passed", nil, float64(passed))
+	c.m.SetGauge("biya_proposals_rejected", nil, float64(rejected))
+
+	// 已结束且押金未达标的提案会从链上状态中删除，上游 total 可能大于列表长度，取较大者
+	total := max(len(proposals), upstreamTotal)
+	if delta := c.counted.advance(total); delta > 0 {
+		_ = c.m.AddCounter("biya_proposals_total", nil, float64(delta))
+	}
+	return nil
+}
+
+func (c *MinuteGovernanceCollector) unavailableAggregates() {
+	c.m.Unavailable("biya_proposals_active", nil)
+	c.m.Unavailable("biya_proposals_passed", nil)
+	c.m.Unavailable("biya_proposals_rejected", nil)
+}
+
+// tracked 判断是否为该提案输出单提案指标：投票中，或投票在 recentWindow 内结束。
+func (c *MinuteGovernanceCollector) tracked(p *proposal, now time.Time) bool {
+	if p.status == proposalStatusVoting {
+		return true
+	}
+	if p.status == proposalStatusUnspecified || p.status == proposalStatusDeposit || p.votingEnd.IsZero() {
+		return false
+	}
+	return now.Sub(p.votingEnd) <= c.recentWindow
+}
+
+func (c *MinuteGovernanceCollector) writeProposal(p *proposal) {
+	c.m.SetGauge("biya_proposal_status", map[string]string{"id": p.id, "title": p.title}, float64(p.status))
+	if !p.votingEnd.IsZero() {
+		c.m.SetGauge("biya_proposal_voting_end_timestamp", map[string]string{"id": p.id}, float64(p.votingEnd.Unix()))
+	}
+	if !p.hasTally {
+		return
+	}
+	labels := map[string]string{"id": p.id}
+	c.m.SetGauge("biya_proposal_votes_yes", labels, amount.Float64(c.denom.ToDisplay(p.yes)))
+	c.m.SetGauge("biya_proposal_votes_no", labels, amount.Float64(c.denom.ToDisplay(p.no)))
+	c.m.SetGauge("biya_proposal_votes_veto", labels, amount.Float64(c.denom.ToDisplay(p.veto)))
+	c.m.SetGauge("biya_proposal_votes_abstain", labels, amount.Float64(c.denom.ToDisplay(p.abstain)))
+}
+
+// refreshTally 用 GetProposalByID 的计票覆盖列表中的计票；查询失败时沿用列表数据。
+func (c *MinuteGovernanceCollector) refreshTally(ctx context.Context, p *proposal) {
+	raw, err := c.api.GetProposalByID(circuit.WithSource(ctx, "stake_governance_proposal"), p.id)
+	if err != nil {
+		c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "stake_governance_proposal"}, 0)
+		warnSourceErr(c.log, "stake governance proposal failed", err, "collector", "minute_governance", "id", p.id)
+		return
+	}
+	c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "stake_governance_proposal"}, 1)
+	var wrapped struct {
+		Proposal json.RawMessage `json:"proposal"`
+	}
+	if err := jsonUnmarshal(raw, &wrapped); err == nil && len(wrapped.Proposal) > 0 && wrapped.Proposal[0] == '{' {
+		raw = wrapped.Proposal
+	}
+	detail, err := parseProposal(raw)
+	if err != nil {
+		c.log.Warn("stake governance proposal parse failed", "collector", "minute_governance", "id", p.id, "err", err)
+		return
+	}
+	if detail.hasTally {
+		p.yes, p.no, p.veto, p.abstain, p.hasTally = detail.yes, detail.no, detail.veto, detail.abstain, true
+	}
+	if p.votingEnd.IsZero() {
+		p.votingEnd = detail.votingEnd
+	}
+}
+
+// listProposals 逐页拉取全部提案（按 ID 去重），返回上游 pagination.total（无法解析时为 0）与是否因页数预算截断。
+func (c *MinuteGovernanceCollector) listProposals(ctx context.Context) ([]proposal, int, bool, error) {
+	var (
+		out   []proposal
+		total int
+		seen  = map[string]struct{}{}
+	)
+	for page := 1; ; page++ {
+		if page > c.maxPages {
+			return out, total, true, nil
+		}
+		raw, err := c.api.GetProposals(circuit.WithSource(ctx, "stake_governance_proposals"), 0, stake.NestedPagination{Page: page, PageSize: c.pageSize})
+		if err != nil {
+			return nil, 0, false, err
+		}
+		var resp struct {
+			Proposals  []json.RawMessage `json:"proposals"`
+			Data       []json.RawMessage `json:"data"`
+			Pagination struct {
+				Total   any   `json:"total"`
+				HasNext *bool `json:"hasNext"`
+			} `json:"pagination"`
+		}
+		if err := jsonUnmarshal(raw, &resp); err != nil {
+			return nil, 0, false, fmt.Errorf("parse governance proposals page %d: %w", page, err)
+		}
+		items := resp.Proposals
+		if len(items) == 0 {
+			items = resp.Data
+		}
+		if v, ok := toFloat64(resp.Pagination.Total); ok {
+			total = int(v)
+		}
+		var errs []error
+		for _, item := range items {
+			p, err := parseProposal(item)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if _, dup := seen[p.id]; dup {
+				continue
+			}
+			seen[p.id] = struct{}{}
+			out = append(out, p)
+		}
+		if len(errs) > 0 {
+			c.log.Warn("stake governance proposals partially unparsable", "collector", "minute_governance", "page", page, "err", errors.Join(errs...))
+		}
+		hasNext := len(items) >= c.pageSize
+		if resp.Pagination.HasNext != nil {
+			hasNext = *resp.Pagination.HasNext
+		}
+		if !hasNext || len(items) == 0 {
+			return out, total, false, nil
+		}
+	}
+}
+
+// proposal 为单个提案中用到的字段；计票为基础单位的投票权重。
+type proposal struct {
+	id        string
+	title     string
+	status    int
+	votingEnd time.Time
+
+	hasTally               bool
+	yes, no, veto, abstain *big.Rat
+}
+
+// parseProposal 解析 stake API 的提案对象，兼容 camelCase / snake_case 字段与字符串形式的状态枚举。
+func parseProposal(raw json.RawMessage) (proposal, error) {
+	var obj map[string]any
+	if err := jsonUnmarshal(raw, &obj); err != nil {
+		return proposal{}, err
+	}
+	var p proposal
+	switch id := pick(obj, "id", "proposalId", "proposal_id").(type) {
+	case string:
+		p.id = strings.TrimSpace(id)
+	case json.Number:
+		p.id = id.String()
+	}
+	if p.id == "" {
+		return proposal{}, errors.New("proposal without id")
+	}
+
+	title, _ := pick(obj, "title").(string)
+	if title == "" {
+		if content, ok := pick(obj, "content").(map[string]any); ok {
+			title, _ = content["title"].(string)
+		}
+	}
+	p.title = truncateRunes(strings.TrimSpace(title), proposalTitleMaxRunes)
+
+	p.status = proposalStatus(pick(obj, "status"))
+	p.votingEnd = parseTimestamp(pick(obj, "votingEndTime", "voting_end_time"))
+
+	tally, ok := pick(obj, "tallyResult", "tally_result", "currentTally", "tally", "finalTallyResult", "final_tally_result").(map[string]any)
+	if ok {
+		yes, errYes := amount.ParseAny(pick(tally, "yes", "yesCount", "yes_count"))
+		no, errNo := amount.ParseAny(pick(tally, "no", "noCount", "no_count"))
+		veto, errVeto := amount.ParseAny(pick(tally, "noWithVeto", "noWithVetoCount", "no_with_veto", "no_with_veto_count"))
+		abstain, errAbstain := amount.ParseAny(pick(tally, "abstain", "abstainCount", "abstain_count"))
+		if errYes == nil && errNo == nil && errVeto == nil && errAbstain == nil {
+			p.yes, p.no, p.veto, p.abstain, p.hasTally = yes, no, veto, abstain, true
+		}
+	}
+	return p, nil
+}
+
+// proposalStatus 把数值或 PROPOSAL_STATUS_* 字符串转换为状态枚举；无法识别时为 0（unspecified）。
+func proposalStatus(v any) int {
+	if n, ok := toFloat64(v); ok {
+		if n >= proposalStatusUnspecified && n <= proposalStatusFailed {
+			return int(n)
+		}
+		return proposalStatusUnspecified
+	}
+	s, _ := v.(string)
+	s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "PROPOSAL_STATUS_")
+	switch s {
+	case "DEPOSIT_PERIOD", "DEPOSIT":
+		return proposalStatusDeposit
+	case "VOTING_PERIOD", "VOTING", "ACTIVE":
+		return proposalStatusVoting
+	case "PASSED":
+		return proposalStatusPassed
+	case "REJECTED":
+		return proposalStatusRejected
+	case "FAILED":
+		return proposalStatusFailed
+	default:
+		return proposalStatusUnspecified
+	}
+}
+
+// parseTimestamp 解析 RFC3339 字符串或 unix 秒；无法解析时返回零值。
+func parseTimestamp(v any) time.Time {
+	if s, ok := v.(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(s)); err == nil {
+			return t
+		}
+		if n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64); err == nil && n > 0 {
+			return time.Unix(n, 0)
+		}
+		return time.Time{}
+	}
+	if n, ok := toFloat64(v); ok && n > 0 {
+		return time.Unix(int64(n), 0)
+	}
+	return time.Time{}
+}
+
+// pick 返回 obj 中第一个存在且非 null 的字段。
+func pick(obj map[string]any, keys ...string) any {
+	for _, k := range keys {
+		if v, ok := obj[k]; ok && v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
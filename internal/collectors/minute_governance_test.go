@@ -0,0 +1,147 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/stake"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/amount"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+func TestMinuteGovernanceCollector_AggregatesAndBoundedPerProposal(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	zeroTally := map[string]any{"yesCount": "0", "noCount": "0", "abstainCount": "0", "noWithVetoCount": "0"}
+	var mu sync.Mutex
+	proposals := []map[string]any{
+		{"id": "1", "title": "old passed", "status": 3, "votingEndTime": now.Add(-30 * 24 * time.Hour).Format(time.RFC3339)},
+		{"id": "2", "title": "recent rejected", "status": "PROPOSAL_STATUS_REJECTED", "votingEndTime": now.Add(-48 * time.Hour).Format(time.RFC3339),
+			"finalTallyResult": map[string]any{"yesCount": "1000000000000000000", "noCount": "3000000000000000000", "abstainCount": "0", "noWithVetoCount": "500000000000000000"}},
+		{"id": "3", "title": "upgrade", "status": "PROPOSAL_STATUS_VOTING_PERIOD", "votingEndTime": now.Add(24 * time.Hour).Format(time.RFC3339), "finalTallyResult": zeroTally},
+		{"id": "4", "title": "deposit", "status": 1},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/stake/governance/proposals":
+			page, size := 1, 2
+			fmt.Sscan(r.URL.Query().Get("pagination.page"), &page)
+			lo, hi := min((page-1)*size, len(proposals)), min(page*size, len(proposals))
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"code": 0,
+				"data": map[string]any{
+					"proposals":  proposals[lo:hi],
+					"pagination": map[string]any{"page": page, "pageSize": size, "total": len(proposals), "hasNext": hi < len(proposals)},
+				},
+			})
+		case "/stake/governance/proposals/by-id":
+			if r.URL.Query().Get("proposalId") != "3" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"code": 0,
+				"data": map[string]any{"proposal": map[string]any{
+					"id": "3", "status": 2,
+					"tallyResult": map[string]any{"yes": "5000000000000000000", "no": "1000000000000000000", "abstain": "250000000000000000", "noWithVeto": "0"},
+				}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	_, root := metrics.New("biya", "dev", "none")
+	m := root.Owned("minute_governance")
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+	counted := NewProposalCounter()
+	newCollector := func() *MinuteGovernanceCollector {
+		c := NewMinuteGovernanceCollector(logger, m, stake.NewClient(srv.URL+"/stake", "k", 2*time.Second)).
+			WithPaging(2, 5).
+			WithRecentWindow(7 * 24 * time.Hour).
+			WithDenom(amount.Denom{Display: "BYB", Exponent: 18}).
+			WithProposalCounter(counted)
+		c.now = func() time.Time { return now }
+		return c
+	}
+	c := newCollector()
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("collector run err: %v", err)
+	}
+
+	out := m.RenderText()
+	assertContains(t, out, "\nbiya_proposals_total 4\n")
+	assertContains(t, out, "\nbiya_proposals_active 1\n")
+	assertContains(t, out, "\nbiya_proposals_passed 1\n")
+	assertContains(t, out, "\nbiya_proposals_rejected 1\n")
+	assertContains(t, out, "\nbiya_proposal_status{id=\"3\",title=\"upgrade\"} 2\n")
+	assertContains(t, out, "\nbiya_proposal_status{id=\"2\",title=\"recent rejected\"} 4\n")
+	// 投票中的提案用 by-id 的实时计票，换算为 BYB
+	assertContains(t, out, "\nbiya_proposal_votes_yes{id=\"3\"} 5\n")
+	assertContains(t, out, "\nbiya_proposal_votes_abstain{id=\"3\"} 0.25\n")
+	assertContains(t, out, "\nbiya_proposal_votes_no{id=\"2\"} 3\n")
+	assertContains(t, out, "\nbiya_proposal_votes_veto{id=\"2\"} 0.5\n")
+	assertContains(t, out, "\nbiya_proposal_voting_end_timestamp{id=\"3\"} 1792281600\n")
+	for _, id := range []string{"1", "4"} {
+		if strings.Contains(out, "biya_proposal_status{id=\""+id+"\"") {
+			t.Fatalf("proposal %s should not have per-proposal series:\n%s", id, out)
+		}
+	}
+
+	// 新提案只累加增量
+	mu.Lock()
+	proposals = append(proposals, map[string]any{"id": "5", "title": "new", "status": 1})
+	mu.Unlock()
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("collector run err: %v", err)
+	}
+	assertContains(t, m.RenderText(), "\nbiya_proposals_total 5\n")
+
+	// 热加载重建 collector：共享的计数保证已计入的提案不会再次累加
+	if err := newCollector().Run(context.Background()); err != nil {
+		t.Fatalf("collector run err: %v", err)
+	}
+	assertContains(t, m.RenderText(), "\nbiya_proposals_total 5\n")
+
+	// 页数预算不足：run 失败，scheduler 不清理预算外的提案（id=3 在第 2 页）
+	c.maxPages = 1
+	start := time.Now()
+	err := c.Run(context.Background())
+	if err == nil {
+		t.Fatalf("expected truncated run to fail")
+	}
+	root.ExpireOwned("minute_governance", err == nil, start, time.Minute)
+	out = m.RenderText()
+	assertContains(t, out, "\nbiya_proposal_status{id=\"3\",title=\"upgrade\"} 2\n")
+	assertContains(t, out, "\nbiya_proposals_total 5\n")
+}
+
+func TestProposalStatus(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[any]int{
+		json.Number("2"):                 proposalStatusVoting,
+		"PROPOSAL_STATUS_PASSED":         proposalStatusPassed,
+		"proposal_status_failed":         proposalStatusFailed,
+		"PROPOSAL_STATUS_DEPOSIT_PERIOD": proposalStatusDeposit,
+		"3":                              proposalStatusPassed,
+		"bogus":                          proposalStatusUnspecified,
+		json.Number("9"):                 proposalStatusUnspecified,
+	} {
+		if got := proposalStatus(in); got != want {
+			t.Fatalf("proposalStatus(%v) = %d, want %d", in, got, want)
+		}
+	}
+}
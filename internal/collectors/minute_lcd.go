@@ -0,0 +1,166 @@
+package collectors
+
+import (
+	"context"
+	"log/slog"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/circuit"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/lcd"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/amount"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+// MinuteLCDCollector 用 Cosmos LCD 读取链上真实的质押池/供应量/模块参数。
+// 与 stake API 相比，这里的数据直接来自链状态，不依赖上游聚合接口的字段命名。
+type MinuteLCDCollector struct {
+	log *slog.Logger
+	m   *metrics.Metrics
+	lcd *lcd.Client
+
+	// bondDenom 来自 staking params；params 拉取失败时沿用上一次成功的值。
+	bondDenom string
+	// denom 为配置的币种元数据；尚未拿到 params 时用 denom.Base 定位 supply，见 WithDenom
+	denom amount.Denom
+}
+
+func NewMinuteLCDCollector(log *slog.Logger, m *metrics.Metrics, cli *lcd.Client) *MinuteLCDCollector {
+	return &MinuteLCDCollector{log: log, m: m, lcd: cli}
+}
+
+// WithDenom 设置配置的币种元数据；Base 与链上 bond_denom 不一致时以链上为准并告警。
+func (c *MinuteLCDCollector) WithDenom(d amount.Denom) *MinuteLCDCollector {
+	c.denom = d
+	return c
+}
+
+func (c *MinuteLCDCollector) Run(ctx context.Context) error {
+	chainID := c.m.ChainID()
+
+	// 1) staking params：max_validators / bond_denom / unbonding_time
+	c.readStakingParams(ctx)
+
+	// 2) staking pool：bonded / not bonded（raw units）
+	pool, err := c.lcd.StakingPool(circuit.WithSource(ctx, "lcd_staking_pool"))
+	if err != nil {
+		c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "lcd_staking_pool"}, 0)
+		return err
+	}
+	c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "lcd_staking_pool"}, 1)
+
+	// 金额为 18 位小数的基础单位，用 math/big 解析，比例在换算为 float64 之前计算
+	bonded, bondedErr := amount.Parse(pool.Pool.BondedTokens)
+	if bondedErr == nil {
+		c.m.SetGauge("biya_stake_bonded_tokens", map[string]string{"chain_id": chainID}, amount.Float64(bonded))
+	}
+	if v, err := amount.Parse(pool.Pool.NotBonded); err == nil {
+		c.m.SetGauge("biya_stake_not_bonded_tokens", map[string]string{"chain_id": chainID}, amount.Float64(v))
+	}
+
+	// 3) bank supply：staked_ratio = bonded / total_supply(bond_denom)
+	if supply, ok := c.readBondDenomSupply(ctx); ok {
+		c.m.SetGauge("biya_stake_total_supply", map[string]string{"chain_id": chainID}, amount.Float64(supply))
+		if bondedErr == nil {
+			if ratio, ok := amount.Ratio(bonded, supply); ok {
+				c.m.SetGauge("biya_staked_ratio", nil, ratio)
+			}
+		}
+	}
+
+	// 4) slashing / mint：参数类数据，失败不影响本次运行结果
+	c.readSlashingParams(ctx)
+	c.readInflation(ctx)
+
+	return nil
+}
+
+func (c *MinuteLCDCollector) readStakingParams(ctx context.Context) {
+	resp, err := c.lcd.StakingParams(circuit.WithSource(ctx, "lcd_staking_params"))
+	if err != nil {
+		c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "lcd_staking_params"}, 0)
+		return
+	}
+	c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "lcd_staking_params"}, 1)
+
+	p := resp.Params
+	if p.MaxValidators > 0 {
+		c.m.SetGauge("biya_validators_max", nil, float64(p.MaxValidators))
+	}
+	if d, err := time.ParseDuration(strings.TrimSpace(p.UnbondingTime)); err == nil {
+		c.m.SetGauge("biya_stake_unbonding_time_seconds", nil, d.Seconds())
+	}
+	if p.BondDenom != "" {
+		if c.denom.Base != "" && p.BondDenom != c.denom.Base && p.BondDenom != c.bondDenom {
+			c.log.Warn("configured chain.denom.base differs from chain bond_denom; using bond_denom", "collector", "minute_lcd", "configured", c.denom.Base, "bond_denom", p.BondDenom)
+		}
+		c.bondDenom = p.BondDenom
+	}
+}
+
+func (c *MinuteLCDCollector) readBondDenomSupply(ctx context.Context) (*big.Rat, bool) {
+	denom := c.bondDenom
+	if denom == "" {
+		denom = c.denom.Base
+	}
+	if denom == "" {
+		// 尚未拿到 bond_denom，也没有配置 chain.denom.base，无法定位 supply 中对应的币种
+		return nil, false
+	}
+	raw, err := c.lcd.SupplyOf(circuit.WithSource(ctx, "lcd_bank_supply"), denom)
+	if err != nil {
+		c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "lcd_bank_supply"}, 0)
+		warnSourceErr(c.log, "lcd supply unavailable", err, "collector", "minute_lcd", "denom", denom)
+		return nil, false
+	}
+	c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "lcd_bank_supply"}, 1)
+	supply, err := amount.Parse(raw)
+	if err != nil {
+		c.log.Warn("lcd supply parse failed", "collector", "minute_lcd", "denom", denom, "err", err)
+		return nil, false
+	}
+	return supply, true
+}
+
+func (c *MinuteLCDCollector) readSlashingParams(ctx context.Context) {
+	resp, err := c.lcd.SlashingParams(circuit.WithSource(ctx, "lcd_slashing_params"))
+	if err != nil {
+		c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "lcd_slashing_params"}, 0)
+		return
+	}
+	c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "lcd_slashing_params"}, 1)
+
+	if v, ok := toFloat64(resp.Params.SignedBlocksWindow); ok {
+		c.m.SetGauge("biya_slashing_signed_blocks_window", nil, v)
+	}
+	if v, ok := toFloat64(resp.Params.MinSignedPerWindow); ok {
+		c.m.SetGauge("biya_slashing_min_signed_per_window", nil, v)
+	}
+}
+
+func (c *MinuteLCDCollector) readInflation(ctx context.Context) {
+	resp, err := c.lcd.Inflation(circuit.WithSource(ctx, "lcd_mint_inflation"))
+	if err != nil {
+		c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "lcd_mint_inflation"}, 0)
+		return
+	}
+	c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "lcd_mint_inflation"}, 1)
+	if v, ok := toFloat64(resp.Inflation); ok {
+		c.m.SetGauge("biya_inflation_rate", nil, v)
+	}
+
+	// mint params 仅用于补充通胀上下限；部分链没有 mint 模块，失败时只标记 source_up。
+	params, err := c.lcd.MintParams(circuit.WithSource(ctx, "lcd_mint_params"))
+	if err != nil {
+		c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "lcd_mint_params"}, 0)
+		return
+	}
+	c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "lcd_mint_params"}, 1)
+	if v, ok := toFloat64(params.Params.InflationMax); ok {
+		c.m.SetGauge("biya_inflation_rate_max", nil, v)
+	}
+	if v, ok := toFloat64(params.Params.InflationMin); ok {
+		c.m.SetGauge("biya_inflation_rate_min", nil, v)
+	}
+}
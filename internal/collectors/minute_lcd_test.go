@@ -0,0 +1,87 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/lcd"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+func TestMinuteLCDCollector_PoolSupplyAndParams(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/cosmos/staking/v1beta1/params":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"params": map[string]any{
+					"unbonding_time": "1814400s",
+					"max_validators": 50,
+					"bond_denom":     "ubyb",
+				},
+			})
+		case "/cosmos/staking/v1beta1/pool":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"pool": map[string]any{
+					"bonded_tokens":     "600",
+					"not_bonded_tokens": "100",
+				},
+			})
+		case "/cosmos/bank/v1beta1/supply/by_denom":
+			if r.URL.Query().Get("denom") != "ubyb" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"amount": map[string]any{"denom": "ubyb", "amount": "1000"},
+			})
+		case "/cosmos/slashing/v1beta1/params":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"params": map[string]any{
+					"signed_blocks_window":  "10000",
+					"min_signed_per_window": "0.500000000000000000",
+				},
+			})
+		case "/cosmos/mint/v1beta1/inflation":
+			_ = json.NewEncoder(w).Encode(map[string]any{"inflation": "0.130000000000000000"})
+		case "/cosmos/mint/v1beta1/params":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"params": map[string]any{
+					"inflation_max": "0.200000000000000000",
+					"inflation_min": "0.070000000000000000",
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	_, m := metrics.New("biya", "dev", "none")
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+	cli := lcd.NewClient(srv.URL, 2*time.Second)
+
+	c := NewMinuteLCDCollector(logger, m, cli)
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("collector run err: %v", err)
+	}
+
+	out := m.RenderText()
+	assertContains(t, out, "\nbiya_stake_bonded_tokens{chain_id=\"biya\"} 600\n")
+	assertContains(t, out, "\nbiya_stake_not_bonded_tokens{chain_id=\"biya\"} 100\n")
+	assertContains(t, out, "\nbiya_stake_total_supply{chain_id=\"biya\"} 1000\n")
+	assertContains(t, out, "\nbiya_staked_ratio 0.6\n")
+	assertContains(t, out, "\nbiya_validators_max 50\n")
+	assertContains(t, out, "\nbiya_stake_unbonding_time_seconds 1814400\n")
+	assertContains(t, out, "\nbiya_slashing_signed_blocks_window 10000\n")
+	assertContains(t, out, "\nbiya_inflation_rate 0.13\n")
+	assertContains(t, out, "\nbiya_inflation_rate_max 0.2\n")
+}
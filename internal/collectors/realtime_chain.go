@@ -2,125 +2,147 @@ package collectors
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"strconv"
 	"sync"
-	"time"
 
 	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/tendermint"
 	"github.com/biya-coin/biya-dex-backend-exporter/internal/config"
 	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
 )
 
+// ChainNode 为一个具名的 Tendermint RPC 节点；Name 即指标中的 node label。
+type ChainNode struct {
+	Name   string
+	Client *tendermint.Client
+}
+
 type RealtimeChainCollector struct {
-	log  *slog.Logger
-	m    *metrics.Metrics
-	tm   *tendermint.Client
-	mock config.MockConfig
-
-	mu         sync.Mutex
-	lastHeight int64
-	lastTime   time.Time
-	lastAvgBT  float64
+	log   *slog.Logger
+	m     *metrics.Metrics
+	nodes []ChainNode
+	head  *ChainHead
+	mock  config.MockConfig
+
+	// infoLabels 为各节点上次成功写入 biya_node_info 的 label，节点失败时按兜底策略处理该 series
+	infoLabels map[string]map[string]string
+}
+
+// NewRealtimeChainCollector 并发轮询所有节点的 /status。
+// head 汇总各节点与 explorer 的最新高度，用于计算 biya_node_behind_blocks；可为 nil（仅按节点间最大高度计算）。
+func NewRealtimeChainCollector(log *slog.Logger, m *metrics.Metrics, nodes []ChainNode, head *ChainHead, mock config.MockConfig) *RealtimeChainCollector {
+	if head == nil {
+		head = NewChainHead()
+	}
+	return &RealtimeChainCollector{log: log, m: m, nodes: nodes, head: head, mock: mock, infoLabels: map[string]map[string]string{}}
 }
 
-func NewRealtimeChainCollector(log *slog.Logger, m *metrics.Metrics, tm *tendermint.Client, mock config.MockConfig) *RealtimeChainCollector {
-	return &RealtimeChainCollector{log: log, m: m, tm: tm, mock: mock}
+type nodeStatus struct {
+	node   ChainNode
+	st     *tendermint.StatusResponse
+	height int64
+	err    error
 }
 
 func (c *RealtimeChainCollector) Run(ctx context.Context) error {
 	chainID := c.m.ChainID()
 
-	st, err := c.tm.Status(ctx)
-	if err != nil {
+	results := c.pollNodes(ctx)
+
+	var best *nodeStatus
+	var errs []error
+	for i := range results {
+		r := &results[i]
+		nodeLabels := map[string]string{"node": r.node.Name}
+		if r.err != nil {
+			c.m.SetGauge("biya_node_up", nodeLabels, 0)
+			// 节点不可达时其余节点级 gauge 按兜底策略处理，而不是一直停留在上次成功的值
+			c.m.Unavailable("biya_node_behind_blocks", nodeLabels)
+			c.m.Unavailable("biya_node_sync_height", nodeLabels)
+			c.m.Unavailable("biya_node_sync_status", nodeLabels)
+			if info, ok := c.infoLabels[r.node.Name]; ok {
+				c.m.Unavailable("biya_node_info", info)
+			}
+			c.log.Warn("tendermint node status failed", "collector", "realtime_chain", "node", r.node.Name, "err", r.err)
+			errs = append(errs, fmt.Errorf("node %s: %w", r.node.Name, r.err))
+			continue
+		}
+		c.m.SetGauge("biya_node_up", nodeLabels, 1)
+		info := map[string]string{
+			"node":    r.node.Name,
+			"moniker": r.st.Result.NodeInfo.Moniker,
+			"network": r.st.Result.NodeInfo.Network,
+		}
+		c.m.SetGauge("biya_node_info", info, 1)
+		c.infoLabels[r.node.Name] = info
+		if r.st.Result.SyncInfo.CatchingUp {
+			c.m.SetGauge("biya_node_sync_status", nodeLabels, 0)
+		} else {
+			c.m.SetGauge("biya_node_sync_status", nodeLabels, 1)
+		}
+		c.m.SetGauge("biya_node_sync_height", nodeLabels, float64(r.height))
+		c.head.Observe(r.node.Name, r.height)
+
+		if best == nil || r.height > best.height {
+			best = r
+		}
+	}
+	if best == nil {
 		c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "tendermint_status"}, 0)
-		return err
+		return errors.Join(errs...)
 	}
 	c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "tendermint_status"}, 1)
 
-	h, err := strconv.ParseInt(st.Result.SyncInfo.LatestBlockHeight, 10, 64)
-	if err != nil {
-		return err
+	// behind_blocks：以所有节点与 explorer 观测到的最大高度为参考
+	ref := c.head.Max()
+	for _, r := range results {
+		if r.err != nil {
+			continue
+		}
+		behind := ref - r.height
+		if behind < 0 {
+			behind = 0
+		}
+		c.m.SetGauge("biya_node_behind_blocks", map[string]string{"node": r.node.Name}, float64(behind))
 	}
-	c.m.SetGauge("biya_chain_head_block_height", map[string]string{"chain_id": chainID}, float64(h))
+
+	// 链级指标取最高的节点，避免落后节点把 head 高度拉低
+	c.m.SetGauge("biya_chain_head_block_height", map[string]string{"chain_id": chainID}, float64(best.height))
 	// 注意：provide.md 口径里 biya_block_height 来自 explorer /api/v1/block/latest
 	// 这里不再写入 biya_block_height，避免同名指标被多个 collector 覆盖导致口径冲突。
-	// blocks_total 当前仍用高度近似（若后续接入 explorer 的 blocks_total，可再调整为 explorer 为准）
-	c.m.SetGauge("biya_blocks_total", nil, float64(h))
-	if st.Result.SyncInfo.CatchingUp {
+	// biya_blocks_total / 出块时间 / 确认时间由 BlockFollower 的订阅者逐块计算，这里不再用 /status 单点近似。
+	if best.st.Result.SyncInfo.CatchingUp {
 		c.m.SetGauge("biya_chain_node_catching_up", map[string]string{"chain_id": chainID}, 1)
-		c.m.SetGauge("biya_node_sync_status", map[string]string{"node": "default"}, 0)
 	} else {
 		c.m.SetGauge("biya_chain_node_catching_up", map[string]string{"chain_id": chainID}, 0)
-		c.m.SetGauge("biya_node_sync_status", map[string]string{"node": "default"}, 1)
-	}
-	c.m.SetGauge("biya_node_sync_height", map[string]string{"node": "default"}, float64(h))
-	c.m.SetGauge("biya_node_behind_blocks", map[string]string{"node": "default"}, 0)
-
-	avgBT := c.updateBlockTimeAvg(h, st.Result.SyncInfo.LatestBlockTime)
-	if avgBT > 0 {
-		c.m.SetGauge("biya_chain_block_time_seconds_avg", map[string]string{"chain_id": chainID}, avgBT)
-		// 注意：provide.md 口径里 biya_block_time_seconds 来自 explorer /api/v1/transaction/stats 的 avg_block_time
-		// 这里不再写入 biya_block_time_seconds，避免同名指标被多个 collector 覆盖导致口径冲突。
-		// BFT 下确认时间可先近似为出块时间
-		c.m.SetGauge("biya_chain_tx_confirm_time_seconds_avg", map[string]string{"chain_id": chainID}, avgBT)
-		c.m.SetGauge("biya_tx_confirm_time_avg_seconds", nil, avgBT)
-		// histogram 先用近似值打一条样本，后续接 explorer 的真实确认时间分布
-		c.m.ObserveHistogramMetric("biya_tx_confirm_time_seconds", nil, []float64{1, 2, 3, 5, 10, 20, 30, 60, 120}, avgBT)
-	} else if c.mock.Enabled {
-		v := c.mock.Values.TxConfirmTimeSeconds
-		c.m.SetGauge("biya_chain_tx_confirm_time_seconds_avg", map[string]string{"chain_id": chainID}, v)
-		c.m.SetGauge("biya_tx_confirm_time_avg_seconds", nil, v)
-		c.m.ObserveHistogramMetric("biya_tx_confirm_time_seconds", nil, []float64{1, 2, 3, 5, 10, 20, 30, 60, 120}, v)
 	}
 
-	// gas utilization / congestion 目前按约定先 Mock
+	// congestion 目前按约定先 Mock；gas utilization 由 GasSubscriber 基于 block_results 计算
 	if c.mock.Enabled {
-		c.m.SetGauge("biya_chain_block_gas_utilization_ratio_avg", map[string]string{"chain_id": chainID}, c.mock.Values.GasUtilizationRatio)
 		c.m.SetGauge("biya_chain_congestion_ratio", map[string]string{"chain_id": chainID}, c.mock.Values.CongestionRatio)
-		c.m.SetGauge("biya_gas_utilization_ratio", nil, c.mock.Values.GasUtilizationRatio)
 	}
 
 	return nil
 }
 
-func (c *RealtimeChainCollector) updateBlockTimeAvg(latestHeight int64, latestTime time.Time) float64 {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	// 首次无法计算平均值，仅记录基线
-	if c.lastHeight == 0 || c.lastTime.IsZero() {
-		c.lastHeight = latestHeight
-		c.lastTime = latestTime
-		return c.lastAvgBT
-	}
-
-	dh := latestHeight - c.lastHeight
-	if dh <= 0 {
-		// 高度未增长或回退，直接返回上一次值
-		return c.lastAvgBT
-	}
-
-	dt := latestTime.Sub(c.lastTime).Seconds()
-	if dt <= 0 {
-		return c.lastAvgBT
-	}
-
-	bt := dt / float64(dh)
-	// 简单 EMA 平滑，避免瞬时抖动；alpha 固定 0.3（低成本、够用）
-	if c.lastAvgBT <= 0 {
-		c.lastAvgBT = bt
-	} else {
-		const alpha = 0.3
-		c.lastAvgBT = alpha*bt + (1-alpha)*c.lastAvgBT
-	}
-
-	c.lastHeight = latestHeight
-	c.lastTime = latestTime
-
-	// 极端值保护：出块时间异常大时不让它把指标打爆（仍保留在日志里）
-	if c.lastAvgBT > 3600 {
-		return 0
+func (c *RealtimeChainCollector) pollNodes(ctx context.Context) []nodeStatus {
+	results := make([]nodeStatus, len(c.nodes))
+	var wg sync.WaitGroup
+	for i, n := range c.nodes {
+		wg.Add(1)
+		go func(i int, n ChainNode) {
+			defer wg.Done()
+			r := nodeStatus{node: n}
+			// 节点 /status 本身就是 biya_node_up 的探活，不经过熔断
+			r.st, r.err = n.Client.Status(ctx)
+			if r.err == nil {
+				r.height, r.err = strconv.ParseInt(r.st.Result.SyncInfo.LatestBlockHeight, 10, 64)
+			}
+			results[i] = r
+		}(i, n)
 	}
-	return c.lastAvgBT
+	wg.Wait()
+	return results
 }
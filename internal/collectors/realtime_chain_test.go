@@ -0,0 +1,156 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/tendermint"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/config"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+func newStatusServer(t *testing.T, moniker, height string, catchingUp bool) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/status" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"result": map[string]any{
+				"node_info": map[string]any{"network": "biya-1", "moniker": moniker},
+				"sync_info": map[string]any{
+					"latest_block_height": height,
+					"latest_block_time":   "2025-01-01T00:00:00Z",
+					"catching_up":         catchingUp,
+				},
+			},
+		})
+	}))
+}
+
+func TestRealtimeChainCollector_MultiNodeBehindBlocks(t *testing.T) {
+	t.Parallel()
+
+	sentry := newStatusServer(t, "sentry-a", "100", false)
+	defer sentry.Close()
+	rpc := newStatusServer(t, "rpc-b", "90", true)
+	defer rpc.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	_, m := metrics.New("biya", "dev", "none")
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+	nodes := []ChainNode{
+		{Name: "sentry", Client: tendermint.NewClient(sentry.URL, 2*time.Second)},
+		{Name: "rpc", Client: tendermint.NewClient(rpc.URL, 2*time.Second)},
+		{Name: "down", Client: tendermint.NewClient(down.URL, 2*time.Second)},
+	}
+	head := NewChainHead()
+	// explorer 已经看到更高的区块
+	head.Observe("explorer", 105)
+
+	c := NewRealtimeChainCollector(logger, m, nodes, head, config.MockConfig{Enabled: false})
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("collector run err: %v", err)
+	}
+
+	out := m.RenderText()
+	assertContains(t, out, "\nbiya_node_behind_blocks{node=\"sentry\"} 5\n")
+	assertContains(t, out, "\nbiya_node_behind_blocks{node=\"rpc\"} 15\n")
+	assertContains(t, out, "\nbiya_node_sync_status{node=\"rpc\"} 0\n")
+	assertContains(t, out, "\nbiya_node_up{node=\"down\"} 0\n")
+	assertContains(t, out, "\nbiya_node_info{node=\"sentry\",moniker=\"sentry-a\",network=\"biya-1\"} 1\n")
+	assertContains(t, out, "\nbiya_chain_head_block_height{chain_id=\"biya\"} 100\n")
+}
+
+func TestRealtimeChainCollector_FailingNodeAppliesFallback(t *testing.T) {
+	t.Parallel()
+
+	sentry := newStatusServer(t, "sentry-a", "100", false)
+	defer sentry.Close()
+	flaky := newStatusServer(t, "flaky-b", "98", false)
+	var failing atomic.Bool
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		flaky.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer proxy.Close()
+	defer flaky.Close()
+
+	_, m := metrics.New("biya", "dev", "none")
+	m.SetFallbackPolicy(metrics.FallbackDrop, map[string]metrics.FallbackPolicy{
+		"biya_node_sync_height": metrics.FallbackZero,
+	})
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+	nodes := []ChainNode{
+		{Name: "sentry", Client: tendermint.NewClient(sentry.URL, 2*time.Second)},
+		{Name: "flaky", Client: tendermint.NewClient(proxy.URL, 2*time.Second)},
+	}
+	c := NewRealtimeChainCollector(logger, m, nodes, NewChainHead(), config.MockConfig{Enabled: false})
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("collector run err: %v", err)
+	}
+	assertContains(t, m.RenderText(), "\nbiya_node_behind_blocks{node=\"flaky\"} 2\n")
+
+	// 节点故障：除 biya_node_up=0 外，其余节点级 gauge 也按兜底策略处理
+	failing.Store(true)
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("collector run err: %v", err)
+	}
+	out := m.RenderText()
+	assertContains(t, out, "\nbiya_node_up{node=\"flaky\"} 0\n")
+	assertContains(t, out, "\nbiya_node_sync_height{node=\"flaky\"} 0\n")
+	for _, gone := range []string{
+		"biya_node_behind_blocks{node=\"flaky\"}",
+		"biya_node_sync_status{node=\"flaky\"}",
+		"biya_node_info{node=\"flaky\"",
+	} {
+		if strings.Contains(out, gone) {
+			t.Fatalf("expected %s to be dropped:\n%s", gone, out)
+		}
+	}
+	assertContains(t, out, "\nbiya_node_behind_blocks{node=\"sentry\"} 0\n")
+}
+
+func TestChainHead_LatestObservationPerSource(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	head := NewChainHead()
+	head.now = func() time.Time { return now }
+	head.Observe("explorer", 1000)
+	head.Observe("sentry", 990)
+	if got := head.Max(); got != 1000 {
+		t.Fatalf("max = %d, want 1000", got)
+	}
+
+	// 链重置 / 异常读数：来源的新观测直接覆盖，参考值随之回落
+	head.Observe("explorer", 12)
+	head.Observe("sentry", 10)
+	if got := head.Max(); got != 12 {
+		t.Fatalf("max after reset = %d, want 12", got)
+	}
+
+	// 停止上报的来源过期后不再参与计算
+	head.Observe("stream", 500)
+	now = now.Add(chainHeadMaxAge / 2)
+	head.Observe("sentry", 11)
+	now = now.Add(chainHeadMaxAge/2 + time.Second)
+	if got := head.Max(); got != 11 {
+		t.Fatalf("max after stale sources expired = %d, want 11", got)
+	}
+}
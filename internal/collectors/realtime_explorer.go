@@ -3,7 +3,11 @@ package collectors
 import (
 	"context"
 	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
 
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/circuit"
 	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/explorer"
 	"github.com/biya-coin/biya-dex-backend-exporter/internal/config"
 	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
@@ -16,20 +20,56 @@ type RealtimeExplorerCollector struct {
 	m    *metrics.Metrics
 	api  *explorer.Client
 	mock config.MockConfig
+	head *ChainHead
+
+	// 以下字段仅在 UseStreams 后使用
+	streaming  bool
+	streamOnce sync.Once
+	blocksUp   atomic.Bool
+	txsUp      atomic.Bool
+	// heightMu 保护 lastHeight：轮询与区块流可能并发写 biya_block_height，只允许高度前进
+	heightMu   sync.Mutex
+	lastHeight int64
 }
 
 func NewRealtimeExplorerCollector(log *slog.Logger, m *metrics.Metrics, api *explorer.Client, mock config.MockConfig) *RealtimeExplorerCollector {
 	return &RealtimeExplorerCollector{log: log, m: m, api: api, mock: mock}
 }
 
+// TrackChainHead 将 explorer 观测到的最新高度汇总到 head，作为节点落后区块数的参考高度之一。
+func (c *RealtimeExplorerCollector) TrackChainHead(head *ChainHead) *RealtimeExplorerCollector {
+	c.head = head
+	return c
+}
+
+// UseStreams 改为消费 explorer 的流式接口（/api/v1/stream/blocks、/api/v1/stream/transactions）：
+// 区块流连接期间不再轮询 /block/latest，断线时自动回退到轮询。
+func (c *RealtimeExplorerCollector) UseStreams() *RealtimeExplorerCollector {
+	c.streaming = true
+	return c
+}
+
 func (c *RealtimeExplorerCollector) Run(ctx context.Context) error {
 	// provide.md 指标口径：
 	// - block height:   GET /api/v1/block/latest                -> .data.data[0].height
 	// - tx stats:       GET /api/v1/transaction/stats           -> .data.count_24h / .data.tps / .data.avg_block_time / .data.active_addresses_24h
 	// - gas price gwei: GET /api/v1/block/gas-utilization       -> .data.gas_price（你已澄清：该字段即“平均 gas 费”）
 
-	if v, ok := c.readLatestBlockHeight(ctx); ok {
-		c.m.SetGauge("biya_block_height", nil, v)
+	if c.streaming {
+		// 与 ChainStream 相同：流在 job 生命周期内只启动一次，热加载替换或退出时随 ctx 结束
+		c.streamOnce.Do(func() {
+			streamCtx := jobLifetime(ctx)
+			go c.streamBlocks(streamCtx)
+			go c.streamTransactions(streamCtx)
+		})
+		c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "explorer_stream_blocks"}, boolToFloat(c.blocksUp.Load()))
+		c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "explorer_stream_transactions"}, boolToFloat(c.txsUp.Load()))
+	}
+
+	if !c.blocksUp.Load() {
+		if v, ok := c.readLatestBlockHeight(ctx); ok {
+			c.observeHeight(int64(v))
+		}
 	}
 
 	if stats, ok := c.readTransactionStats(ctx); ok {
@@ -54,8 +94,59 @@ func (c *RealtimeExplorerCollector) Run(ctx context.Context) error {
 	return nil
 }
 
+func (c *RealtimeExplorerCollector) streamBlocks(ctx context.Context) {
+	_ = c.api.StreamBlocks(ctx, explorer.BlockStream{
+		OnBlock: func(u explorer.BlockUpdate) {
+			c.m.IncCounter("biya_explorer_stream_messages_total", map[string]string{"stream": "blocks"})
+			if h, err := strconv.ParseInt(u.Block.Height, 10, 64); err == nil {
+				c.observeHeight(h)
+			}
+		},
+		OnConnState: func(connected bool, err error) {
+			c.blocksUp.Store(connected)
+			c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "explorer_stream_blocks"}, boolToFloat(connected))
+			if connected {
+				c.log.Info("explorer block stream connected", "collector", "realtime_explorer")
+				return
+			}
+			c.log.Warn("explorer block stream down, fallback to polling", "collector", "realtime_explorer", "err", err)
+		},
+	})
+}
+
+func (c *RealtimeExplorerCollector) observeHeight(h int64) {
+	c.heightMu.Lock()
+	defer c.heightMu.Unlock()
+	if c.streaming && h < c.lastHeight {
+		return
+	}
+	c.lastHeight = h
+	c.m.SetGauge("biya_block_height", nil, float64(h))
+	c.head.Observe("explorer", h)
+}
+
+func (c *RealtimeExplorerCollector) streamTransactions(ctx context.Context) {
+	_ = c.api.StreamTransactions(ctx, explorer.TransactionStream{
+		OnTransaction: func(u explorer.TransactionUpdate) {
+			c.m.IncCounter("biya_explorer_stream_messages_total", map[string]string{"stream": "transactions"})
+			status := "success"
+			if u.Transaction.Code != 0 {
+				status = "failed"
+			}
+			c.m.IncCounter("biya_explorer_transactions_streamed_total", map[string]string{"status": status})
+		},
+		OnConnState: func(connected bool, err error) {
+			c.txsUp.Store(connected)
+			c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "explorer_stream_transactions"}, boolToFloat(connected))
+			if !connected {
+				c.log.Warn("explorer transaction stream down, reconnecting", "collector", "realtime_explorer", "err", err)
+			}
+		},
+	})
+}
+
 func (c *RealtimeExplorerCollector) readLatestBlockHeight(ctx context.Context) (float64, bool) {
-	raw, err := c.api.GetLatestBlocks(ctx, explorer.CursorPage{Page: 1, PageSize: 1})
+	raw, err := c.api.GetLatestBlocks(circuit.WithSource(ctx, "explorer_latest_block"), explorer.CursorPage{Page: 1, PageSize: 1})
 	if err != nil {
 		c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "explorer_latest_block"}, 0)
 		return 0, false
@@ -88,7 +179,7 @@ type txStats struct {
 }
 
 func (c *RealtimeExplorerCollector) readTransactionStats(ctx context.Context) (txStats, bool) {
-	raw, err := c.api.GetTransactionStats(ctx)
+	raw, err := c.api.GetTransactionStats(circuit.WithSource(ctx, "explorer_transaction_stats"))
 	if err != nil {
 		c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "explorer_transaction_stats"}, 0)
 		return txStats{}, false
@@ -130,7 +221,7 @@ func (c *RealtimeExplorerCollector) readTransactionStats(ctx context.Context) (t
 }
 
 func (c *RealtimeExplorerCollector) readGasPriceGwei(ctx context.Context) (float64, bool) {
-	raw, err := c.api.GetBlockGasUtilization(ctx)
+	raw, err := c.api.GetBlockGasUtilization(circuit.WithSource(ctx, "explorer_block_gas_price"))
 	if err != nil {
 		c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "explorer_block_gas_price"}, 0)
 		return 0, false
@@ -156,3 +247,10 @@ func (c *RealtimeExplorerCollector) readGasPriceGwei(ctx context.Context) (float
 	c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "explorer_block_gas_price"}, 1)
 	return v, true
 }
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
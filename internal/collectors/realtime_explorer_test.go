@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -80,6 +81,66 @@ func TestRealtimeExplorerCollector_ProvideMDMetrics(t *testing.T) {
 	assertContains(t, out, "\nbiya_gas_price 88.8\n")
 }
 
+func TestRealtimeExplorerCollector_StreamsReplaceLatestBlockPolling(t *testing.T) {
+	t.Parallel()
+
+	var latestCalls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/stream/blocks":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: {\"result\":{\"block\":{\"height\":\"200\"}}}\n\n"))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		case "/api/v1/stream/transactions":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"transaction":{"hash":"A","code":0}}` + "\n" + `{"transaction":{"hash":"B","code":11}}` + "\n"))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		case "/api/v1/block/latest":
+			latestCalls.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{"code": 0, "data": map[string]any{"data": []any{map[string]any{"height": "150"}}}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	_, m := metrics.New("biya", "dev", "none")
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+	head := NewChainHead()
+	c := NewRealtimeExplorerCollector(logger, m, explorer.NewClient(srv.URL, "k", 2*time.Second), config.MockConfig{}).TrackChainHead(head).UseStreams()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// 流尚未连上时仍然轮询兜底
+	if err := c.Run(ctx); err != nil {
+		t.Fatalf("collector run err: %v", err)
+	}
+	waitFor(t, "streamed block height", func() bool {
+		return strings.Contains(m.RenderText(), "\nbiya_block_height 200\n")
+	})
+	waitFor(t, "streamed transactions", func() bool {
+		return strings.Contains(m.RenderText(), "\nbiya_explorer_transactions_streamed_total{status=\"failed\"} 1\n")
+	})
+
+	polled := latestCalls.Load()
+	if err := c.Run(ctx); err != nil {
+		t.Fatalf("collector run err: %v", err)
+	}
+	if got := latestCalls.Load(); got != polled {
+		t.Fatalf("/block/latest polled while block stream connected: %d -> %d", polled, got)
+	}
+	out := m.RenderText()
+	assertContains(t, out, "\nbiya_block_height 200\n")
+	assertContains(t, out, "\nbiya_explorer_stream_messages_total{stream=\"blocks\"} 1\n")
+	assertContains(t, out, "\nbiya_explorer_stream_messages_total{stream=\"transactions\"} 2\n")
+	assertContains(t, out, "\nbiya_explorer_transactions_streamed_total{status=\"success\"} 1\n")
+	assertContains(t, out, "\nbiya_exporter_source_up{source=\"explorer_stream_blocks\"} 1\n")
+	assertContains(t, out, "\nbiya_exporter_source_up{source=\"explorer_stream_transactions\"} 1\n")
+}
+
 func assertContains(t *testing.T, s, sub string) {
 	t.Helper()
 	if !strings.Contains(s, sub) {
@@ -2,10 +2,15 @@ package collectors
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log/slog"
+	"math/big"
 	"time"
 
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/circuit"
 	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/stake"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/amount"
 	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
 )
 
@@ -13,57 +18,176 @@ type RealtimeStakeCollector struct {
 	log *slog.Logger
 	m   *metrics.Metrics
 	api *stake.Client
+
+	// stakedRatioFromLCD 为 true 时 biya_staked_ratio 由 MinuteLCDCollector 基于链上 supply 计算，
+	// 这里不再用 stake API 的字段覆盖，避免同名指标口径冲突。
+	stakedRatioFromLCD bool
+
+	// slashing 增量拉取惩罚事件并保存高水位，见 WithSlashing
+	slashing     *SlashingIngester
+	slashingOpts SlashingOptions
+
+	// 验证人列表分页参数，见 WithValidatorPaging
+	validatorsPageSize int
+	validatorsMaxPages int
+
+	// denom 用于把 tokens 等基础单位金额换算为 BYB，见 WithDenom
+	denom amount.Denom
+	// details 缓存 GetValidator 的详情（commission），见 WithValidatorDetails
+	details *ValidatorDetailCache
 }
 
 func NewRealtimeStakeCollector(log *slog.Logger, m *metrics.Metrics, api *stake.Client) *RealtimeStakeCollector {
-	return &RealtimeStakeCollector{log: log, m: m, api: api}
+	return &RealtimeStakeCollector{
+		log:                log,
+		m:                  m,
+		api:                api,
+		validatorsPageSize: 100,
+		validatorsMaxPages: 20,
+		denom:              amount.Denom{Display: "BYB", Exponent: 18},
+		details:            NewValidatorDetailCache(10*time.Minute, 4, 20),
+		slashing:           NewSlashingIngester(log, ""),
+		slashingOpts:       SlashingOptions{PageSize: 100, MaxPages: 20, InitialLookback: 24 * time.Hour, Overlap: 10 * time.Minute},
+	}
+}
+
+// WithSlashing 设置惩罚事件的 ingester（跨重载共享，保存高水位）与拉取参数。
+func (c *RealtimeStakeCollector) WithSlashing(s *SlashingIngester, opts SlashingOptions) *RealtimeStakeCollector {
+	c.slashing, c.slashingOpts = s, opts
+	return c
+}
+
+// WithDenom 设置基础单位换算为 BYB 的币种元数据。
+func (c *RealtimeStakeCollector) WithDenom(d amount.Denom) *RealtimeStakeCollector {
+	c.denom = d
+	return c
+}
+
+// WithValidatorDetails 使用跨重载共享的验证人详情缓存（缓存时间、并发数等参数由 cache 自身配置）。
+func (c *RealtimeStakeCollector) WithValidatorDetails(cache *ValidatorDetailCache) *RealtimeStakeCollector {
+	if cache != nil {
+		c.details = cache
+	}
+	return c
+}
+
+// WithValidatorPaging 设置验证人列表的分页大小与单次采集的页数预算（<=0 保持默认）。
+func (c *RealtimeStakeCollector) WithValidatorPaging(pageSize, maxPages int) *RealtimeStakeCollector {
+	if pageSize > 0 {
+		c.validatorsPageSize = pageSize
+	}
+	if maxPages > 0 {
+		c.validatorsMaxPages = maxPages
+	}
+	return c
+}
+
+// UseLCDStakedRatio 声明 biya_staked_ratio 改由 LCD collector 写入。
+func (c *RealtimeStakeCollector) UseLCDStakedRatio() *RealtimeStakeCollector {
+	c.stakedRatioFromLCD = true
+	return c
 }
 
 func (c *RealtimeStakeCollector) Run(ctx context.Context) error {
+	// 验证人列表失败时其余数据源照常采集，但本次 run 以失败结束：
+	// scheduler 只在 run 成功后清理未刷新的 run-scoped series，失败时 biya_validator_* 保留上次值（直到 series TTL）
+	err := c.readValidators(ctx)
+
+	// 获取质押统计信息
+	c.readStatistics(ctx)
+
+	// 获取惩罚事件
+	c.readSlashingEvents(ctx)
+
+	// 获取治理统计信息
+	c.readGovernanceStatistics(ctx)
+
+	return err
+}
+
+// readValidators 拉取验证人列表，写入聚合与单验证人指标。
+func (c *RealtimeStakeCollector) readValidators(ctx context.Context) error {
 	chainID := c.m.ChainID()
 
-	resp, err := c.api.GetValidators(ctx, 1, 100)
+	list, err := c.api.ListValidators(circuit.WithSource(ctx, "stake_validators"), c.validatorsPageSize, c.validatorsMaxPages)
 	if err != nil {
 		c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "stake_validators"}, 0)
-		// 按需求：拿不到先返回固定值，不让 exporter 直接失败
-		c.m.SetGauge("biya_validators_total", nil, 0)
-		// provide.md：biya_validators_active 取 validators 数组长度
-		c.m.SetGauge("biya_validators_active", nil, 0)
-		c.m.SetGauge("biya_validators_jailed", nil, 0)
-		return nil
+		// 拿不到时按 metrics.fallback 处理（置 0 / 保留上次值 / 删除）
+		c.m.Unavailable("biya_validators_total", nil)
+		c.m.Unavailable("biya_validators_active", nil)
+		c.m.Unavailable("biya_validators_jailed", nil)
+		return fmt.Errorf("stake validators: %w", err)
 	}
 	c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "stake_validators"}, 1)
 
-	total := len(resp.Validators)
+	if list.Truncated {
+		c.log.Warn("validator list truncated by page budget", "collector", "realtime_stake", "pages", list.Pages, "ingested", len(list.Validators), "upstream_total", list.Total)
+	}
+
+	total := len(list.Validators)
 	var jailed, bonded int
 	var uptimeSum float64
 	var uptimeN int
+	// bondedTokens 为 bonded 验证人 tokens（基础单位）之和，作为 voting power 占比的分母
+	bondedTokens := new(big.Rat)
+	byStatus := map[string]int{"bonded": 0, "unbonding": 0, "unbonded": 0}
+	operators := make([]string, 0, len(list.Validators))
 
-	for _, v := range resp.Validators {
+	for _, v := range list.Validators {
 		if v.Jailed {
 			jailed++
 		}
+		byStatus[validatorStatusName(v.Status)]++
 		// Cosmos staking status：1 unbonded, 2 unbonding, 3 bonded
 		if v.Status == 3 {
 			bonded++
+			if t, err := amount.Parse(v.Tokens); err == nil {
+				bondedTokens.Add(bondedTokens, t)
+			}
 		}
 		if v.UptimePercentage > 0 {
 			uptimeSum += v.UptimePercentage
 			uptimeN++
 		}
+		operators = append(operators, v.OperatorAddress)
+	}
+
+	// commission 不在列表接口中，按 operator 查询 GetValidator 并缓存（见 ValidatorDetailCache）
+	attempted, err := c.details.refresh(ctx, operators, time.Now(), func(ctx context.Context, op string) (validatorDetail, error) {
+		raw, err := c.api.GetValidator(circuit.WithSource(ctx, "stake_validator_detail"), op)
+		if err != nil {
+			return validatorDetail{}, err
+		}
+		return parseValidatorDetail(raw)
+	})
+	if attempted > 0 {
+		if err != nil {
+			warnSourceErr(c.log, "stake validator detail failed", err, "attempted", attempted)
+			c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "stake_validator_detail"}, 0)
+		} else {
+			c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "stake_validator_detail"}, 1)
+		}
 	}
 
 	c.m.SetGauge("biya_stake_validators_total", map[string]string{"chain_id": chainID}, float64(total))
 	c.m.SetGauge("biya_stake_validators_bonded", map[string]string{"chain_id": chainID}, float64(bonded))
 	c.m.SetGauge("biya_stake_validators_jailed", map[string]string{"chain_id": chainID}, float64(jailed))
+	for status, n := range byStatus {
+		c.m.SetGauge("biya_stake_validators_by_status", map[string]string{"chain_id": chainID, "status": status}, float64(n))
+	}
+	// 数据质量：拉取到的数量与上游 pagination.total 对账（页数预算不足、分页期间集合变化都会造成差异）
+	if list.Total >= 0 {
+		c.m.SetGauge("biya_stake_validators_upstream_total", map[string]string{"chain_id": chainID}, float64(list.Total))
+		c.m.SetGauge("biya_stake_validators_total_mismatch", map[string]string{"chain_id": chainID}, float64(total-list.Total))
+	}
 	// METRICS.md（聚合）
 	c.m.SetGauge("biya_validators_total", nil, float64(total))
 	// provide.md：biya_validators_active 取 validators 数组长度（你已澄清）
 	c.m.SetGauge("biya_validators_active", nil, float64(total))
 	c.m.SetGauge("biya_validators_jailed", nil, float64(jailed))
 
-	// METRICS.md（单验证人维度）：只对当前返回的 validators 填充；字段不足的先置 0。
-	for _, v := range resp.Validators {
+	// METRICS.md（单验证人维度）：只对当前返回的 validators 填充；rewards 暂无接口对接，先置 0。
+	for _, v := range list.Validators {
 		labels := map[string]string{"address": v.OperatorAddress, "moniker": v.Moniker}
 		if v.Jailed {
 			c.m.SetGauge("biya_validator_status", labels, -1)
@@ -81,38 +205,59 @@ func (c *RealtimeStakeCollector) Run(ctx context.Context) error {
 		} else {
 			c.m.SetGauge("biya_validator_uptime_ratio", labels, 0)
 		}
-		// 其他字段暂无接口对接，先置 0
-		c.m.SetGauge("biya_validator_commission_rate", labels, 0)
-		c.m.SetGauge("biya_validator_stake_byb", labels, 0)
-		c.m.SetGauge("biya_validator_voting_power", labels, 0)
+		// stake 为 tokens 换算后的 BYB；voting power 为占 bonded 总量的百分比，非 bonded 验证人为 0
+		tokens, err := amount.Parse(v.Tokens)
+		if err == nil {
+			c.m.SetGauge("biya_validator_stake_byb", labels, amount.Float64(c.denom.ToDisplay(tokens)))
+		} else {
+			c.m.Unavailable("biya_validator_stake_byb", labels)
+		}
+		share, shareOK := 0.0, false
+		if err == nil {
+			share, shareOK = amount.Ratio(tokens, bondedTokens)
+		}
+		switch {
+		case v.Status != 3:
+			c.m.SetGauge("biya_validator_voting_power", labels, 0)
+		case shareOK:
+			c.m.SetGauge("biya_validator_voting_power", labels, share*100)
+		default:
+			c.m.Unavailable("biya_validator_voting_power", labels)
+		}
+		// commission 只在查询成功过后写入，查询前不写 0 以免误报
+		if d, ok := c.details.get(v.OperatorAddress); ok && d.hasCommission {
+			c.m.SetGauge("biya_validator_commission_rate", labels, d.commission)
+		}
 		c.m.SetGauge("biya_validator_rewards_24h_byb", labels, 0)
-		c.m.SetGauge("biya_validator_last_active_timestamp", labels, 0)
-		c.m.SetGauge("biya_validator_blocks_proposed_total", labels, 0)
-		c.m.SetGauge("biya_validator_blocks_missed_total", labels, 0)
+		// 出块/漏签/最后活跃时间由 ValidatorSigningSubscriber 基于区块签名计算，这里不再写常量
 	}
 	if uptimeN > 0 {
 		c.m.SetGauge("biya_stake_validators_uptime_percentage_avg", map[string]string{"chain_id": chainID}, uptimeSum/float64(uptimeN))
 	}
-
-	// 获取质押统计信息
-	c.readStatistics(ctx)
-
-	// 获取惩罚事件
-	c.readSlashingEvents(ctx)
-
-	// 获取治理统计信息
-	c.readGovernanceStatistics(ctx)
-
 	return nil
 }
 
+// validatorStatusName 把 Cosmos staking status（1 unbonded, 2 unbonding, 3 bonded）转换为 status label。
+func validatorStatusName(status int) string {
+	switch status {
+	case 1:
+		return "unbonded"
+	case 2:
+		return "unbonding"
+	case 3:
+		return "bonded"
+	default:
+		return "unspecified"
+	}
+}
+
 func (c *RealtimeStakeCollector) readStatistics(ctx context.Context) {
-	raw, err := c.api.GetStatistics(ctx)
+	raw, err := c.api.GetStatistics(circuit.WithSource(ctx, "stake_statistics"))
 	if err != nil {
 		c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "stake_statistics"}, 0)
-		c.m.SetGauge("biya_staked_total_byb", nil, 0)
-		c.m.SetGauge("biya_rewards_24h_total_byb", nil, 0)
-		c.m.SetGauge("biya_apr_annual", nil, 0)
+		c.m.Unavailable("biya_staked_total_byb", nil)
+		c.m.Unavailable("biya_rewards_24h_total_byb", nil)
+		c.m.Unavailable("biya_apr_annual", nil)
 		return
 	}
 	c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "stake_statistics"}, 1)
@@ -136,21 +281,21 @@ func (c *RealtimeStakeCollector) readStatistics(ctx context.Context) {
 		return
 	}
 
-	// 总质押量 (BYB)
-	if v, ok := toFloat64(resp.TotalStakedBYB); ok {
+	// 总质押量 (BYB)：*Byb 字段已是展示单位，其余字段为基础单位，按 denom 换算
+	if v, ok := c.byb(resp.TotalStakedBYB, false); ok {
 		c.m.SetGauge("biya_staked_total_byb", nil, v)
-	} else if v, ok := toFloat64(resp.TotalStaked); ok {
+	} else if v, ok := c.byb(resp.TotalStaked, true); ok {
 		c.m.SetGauge("biya_staked_total_byb", nil, v)
-	} else if v, ok := toFloat64(resp.StakedTotal); ok {
+	} else if v, ok := c.byb(resp.StakedTotal, true); ok {
 		c.m.SetGauge("biya_staked_total_byb", nil, v)
 	}
 
 	// 24h总奖励 (BYB)
-	if v, ok := toFloat64(resp.Rewards24HBYB); ok {
+	if v, ok := c.byb(resp.Rewards24HBYB, false); ok {
 		c.m.SetGauge("biya_rewards_24h_total_byb", nil, v)
-	} else if v, ok := toFloat64(resp.Rewards24HTotal); ok {
+	} else if v, ok := c.byb(resp.Rewards24HTotal, true); ok {
 		c.m.SetGauge("biya_rewards_24h_total_byb", nil, v)
-	} else if v, ok := toFloat64(resp.Rewards24H); ok {
+	} else if v, ok := c.byb(resp.Rewards24H, true); ok {
 		c.m.SetGauge("biya_rewards_24h_total_byb", nil, v)
 	}
 
@@ -163,7 +308,10 @@ func (c *RealtimeStakeCollector) readStatistics(ctx context.Context) {
 		c.m.SetGauge("biya_apr_annual", nil, v)
 	}
 
-	// 质押比例
+	// 质押比例（配置了 LCD 时以链上 supply 口径为准）
+	if c.stakedRatioFromLCD {
+		return
+	}
 	if v, ok := toFloat64(resp.StakingRatio); ok {
 		c.m.SetGauge("biya_staked_ratio", nil, v)
 	} else if v, ok := toFloat64(resp.StakedRatio); ok {
@@ -172,75 +320,47 @@ func (c *RealtimeStakeCollector) readStatistics(ctx context.Context) {
 }
 
 func (c *RealtimeStakeCollector) readSlashingEvents(ctx context.Context) {
-	// 获取过去24小时的惩罚事件
-	endTime := time.Now().UTC()
-	startTime := endTime.Add(-24 * time.Hour)
-	p := stake.NestedPagination{Page: 1, PageSize: 100}
-
-	raw, err := c.api.GetSlashingEvents(ctx, startTime.Format(time.RFC3339), endTime.Format(time.RFC3339), p)
+	// 从高水位增量拉取（见 SlashingIngester），每个事件只计入一次
+	err := c.slashing.Ingest(ctx, c.m, c.slashingOpts, func(ctx context.Context, start, end time.Time, page, pageSize int) ([]json.RawMessage, bool, error) {
+		p := stake.NestedPagination{Page: page, PageSize: pageSize}
+		raw, err := c.api.GetSlashingEvents(circuit.WithSource(ctx, "stake_slashing_events"), start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339), p)
+		if err != nil {
+			return nil, false, err
+		}
+		var resp struct {
+			Events     []json.RawMessage `json:"events"`
+			Data       []json.RawMessage `json:"data"`
+			Pagination struct {
+				HasNext *bool `json:"hasNext"`
+			} `json:"pagination"`
+		}
+		if err := jsonUnmarshal(raw, &resp); err != nil {
+			return nil, false, fmt.Errorf("parse slashing events page %d: %w", page, err)
+		}
+		events := resp.Events
+		if len(events) == 0 {
+			events = resp.Data
+		}
+		hasNext := len(events) >= pageSize
+		if resp.Pagination.HasNext != nil {
+			hasNext = *resp.Pagination.HasNext
+		}
+		return events, hasNext, nil
+	})
 	if err != nil {
 		c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "stake_slashing_events"}, 0)
-		c.m.SetGauge("biya_slashing_events_24h", nil, 0)
+		warnSourceErr(c.log, "stake slashing events failed", err, "collector", "realtime_stake", "method", "readSlashingEvents")
 		return
 	}
 	c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "stake_slashing_events"}, 1)
-
-	// 尝试解析事件列表
-	var resp struct {
-		Events []struct {
-			Type string `json:"type"`
-		} `json:"events"`
-		Data []struct {
-			Type string `json:"type"`
-		} `json:"data"`
-		Count int `json:"count"`
-		Total int `json:"total"`
-	}
-	if err := jsonUnmarshal(raw, &resp); err != nil {
-		c.log.Warn("stake slashing events parse failed", "collector", "realtime_stake", "method", "readSlashingEvents", "err", err)
-		return
-	}
-
-	// 统计24小时内的惩罚事件数量
-	var events []struct {
-		Type string `json:"type"`
-	}
-	if len(resp.Events) > 0 {
-		events = resp.Events
-	} else if len(resp.Data) > 0 {
-		events = resp.Data
-	}
-
-	count24H := 0
-	typeCount := make(map[string]int)
-	for _, event := range events {
-		count24H++
-		if event.Type != "" {
-			typeCount[event.Type]++
-		}
-	}
-
-	// 如果响应中有 count 或 total 字段，优先使用
-	if resp.Count > 0 {
-		count24H = resp.Count
-	} else if resp.Total > 0 {
-		count24H = resp.Total
-	}
-
-	c.m.SetGauge("biya_slashing_events_24h", nil, float64(count24H))
-
-	// 按类型统计总惩罚事件（使用 SetGauge，因为当前 registry 的 counter 通过 SetGauge 写入）
-	for eventType, count := range typeCount {
-		c.m.SetGauge("biya_slashing_events_total", map[string]string{"type": eventType}, float64(count))
-	}
 }
 
 func (c *RealtimeStakeCollector) readGovernanceStatistics(ctx context.Context) {
-	raw, err := c.api.GetGovernanceStatistics(ctx)
+	raw, err := c.api.GetGovernanceStatistics(circuit.WithSource(ctx, "stake_governance_statistics"))
 	if err != nil {
 		c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "stake_governance_statistics"}, 0)
-		c.m.SetGauge("biya_voting_power_total", nil, 0)
-		c.m.SetGauge("biya_participation_rate_avg", nil, 0)
+		c.m.Unavailable("biya_voting_power_total", nil)
+		c.m.Unavailable("biya_participation_rate_avg", nil)
 		return
 	}
 	c.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "stake_governance_statistics"}, 1)
@@ -277,3 +397,15 @@ func (c *RealtimeStakeCollector) readGovernanceStatistics(ctx context.Context) {
 		c.m.SetGauge("biya_participation_rate_avg", nil, v)
 	}
 }
+
+// byb 把 stake API 返回的金额转为 BYB；fromBase 为 true 表示 v 为基础单位，需要按 denom 换算。
+func (c *RealtimeStakeCollector) byb(v any, fromBase bool) (float64, bool) {
+	r, err := amount.ParseAny(v)
+	if err != nil {
+		return 0, false
+	}
+	if fromBase {
+		r = c.denom.ToDisplay(r)
+	}
+	return amount.Float64(r), true
+}
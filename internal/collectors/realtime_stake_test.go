@@ -3,14 +3,17 @@ package collectors
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/stake"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/amount"
 	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
 )
 
@@ -76,3 +79,241 @@ func TestRealtimeStakeCollector_ProvideMDValidatorsActiveLen(t *testing.T) {
 }
 
 
+
+func TestRealtimeStakeCollector_StatisticsFallbackPolicy(t *testing.T) {
+	t.Parallel()
+
+	var fail atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"code":    0,
+			"message": "success",
+			"data":    map[string]any{"totalStakedByb": 1000, "rewards24hByb": 5, "aprAnnual": 12.5},
+		})
+	}))
+	defer srv.Close()
+
+	_, m := metrics.New("biya", "dev", "none")
+	// 默认 keep_last；rewards 按旧行为置 0，apr 删除
+	m.SetFallbackPolicy(metrics.FallbackKeepLast, map[string]metrics.FallbackPolicy{
+		"biya_rewards_24h_total_byb": metrics.FallbackZero,
+		"biya_apr_annual":            metrics.FallbackDrop,
+	})
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+	c := NewRealtimeStakeCollector(logger, m, stake.NewClient(srv.URL+"/stake", "k", 2*time.Second))
+
+	ctx := context.Background()
+	c.readStatistics(ctx)
+	out := m.RenderText()
+	assertContains(t, out, "\nbiya_staked_total_byb 1000\n")
+	if strings.Contains(out, "\nbiya_exporter_metric_age_seconds{") {
+		t.Fatalf("fresh values should not report an age:\n%s", out)
+	}
+
+	fail.Store(true)
+	c.readStatistics(ctx)
+	out = m.RenderText()
+	assertContains(t, out, "\nbiya_exporter_source_up{source=\"stake_statistics\"} 0\n")
+	assertContains(t, out, "\nbiya_staked_total_byb 1000\n")
+	assertContains(t, out, "\nbiya_exporter_metric_age_seconds{metric=\"biya_staked_total_byb\"} ")
+	assertContains(t, out, "\nbiya_rewards_24h_total_byb 0\n")
+	if strings.Contains(out, "\nbiya_apr_annual ") || strings.Contains(out, `metric="biya_apr_annual"`) || strings.Contains(out, `metric="biya_rewards_24h_total_byb"`) {
+		t.Fatalf("dropped/zeroed metrics should not be held:\n%s", out)
+	}
+
+	// 恢复后不再标注年龄
+	fail.Store(false)
+	c.readStatistics(ctx)
+	out = m.RenderText()
+	assertContains(t, out, "\nbiya_apr_annual 12.5\n")
+	if strings.Contains(out, "\nbiya_exporter_metric_age_seconds{") {
+		t.Fatalf("age should disappear after recovery:\n%s", out)
+	}
+}
+
+func TestRealtimeStakeCollector_PagesValidatorsWithBudget(t *testing.T) {
+	t.Parallel()
+
+	// 5 个验证人，每页 2 条；页数预算为 2 时只能取到 4 个
+	all := []map[string]any{
+		{"moniker": "v1", "operatorAddress": "op1", "status": 3},
+		{"moniker": "v2", "operatorAddress": "op2", "status": 3, "jailed": true},
+		{"moniker": "v3", "operatorAddress": "op3", "status": 2},
+		{"moniker": "v4", "operatorAddress": "op4", "status": 1},
+		{"moniker": "v5", "operatorAddress": "op5", "status": 1},
+	}
+	var pages atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stake/validators" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		pages.Add(1)
+		page, size := 1, 2
+		fmt.Sscan(r.URL.Query().Get("page"), &page)
+		fmt.Sscan(r.URL.Query().Get("pageSize"), &size)
+		lo, hi := min((page-1)*size, len(all)), min(page*size, len(all))
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"code": 0,
+			"data": map[string]any{
+				"validators": all[lo:hi],
+				"pagination": map[string]any{"page": page, "pageSize": size, "total": "5", "hasNext": hi < len(all)},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	_, m := metrics.New("biya", "dev", "none")
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+	c := NewRealtimeStakeCollector(logger, m, stake.NewClient(srv.URL+"/stake", "k", 2*time.Second)).WithValidatorPaging(2, 2)
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("collector run err: %v", err)
+	}
+	if n := pages.Load(); n != 2 {
+		t.Fatalf("validator pages requested = %d, want 2", n)
+	}
+
+	out := m.RenderText()
+	assertContains(t, out, "\nbiya_validators_total 4\n")
+	assertContains(t, out, "\nbiya_stake_validators_upstream_total{chain_id=\"biya\"} 5\n")
+	assertContains(t, out, "\nbiya_stake_validators_total_mismatch{chain_id=\"biya\"} -1\n")
+	assertContains(t, out, "\nbiya_stake_validators_by_status{chain_id=\"biya\",status=\"bonded\"} 2\n")
+	assertContains(t, out, "\nbiya_stake_validators_by_status{chain_id=\"biya\",status=\"unbonding\"} 1\n")
+	assertContains(t, out, "\nbiya_stake_validators_by_status{chain_id=\"biya\",status=\"unbonded\"} 1\n")
+	assertContains(t, out, "\nbiya_validator_status{address=\"op4\",moniker=\"v4\"} 0\n")
+
+	// 预算足够时取全并对齐
+	pages.Store(0)
+	c.WithValidatorPaging(2, 5)
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("collector run err: %v", err)
+	}
+	out = m.RenderText()
+	assertContains(t, out, "\nbiya_validators_total 5\n")
+	assertContains(t, out, "\nbiya_stake_validators_total_mismatch{chain_id=\"biya\"} 0\n")
+	assertContains(t, out, "\nbiya_stake_validators_by_status{chain_id=\"biya\",status=\"unbonded\"} 2\n")
+}
+
+func TestRealtimeStakeCollector_ValidatorStakeVotingPowerAndCommission(t *testing.T) {
+	t.Parallel()
+
+	details := map[string]string{
+		"op1": `{"commission":{"commissionRates":{"rate":"0.05"}}}`,
+		"op2": `{"commissionRate":"0.1"}`,
+		"op3": `{"validator":{"commission":{"commission_rates":{"rate":"0.2"}}}}`,
+	}
+	var detailCalls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/stake/validators":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"code": 0,
+				"data": map[string]any{
+					"validators": []map[string]any{
+						{"moniker": "v1", "operatorAddress": "op1", "status": 3, "tokens": "3000000000000000000"},
+						{"moniker": "v2", "operatorAddress": "op2", "status": 3, "tokens": "1000000000000000000"},
+						{"moniker": "v3", "operatorAddress": "op3", "status": 1, "tokens": "500000000000000000"},
+					},
+					"pagination": map[string]any{"page": 1, "pageSize": 100, "total": "3", "hasNext": false},
+				},
+			})
+		case "/stake/validator":
+			detailCalls.Add(1)
+			_, _ = fmt.Fprintf(w, `{"code":0,"data":%s}`, details[r.URL.Query().Get("operatorAddress")])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	_, m := metrics.New("biya", "dev", "none")
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+	c := NewRealtimeStakeCollector(logger, m, stake.NewClient(srv.URL+"/stake", "k", 2*time.Second)).
+		WithDenom(amount.Denom{Display: "BYB", Exponent: 18}).
+		WithValidatorDetails(NewValidatorDetailCache(time.Hour, 2, 2))
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("collector run err: %v", err)
+	}
+
+	out := m.RenderText()
+	assertContains(t, out, "\nbiya_validator_stake_byb{address=\"op1\",moniker=\"v1\"} 3\n")
+	assertContains(t, out, "\nbiya_validator_stake_byb{address=\"op3\",moniker=\"v3\"} 0.5\n")
+	assertContains(t, out, "\nbiya_validator_voting_power{address=\"op1\",moniker=\"v1\"} 75\n")
+	assertContains(t, out, "\nbiya_validator_voting_power{address=\"op2\",moniker=\"v2\"} 25\n")
+	assertContains(t, out, "\nbiya_validator_voting_power{address=\"op3\",moniker=\"v3\"} 0\n")
+	assertContains(t, out, "\nbiya_validator_commission_rate{address=\"op1\",moniker=\"v1\"} 0.05\n")
+	assertContains(t, out, "\nbiya_validator_commission_rate{address=\"op2\",moniker=\"v2\"} 0.1\n")
+	assertContains(t, out, "\nbiya_exporter_source_up{source=\"stake_validator_detail\"} 1\n")
+	// 每次最多查 2 个：op3 的详情留到下一个周期
+	if strings.Contains(out, "biya_validator_commission_rate{address=\"op3\"") {
+		t.Fatalf("op3 commission should not be reported before its detail is fetched:\n%s", out)
+	}
+
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("collector run err: %v", err)
+	}
+	assertContains(t, m.RenderText(), "\nbiya_validator_commission_rate{address=\"op3\",moniker=\"v3\"} 0.2\n")
+
+	// 详情都在 ttl 内，不再查询
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatalf("collector run err: %v", err)
+	}
+	if n := detailCalls.Load(); n != 3 {
+		t.Fatalf("detail calls = %d, want 3", n)
+	}
+}
+
+func TestRealtimeStakeCollector_ValidatorOutageKeepsPerValidatorSeries(t *testing.T) {
+	t.Parallel()
+
+	var fail atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stake/validators" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if fail.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"code": 0,
+			"data": map[string]any{
+				"validators": []map[string]any{{"moniker": "v1", "operatorAddress": "op1", "status": 3, "tokens": "2000000000000000000"}},
+				"pagination": map[string]any{"page": 1, "pageSize": 100, "total": "1", "hasNext": false},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	_, root := metrics.New("biya", "dev", "none")
+	m := root.Owned("realtime_stake")
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+	c := NewRealtimeStakeCollector(logger, m, stake.NewClient(srv.URL+"/stake", "k", 2*time.Second))
+	// 与 scheduler 一致：run 成功时清理本次未刷新的 run-scoped series
+	run := func() error {
+		start := time.Now()
+		err := c.Run(context.Background())
+		root.ExpireOwned("realtime_stake", err == nil, start, time.Minute)
+		return err
+	}
+
+	if err := run(); err != nil {
+		t.Fatalf("collector run err: %v", err)
+	}
+	assertContains(t, root.RenderText(), "\nbiya_validator_stake_byb{address=\"op1\",moniker=\"v1\"} 2\n")
+
+	fail.Store(true)
+	if err := run(); err == nil {
+		t.Fatalf("expected run to fail when the validator list is unavailable")
+	}
+	out := root.RenderText()
+	assertContains(t, out, "\nbiya_exporter_source_up{source=\"stake_validators\"} 0\n")
+	assertContains(t, out, "\nbiya_validator_stake_byb{address=\"op1\",moniker=\"v1\"} 2\n")
+	assertContains(t, out, "\nbiya_validator_status{address=\"op1\",moniker=\"v1\"} 1\n")
+}
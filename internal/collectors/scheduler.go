@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"math/rand/v2"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/circuit"
 	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
 )
 
@@ -18,6 +20,17 @@ type Collector interface {
 type Job struct {
 	Name     string
 	Interval time.Duration
+	// Timeout 为单次 run 的超时；<=0 时使用 Interval，避免慢上游把一次 run 拖过多个周期。
+	Timeout time.Duration
+	// Jitter 为首次运行前的随机延迟上限，错开各 job 的启动时刻（之后的周期相位随之错开）。
+	Jitter time.Duration
+	// MaxConcurrentRuns 为同一 job 允许同时进行的 run 数；<=0 视为 1。
+	// 到点时已达上限则跳过本次 run（计入 biya_exporter_scrape_skipped_total）。
+	// 大于 1 只适用于 Run 可并发调用的 collector。
+	MaxConcurrentRuns int
+	// Fingerprint 为该 job 所依赖配置的摘要；热加载时只有 Fingerprint 变化的 job 才会被重建，
+	// 未变化的 job 继续运行原 collector（保留其内部状态，例如出块时间 EMA）。
+	Fingerprint string
 	Collector
 }
 
@@ -28,18 +41,33 @@ func NewJob(name string, interval time.Duration, c Collector) Job {
 type Scheduler struct {
 	log   *slog.Logger
 	m     *metrics.Metrics
-	jobs  []Job
 	ready atomic.Bool
 
-	mu           sync.Mutex
+	// applyMu 串行化 Run/Apply 对运行中 job 集合的变更
+	applyMu sync.Mutex
+
+	mu      sync.Mutex
+	ctx     context.Context
+	jobs    []Job
+	running map[string]*jobRunner
+	wg      sync.WaitGroup
+
+	readyMu      sync.Mutex
 	jobReadyOnce map[string]bool
 }
 
+type jobRunner struct {
+	job    Job
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
 func NewScheduler(log *slog.Logger, m *metrics.Metrics, jobs []Job) *Scheduler {
 	s := &Scheduler{
 		log:          log,
 		m:            m,
 		jobs:         jobs,
+		running:      make(map[string]*jobRunner, len(jobs)),
 		jobReadyOnce: make(map[string]bool, len(jobs)),
 	}
 	return s
@@ -50,32 +78,151 @@ func (s *Scheduler) Ready() bool {
 }
 
 func (s *Scheduler) Run(ctx context.Context) error {
+	s.applyMu.Lock()
+	s.mu.Lock()
 	if len(s.jobs) == 0 {
+		s.mu.Unlock()
+		s.applyMu.Unlock()
 		return errors.New("no jobs configured")
 	}
+	s.ctx = ctx
+	for _, j := range s.jobs {
+		s.startLocked(j)
+	}
+	s.mu.Unlock()
+	s.applyMu.Unlock()
+
+	<-ctx.Done()
+	s.wg.Wait()
+	return nil
+}
+
+// Apply 用新的 job 列表替换当前运行的 job（用于配置热加载）：
+// - 新增的 job 启动（首次立即运行一次）
+// - 删除的 job 停止
+// - Name 相同但 Fingerprint 变化的 job 先停旧的再启动新的
+// - 未变化的 job 不受影响
+//
+// Scheduler 尚未 Run 时只替换待启动的 job 列表。
+func (s *Scheduler) Apply(jobs []Job) {
+	s.applyMu.Lock()
+	defer s.applyMu.Unlock()
+
+	next := make(map[string]Job, len(jobs))
+	for _, j := range jobs {
+		next[j.Name] = j
+	}
 
-	var wg sync.WaitGroup
-	for _, job := range s.jobs {
-		j := job
-		if j.Interval <= 0 {
-			s.log.Warn("skip job with non-positive interval", "job", j.Name, "interval", j.Interval)
+	s.mu.Lock()
+	s.jobs = jobs
+	if s.ctx == nil {
+		s.mu.Unlock()
+		return
+	}
+	var stopping, removed []*jobRunner
+	for name, r := range s.running {
+		if j, ok := next[name]; ok && j.Fingerprint == r.job.Fingerprint && sameSchedule(j, r.job) {
 			continue
 		}
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			s.runJobLoop(ctx, j)
-		}()
+		delete(s.running, name)
+		stopping = append(stopping, r)
+		if _, ok := next[name]; !ok {
+			removed = append(removed, r)
+		}
 	}
+	s.mu.Unlock()
 
-	<-ctx.Done()
-	wg.Wait()
-	return nil
+	// 在锁外等待旧 job 退出：runOnce 内部会获取 readyMu，这里持锁等待会死锁。
+	for _, r := range stopping {
+		r.cancel()
+		<-r.done
+		s.log.Info("job stopped for reload", "job", r.job.Name)
+	}
+	// 被移除的 job 不会再刷新它的 series，直接清理；重建的 job 保留，由新 collector 继续刷新
+	for _, r := range removed {
+		s.m.DropOwned(r.job.Name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx.Err() != nil {
+		return
+	}
+	for _, j := range jobs {
+		if _, ok := s.running[j.Name]; ok {
+			continue
+		}
+		s.startLocked(j)
+	}
+}
+
+func (s *Scheduler) startLocked(j Job) {
+	if j.Interval <= 0 {
+		s.log.Warn("skip job with non-positive interval", "job", j.Name, "interval", j.Interval)
+		return
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	r := &jobRunner{job: j, cancel: cancel, done: make(chan struct{})}
+	s.running[j.Name] = r
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(r.done)
+		s.runJobLoop(ctx, j)
+	}()
+}
+
+// sameSchedule 判断两个 job 的调度参数是否一致（不一致时热加载需要重启该 job）。
+func sameSchedule(a, b Job) bool {
+	return a.Interval == b.Interval && a.Timeout == b.Timeout && a.Jitter == b.Jitter && a.MaxConcurrentRuns == b.MaxConcurrentRuns
+}
+
+type jobLifetimeKey struct{}
+
+// jobLifetime 返回 job 生命周期的 ctx（job 停止或被热加载替换时取消）。
+// 单次 run 的 ctx 带有超时，collector 启动跨 run 的后台 goroutine（流式订阅等）时必须改用它；
+// 不经 scheduler 直接调用 Run（例如测试）时返回 ctx 本身。
+func jobLifetime(ctx context.Context) context.Context {
+	if lc, ok := ctx.Value(jobLifetimeKey{}).(context.Context); ok {
+		return lc
+	}
+	return ctx
 }
 
 func (s *Scheduler) runJobLoop(ctx context.Context, job Job) {
-	// 首次立即跑一次，避免 exporter 启动后长时间无数据
-	s.runOnce(ctx, job)
+	ctx = context.WithValue(ctx, jobLifetimeKey{}, ctx)
+
+	if job.Jitter > 0 {
+		delay := time.NewTimer(time.Duration(rand.Int64N(int64(job.Jitter))))
+		select {
+		case <-ctx.Done():
+			delay.Stop()
+			return
+		case <-delay.C:
+		}
+	}
+
+	slots := make(chan struct{}, max(job.MaxConcurrentRuns, 1))
+	var runs sync.WaitGroup
+	defer runs.Wait()
+	trigger := func() {
+		select {
+		case slots <- struct{}{}:
+		default:
+			s.m.IncCounter("biya_exporter_scrape_skipped_total", map[string]string{"source": job.Name})
+			s.log.Warn("collector run skipped, previous run still in progress", "collector", job.Name)
+			return
+		}
+		runs.Add(1)
+		go func() {
+			defer runs.Done()
+			defer func() { <-slots }()
+			s.runOnce(ctx, job)
+		}()
+	}
+
+	// 首次立即跑一次（jitter 之后），避免 exporter 启动后长时间无数据
+	trigger()
 
 	t := time.NewTicker(job.Interval)
 	defer t.Stop()
@@ -85,20 +232,46 @@ func (s *Scheduler) runJobLoop(ctx context.Context, job Job) {
 		case <-ctx.Done():
 			return
 		case <-t.C:
-			s.runOnce(ctx, job)
+			trigger()
 		}
 	}
 }
 
 func (s *Scheduler) runOnce(ctx context.Context, job Job) {
+	timeout := job.Timeout
+	if timeout <= 0 {
+		timeout = job.Interval
+	}
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
 	start := time.Now()
-	err := job.Collector.Run(ctx)
+	err := job.Collector.Run(runCtx)
 	dur := time.Since(start).Seconds()
 
+	if ctx.Err() != nil {
+		// job 被停止（退出或热加载替换），本次结果不计入指标
+		return
+	}
+	if err == nil && errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		// collector 吞掉了 ctx 错误（例如只上报 source_up），超时仍要算作失败
+		err = runCtx.Err()
+	}
+
 	s.m.ObserveDuration(job.Name, dur)
+	// 至少保留两个周期，避免低频 job 的 series 在两次 run 之间被 TTL 误删
+	s.m.ExpireOwned(job.Name, err == nil, start, 2*job.Interval)
 	if err != nil {
+		if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			s.m.IncCounter("biya_exporter_scrape_timeout_total", map[string]string{"source": job.Name})
+			s.log.Error("collector run timed out", "collector", job.Name, "timeout", timeout, "err", err)
+		} else if circuit.IsOpen(err) {
+			// 数据源熔断中：本次 run 没有真正请求上游，打开/恢复已由 circuit 记录
+			s.log.Debug("collector run skipped sources with open circuit", "collector", job.Name, "err", err)
+		} else {
+			s.log.Error("collector run failed", "collector", job.Name, "duration_s", dur, "err", err)
+		}
 		s.m.SetGauge("biya_exporter_scrape_success", map[string]string{"source": job.Name}, 0)
-		s.log.Error("collector run failed", "collector", job.Name, "duration_s", dur, "err", err)
 		return
 	}
 
@@ -109,16 +282,23 @@ func (s *Scheduler) runOnce(ctx context.Context, job Job) {
 }
 
 func (s *Scheduler) markJobReady(jobName string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.readyMu.Lock()
+	defer s.readyMu.Unlock()
 	if s.jobReadyOnce[jobName] {
 		return
 	}
 	s.jobReadyOnce[jobName] = true
+	if s.ready.Load() {
+		// 已 ready 后不再回退：热加载新增 job 不应让 /readyz 抖动
+		return
+	}
 
 	// 全部 job 首次成功后置 ready
-	for _, j := range s.jobs {
-		if !s.jobReadyOnce[j.Name] {
+	s.mu.Lock()
+	jobs := s.jobs
+	s.mu.Unlock()
+	for _, j := range jobs {
+		if j.Interval > 0 && !s.jobReadyOnce[j.Name] {
 			return
 		}
 	}
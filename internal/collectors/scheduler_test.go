@@ -0,0 +1,131 @@
+package collectors
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+type countingCollector struct {
+	runs atomic.Int64
+}
+
+func (c *countingCollector) Run(context.Context) error {
+	c.runs.Add(1)
+	return nil
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timeout waiting for %s", what)
+}
+
+func TestScheduler_ApplyRestartsOnlyChangedJobs(t *testing.T) {
+	t.Parallel()
+
+	_, m := metrics.New("biya", "dev", "none")
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+
+	oldA, keepB := &countingCollector{}, &countingCollector{}
+	jobA := NewJob("a", time.Hour, oldA)
+	jobA.Fingerprint = "v1"
+	jobB := NewJob("b", time.Hour, keepB)
+	jobB.Fingerprint = "v1"
+
+	s := NewScheduler(logger, m, []Job{jobA, jobB})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = s.Run(ctx) }()
+
+	waitFor(t, "initial runs", func() bool { return oldA.runs.Load() == 1 && keepB.runs.Load() == 1 })
+	waitFor(t, "ready", s.Ready)
+
+	newA, newC := &countingCollector{}, &countingCollector{}
+	jobA2 := NewJob("a", time.Hour, newA)
+	jobA2.Fingerprint = "v2"
+	// b 使用新的 collector 实例但 fingerprint 不变：应继续运行旧实例
+	jobB2 := NewJob("b", time.Hour, &countingCollector{})
+	jobB2.Fingerprint = "v1"
+	jobC := NewJob("c", time.Hour, newC)
+
+	s.Apply([]Job{jobA2, jobB2, jobC})
+
+	waitFor(t, "replaced and new jobs run", func() bool { return newA.runs.Load() == 1 && newC.runs.Load() == 1 })
+	if got := keepB.runs.Load(); got != 1 {
+		t.Fatalf("unchanged job should not be restarted, runs = %d", got)
+	}
+	if got := oldA.runs.Load(); got != 1 {
+		t.Fatalf("old job should be stopped, runs = %d", got)
+	}
+	if !s.Ready() {
+		t.Fatalf("ready should not regress after reload")
+	}
+}
+
+// slowCollector 阻塞到 ctx 结束，并记录首次 run 拿到的 job 生命周期 ctx。
+type slowCollector struct {
+	runs     atomic.Int64
+	lifetime atomic.Pointer[context.Context]
+}
+
+func (c *slowCollector) Run(ctx context.Context) error {
+	c.runs.Add(1)
+	lc := jobLifetime(ctx)
+	c.lifetime.CompareAndSwap(nil, &lc)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestScheduler_TimeoutSkipAndJobLifetime(t *testing.T) {
+	t.Parallel()
+
+	_, m := metrics.New("biya", "dev", "none")
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+
+	slow := &slowCollector{}
+	job := NewJob("slow", 20*time.Millisecond, slow)
+	// 超时长于周期：run 进行期间到点的 tick 应被跳过
+	job.Timeout = 70 * time.Millisecond
+	job.Jitter = 10 * time.Millisecond
+
+	s := NewScheduler(logger, m, []Job{job})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = s.Run(ctx)
+		close(done)
+	}()
+
+	waitFor(t, "timeout and skip counted", func() bool {
+		out := m.RenderText()
+		return strings.Contains(out, "\nbiya_exporter_scrape_timeout_total{source=\"slow\"} ") &&
+			strings.Contains(out, "\nbiya_exporter_scrape_skipped_total{source=\"slow\"} ")
+	})
+	assertContains(t, m.RenderText(), "\nbiya_exporter_scrape_success{source=\"slow\"} 0\n")
+	if s.Ready() {
+		t.Fatalf("timed out job should not mark scheduler ready")
+	}
+
+	// 单次 run 已超时结束，但 job 仍在运行：生命周期 ctx 不应被取消
+	lc := *slow.lifetime.Load()
+	if lc.Err() != nil {
+		t.Fatalf("job lifetime ctx cancelled with the run: %v", lc.Err())
+	}
+	cancel()
+	<-done
+	if lc.Err() == nil {
+		t.Fatalf("job lifetime ctx should end when the scheduler stops")
+	}
+}
@@ -0,0 +1,317 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+// slashingWindow 为 biya_slashing_events_24h 的统计窗口，也是已入库事件 key 与各验证人最近惩罚时间的最短保留时间。
+const slashingWindow = 24 * time.Hour
+
+// slashingSaveInterval 为只有高水位推进（没有新事件）时写状态文件的最小间隔。
+// 新事件总是立即落盘，因此崩溃时最多从稍早的高水位重拉，已计入的事件仍按 key 去重。
+const slashingSaveInterval = time.Minute
+
+// SlashingOptions 为惩罚事件增量拉取的参数。
+type SlashingOptions struct {
+	// PageSize / MaxPages 为单次拉取的分页大小与页数预算；达到预算时不推进高水位，下个周期重拉（已计入的事件按 key 去重）
+	PageSize int
+	MaxPages int
+	// InitialLookback 为没有高水位（首次启动且无状态文件）时回看的时长，窗口内已有事件作为 counter 的基线
+	InitialLookback time.Duration
+	// Overlap 为每次查询在高水位之前多回看的时长，兜住上游索引延迟导致的迟到事件
+	Overlap time.Duration
+}
+
+// SlashingIngester 按高水位（已完整拉取到的时间点）增量拉取惩罚事件，每个事件（按 ID，缺省时按高度/验证人/类型）只计入一次。
+// 高水位、保留窗口内的事件 key 与各验证人最近一次惩罚时间写入状态文件，重启后从高水位继续，不会重复计数；
+// 最近惩罚时间与事件 key 使用同一保留窗口，早于窗口的条目被清理，避免随验证人更替无限增长。
+// 跨配置重载共享（见 jobDeps），避免重建 collector 后重新计入窗口内的事件。
+type SlashingIngester struct {
+	log       *slog.Logger
+	statePath string
+	now       func() time.Time
+
+	mu    sync.Mutex
+	state slashingState
+	// dirty 表示事件 key / 最近惩罚时间有变化尚未落盘；savedHWM 为状态文件中的高水位
+	dirty    bool
+	savedHWM time.Time
+	// types / validators 为已计入过的 series，每次 run 以 0 增量刷新，避免长时间无新事件时 counter 被 TTL 清理
+	types      map[string]struct{}
+	validators map[[2]string]struct{}
+}
+
+type slashingState struct {
+	HighWaterMark time.Time            `json:"high_water_mark"`
+	Events        []slashingEventRef   `json:"events"`
+	LastSlash     map[string]time.Time `json:"last_slash"`
+}
+
+type slashingEventRef struct {
+	Key  string    `json:"key"`
+	Time time.Time `json:"time"`
+}
+
+// NewSlashingIngester 创建 ingester 并加载 statePath 中的状态；statePath 为空时只在内存中保存。
+// 状态文件不存在或损坏时从 InitialLookback 重新开始（损坏时记录告警）。
+func NewSlashingIngester(log *slog.Logger, statePath string) *SlashingIngester {
+	s := &SlashingIngester{
+		log:        log,
+		statePath:  statePath,
+		now:        time.Now,
+		state:      slashingState{LastSlash: map[string]time.Time{}},
+		types:      map[string]struct{}{},
+		validators: map[[2]string]struct{}{},
+	}
+	if statePath == "" {
+		return s
+	}
+	b, err := os.ReadFile(statePath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Warn("slashing state unreadable; starting from initial lookback", "path", statePath, "err", err)
+		}
+		return s
+	}
+	var st slashingState
+	if err := json.Unmarshal(b, &st); err != nil {
+		log.Warn("slashing state corrupt; starting from initial lookback", "path", statePath, "err", err)
+		return s
+	}
+	if st.LastSlash == nil {
+		st.LastSlash = map[string]time.Time{}
+	}
+	s.state = st
+	s.savedHWM = st.HighWaterMark
+	return s
+}
+
+// slashingPageFetcher 拉取 [start, end] 内第 page 页事件，返回事件与是否还有下一页。
+type slashingPageFetcher func(ctx context.Context, start, end time.Time, page, pageSize int) ([]json.RawMessage, bool, error)
+
+// Ingest 从高水位拉取新事件并更新指标：
+// biya_slashing_events_total{type} / biya_validator_slashing_events_total{address,type} 只累加新事件，
+// biya_slashing_events_24h 为 24h 内入库的事件数，biya_validator_last_slash_timestamp 为各验证人最近一次惩罚时间。
+func (s *SlashingIngester) Ingest(ctx context.Context, m *metrics.Metrics, opts SlashingOptions, fetch slashingPageFetcher) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	start := s.state.HighWaterMark.Add(-opts.Overlap)
+	if s.state.HighWaterMark.IsZero() {
+		start = now.Add(-opts.InitialLookback)
+	}
+	seen := make(map[string]struct{}, len(s.state.Events))
+	for _, e := range s.state.Events {
+		seen[e.Key] = struct{}{}
+	}
+
+	var (
+		newest    time.Time
+		ingested  int
+		truncated = true
+		unparsed  []error
+	)
+	for page := 1; page <= max(opts.MaxPages, 1); page++ {
+		items, hasNext, err := fetch(ctx, start, now, page, opts.PageSize)
+		if err != nil {
+			// 已计入的事件保留（key 已记录），高水位不推进，下个周期从同一位置重拉
+			s.finish(m, now, opts.Overlap)
+			return err
+		}
+		for _, raw := range items {
+			ev, err := parseSlashingEvent(raw)
+			if err != nil {
+				unparsed = append(unparsed, err)
+				continue
+			}
+			if ev.time.IsZero() {
+				ev.time = now
+			}
+			if _, dup := seen[ev.key]; dup {
+				continue
+			}
+			seen[ev.key] = struct{}{}
+			s.state.Events = append(s.state.Events, slashingEventRef{Key: ev.key, Time: ev.time})
+			s.dirty = true
+			s.count(m, ev)
+			ingested++
+			if ev.time.After(newest) {
+				newest = ev.time
+			}
+		}
+		if !hasNext || len(items) == 0 {
+			truncated = false
+			break
+		}
+	}
+	if len(unparsed) > 0 {
+		s.log.Warn("stake slashing events partially unparsable", "collector", "realtime_stake", "err", errors.Join(unparsed...))
+	}
+
+	if truncated {
+		// 不知道上游排序，部分拉取时推进高水位可能跳过未拉到的事件
+		s.log.Warn("stake slashing events truncated by page budget; high water mark not advanced", "collector", "realtime_stake", "max_pages", opts.MaxPages, "ingested", ingested)
+	} else {
+		// 查询窗口已完整拉取：高水位推进到 now-Overlap（更晚的部分留给迟到事件），且不早于最新事件
+		hwm := now.Add(-opts.Overlap)
+		if newest.After(hwm) {
+			hwm = newest
+		}
+		if hwm.After(s.state.HighWaterMark) {
+			s.state.HighWaterMark = hwm
+		}
+	}
+	s.finish(m, now, opts.Overlap)
+	return nil
+}
+
+// count 把一个新事件计入各 counter 并更新该验证人的最近惩罚时间。
+func (s *SlashingIngester) count(m *metrics.Metrics, ev slashingEvent) {
+	_ = m.AddCounter("biya_slashing_events_total", map[string]string{"type": ev.typ}, 1)
+	s.types[ev.typ] = struct{}{}
+	if ev.validator == "" {
+		return
+	}
+	_ = m.AddCounter("biya_validator_slashing_events_total", map[string]string{"address": ev.validator, "type": ev.typ}, 1)
+	s.validators[[2]string{ev.validator, ev.typ}] = struct{}{}
+	if ev.time.After(s.state.LastSlash[ev.validator]) {
+		s.state.LastSlash[ev.validator] = ev.time
+	}
+}
+
+// finish 清理过期的事件 key、刷新窗口类指标并保存状态。
+func (s *SlashingIngester) finish(m *metrics.Metrics, now time.Time, overlap time.Duration) {
+	// 去重需要覆盖下次查询的起点（高水位-Overlap），24h 计数需要覆盖 24h 窗口，取更早者
+	keepFrom := now.Add(-slashingWindow)
+	if hwm := s.state.HighWaterMark; !hwm.IsZero() && hwm.Add(-overlap).Before(keepFrom) {
+		keepFrom = hwm.Add(-overlap)
+	}
+	kept := s.state.Events[:0]
+	window := 0
+	for _, e := range s.state.Events {
+		if e.Time.Before(keepFrom) {
+			s.dirty = true
+			continue
+		}
+		kept = append(kept, e)
+		if !e.Time.Before(now.Add(-slashingWindow)) {
+			window++
+		}
+	}
+	s.state.Events = kept
+	for addr, t := range s.state.LastSlash {
+		if t.Before(keepFrom) {
+			delete(s.state.LastSlash, addr)
+			s.dirty = true
+		}
+	}
+
+	m.SetGauge("biya_slashing_events_24h", nil, float64(window))
+	if !s.state.HighWaterMark.IsZero() {
+		m.SetGauge("biya_slashing_high_water_mark_timestamp", nil, float64(s.state.HighWaterMark.Unix()))
+	}
+	for typ := range s.types {
+		_ = m.AddCounter("biya_slashing_events_total", map[string]string{"type": typ}, 0)
+	}
+	for k := range s.validators {
+		_ = m.AddCounter("biya_validator_slashing_events_total", map[string]string{"address": k[0], "type": k[1]}, 0)
+	}
+	for addr, t := range s.state.LastSlash {
+		m.SetGauge("biya_validator_last_slash_timestamp", map[string]string{"address": addr}, float64(t.Unix()))
+	}
+
+	if !s.dirty && s.state.HighWaterMark.Sub(s.savedHWM) < slashingSaveInterval {
+		return
+	}
+	if err := s.save(); err != nil {
+		s.log.Warn("slashing state save failed", "path", s.statePath, "err", err)
+		return
+	}
+	s.dirty, s.savedHWM = false, s.state.HighWaterMark
+}
+
+// save 原子写入状态文件（先写临时文件再 rename），避免进程中断留下半个文件。
+func (s *SlashingIngester) save() error {
+	if s.statePath == "" {
+		return nil
+	}
+	sort.Slice(s.state.Events, func(i, j int) bool { return s.state.Events[i].Time.Before(s.state.Events[j].Time) })
+	b, err := json.Marshal(s.state)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.statePath), filepath.Base(s.statePath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.statePath)
+}
+
+// slashingEvent 为单个惩罚事件中用到的字段。
+type slashingEvent struct {
+	key       string
+	typ       string
+	validator string
+	time      time.Time
+}
+
+// parseSlashingEvent 解析 stake API 的惩罚事件，兼容 camelCase / snake_case 字段。
+// 去重 key 优先用事件 ID；没有 ID 时用 高度/验证人/类型 组合，三者都缺失时无法去重，视为解析失败。
+func parseSlashingEvent(raw json.RawMessage) (slashingEvent, error) {
+	var obj map[string]any
+	if err := jsonUnmarshal(raw, &obj); err != nil {
+		return slashingEvent{}, err
+	}
+	var ev slashingEvent
+	ev.typ = scalarString(pick(obj, "type", "eventType", "event_type", "reason"))
+	if ev.typ == "" {
+		ev.typ = "unknown"
+	}
+	ev.validator = scalarString(pick(obj, "validatorAddress", "validator_address", "operatorAddress", "operator_address", "validator", "consensusAddress", "consensus_address"))
+	ev.time = parseTimestamp(pick(obj, "timestamp", "time", "blockTime", "block_time", "createdAt", "created_at"))
+
+	if id := scalarString(pick(obj, "id", "eventId", "event_id")); id != "" {
+		ev.key = "id:" + id
+		return ev, nil
+	}
+	height := scalarString(pick(obj, "height", "blockHeight", "block_height"))
+	if height == "" && ev.validator == "" {
+		return slashingEvent{}, fmt.Errorf("slashing event without id, height or validator")
+	}
+	ev.key = strings.Join([]string{"h:" + height, ev.validator, ev.typ}, "/")
+	return ev, nil
+}
+
+// scalarString 把 JSON 字符串/数字转换为字符串；其他类型返回空串。
+func scalarString(v any) string {
+	switch x := v.(type) {
+	case string:
+		return strings.TrimSpace(x)
+	case json.Number:
+		return x.String()
+	default:
+		return ""
+	}
+}
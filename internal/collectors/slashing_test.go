@@ -0,0 +1,211 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/stake"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+func TestRealtimeStakeCollector_SlashingIngestsFromHighWaterMark(t *testing.T) {
+	t.Parallel()
+
+	t0 := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	var (
+		mu     sync.Mutex
+		starts []string
+		events = []map[string]any{
+			{"id": "e0", "type": "downtime", "validatorAddress": "valA", "timestamp": t0.Add(-30 * time.Hour).Format(time.RFC3339)},
+			{"id": "e1", "type": "downtime", "validatorAddress": "valA", "timestamp": t0.Add(-2 * time.Hour).Format(time.RFC3339)},
+			// 没有 ID：按 高度/验证人/类型 去重
+			{"height": 100, "type": "double_sign", "validatorAddress": "valB", "timestamp": t0.Add(-time.Hour).Format(time.RFC3339)},
+		}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stake/slashing/events" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		q := r.URL.Query()
+		start, _ := time.Parse(time.RFC3339, q.Get("startTime"))
+		end, _ := time.Parse(time.RFC3339, q.Get("endTime"))
+		page, size := 1, 100
+		fmt.Sscan(q.Get("pagination.page"), &page)
+		fmt.Sscan(q.Get("pagination.pageSize"), &size)
+		if page == 1 {
+			starts = append(starts, q.Get("startTime"))
+		}
+		var in []map[string]any
+		for _, e := range events {
+			ts, _ := time.Parse(time.RFC3339, e["timestamp"].(string))
+			if !ts.Before(start) && !ts.After(end) {
+				in = append(in, e)
+			}
+		}
+		lo, hi := min((page-1)*size, len(in)), min(page*size, len(in))
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"code": 0,
+			"data": map[string]any{"events": in[lo:hi], "pagination": map[string]any{"hasNext": hi < len(in)}},
+		})
+	}))
+	defer srv.Close()
+
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+	statePath := filepath.Join(t.TempDir(), "slashing.json")
+	opts := SlashingOptions{PageSize: 1, MaxPages: 5, InitialLookback: 24 * time.Hour, Overlap: 10 * time.Minute}
+	newCollector := func(m *metrics.Metrics, now time.Time) *RealtimeStakeCollector {
+		ing := NewSlashingIngester(logger, statePath)
+		ing.now = func() time.Time { return now }
+		return NewRealtimeStakeCollector(logger, m, stake.NewClient(srv.URL+"/stake", "k", 2*time.Second)).WithSlashing(ing, opts)
+	}
+
+	// 首次运行：回看 24h（e0 在窗口外），分页拉完
+	_, m := metrics.New("biya", "dev", "none")
+	c := newCollector(m, t0)
+	c.readSlashingEvents(context.Background())
+	out := m.RenderText()
+	assertContains(t, out, "\nbiya_slashing_events_total{type=\"downtime\"} 1\n")
+	assertContains(t, out, "\nbiya_slashing_events_total{type=\"double_sign\"} 1\n")
+	assertContains(t, out, "\nbiya_validator_slashing_events_total{address=\"valB\",type=\"double_sign\"} 1\n")
+	assertContains(t, out, "\nbiya_slashing_events_24h 2\n")
+	assertContains(t, out, fmt.Sprintf("\nbiya_validator_last_slash_timestamp{address=\"valA\"} %d\n", t0.Add(-2*time.Hour).Unix()))
+	assertContains(t, out, fmt.Sprintf("\nbiya_slashing_high_water_mark_timestamp %d\n", t0.Add(-10*time.Minute).Unix()))
+
+	// 第二次运行：从 高水位-overlap 开始，迟到的 e3 只计入一次
+	mu.Lock()
+	events = append(events, map[string]any{"id": "e3", "type": "downtime", "validatorAddress": "valA", "timestamp": t0.Add(-5 * time.Minute).Format(time.RFC3339)})
+	mu.Unlock()
+	c.slashing.now = func() time.Time { return t0.Add(time.Minute) }
+	c.readSlashingEvents(context.Background())
+	c.readSlashingEvents(context.Background())
+	out = m.RenderText()
+	assertContains(t, out, "\nbiya_slashing_events_total{type=\"downtime\"} 2\n")
+	assertContains(t, out, "\nbiya_validator_slashing_events_total{address=\"valA\",type=\"downtime\"} 2\n")
+	assertContains(t, out, fmt.Sprintf("\nbiya_validator_last_slash_timestamp{address=\"valA\"} %d\n", t0.Add(-5*time.Minute).Unix()))
+
+	// 重启：从状态文件恢复高水位与已计入的事件，窗口内的事件不会重复计数
+	_, m2 := metrics.New("biya", "dev", "none")
+	c2 := newCollector(m2, t0.Add(2*time.Minute))
+	c2.readSlashingEvents(context.Background())
+	out = m2.RenderText()
+	if strings.Contains(out, "\nbiya_slashing_events_total{") {
+		t.Fatalf("events already ingested before restart were counted again:\n%s", out)
+	}
+	assertContains(t, out, "\nbiya_slashing_events_24h 3\n")
+	assertContains(t, out, fmt.Sprintf("\nbiya_validator_last_slash_timestamp{address=\"valA\"} %d\n", t0.Add(-5*time.Minute).Unix()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"2026-10-16T12:00:00Z", "2026-10-17T11:40:00Z", "2026-10-17T11:45:00Z", "2026-10-17T11:45:00Z"}
+	if strings.Join(starts, ",") != strings.Join(want, ",") {
+		t.Fatalf("query start times = %v, want %v", starts, want)
+	}
+}
+
+func TestSlashingIngester_TruncatedPagesKeepHighWaterMark(t *testing.T) {
+	t.Parallel()
+
+	t0 := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+	ing := NewSlashingIngester(logger, "")
+	ing.now = func() time.Time { return t0 }
+	opts := SlashingOptions{PageSize: 1, MaxPages: 1, InitialLookback: time.Hour, Overlap: time.Minute}
+	var starts []time.Time
+	fetch := func(_ context.Context, start, _ time.Time, page, _ int) ([]json.RawMessage, bool, error) {
+		starts = append(starts, start)
+		// 上游始终还有下一页：页数预算用尽
+		return []json.RawMessage{json.RawMessage(fmt.Sprintf(`{"id":"p%d","type":"downtime"}`, page))}, true, nil
+	}
+
+	_, m := metrics.New("biya", "dev", "none")
+	for i := 0; i < 2; i++ {
+		if err := ing.Ingest(context.Background(), m, opts, fetch); err != nil {
+			t.Fatalf("ingest: %v", err)
+		}
+	}
+	out := m.RenderText()
+	// 高水位未推进：第二次仍从初始回看位置拉取，已计入的事件不重复计数
+	if !starts[1].Equal(t0.Add(-time.Hour)) {
+		t.Fatalf("second start = %v, want %v", starts[1], t0.Add(-time.Hour))
+	}
+	if strings.Contains(out, "\nbiya_slashing_high_water_mark_timestamp ") {
+		t.Fatalf("high water mark should not be set after truncated ingest:\n%s", out)
+	}
+	assertContains(t, out, "\nbiya_slashing_events_total{type=\"downtime\"} 1\n")
+}
+
+func TestSlashingIngester_PrunesLastSlashAndSavesOnlyOnChange(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+	statePath := filepath.Join(t.TempDir(), "slashing.json")
+	ing := NewSlashingIngester(logger, statePath)
+	ing.now = func() time.Time { return now }
+	opts := SlashingOptions{PageSize: 10, MaxPages: 1, InitialLookback: time.Hour, Overlap: 10 * time.Minute}
+	var events []json.RawMessage
+	fetch := func(context.Context, time.Time, time.Time, int, int) ([]json.RawMessage, bool, error) {
+		return events, false, nil
+	}
+	_, root := metrics.New("biya", "dev", "none")
+	m := root.Owned("realtime_stake")
+	ingest := func() {
+		t.Helper()
+		start := time.Now()
+		if err := ing.Ingest(context.Background(), m, opts, fetch); err != nil {
+			t.Fatalf("ingest: %v", err)
+		}
+		root.ExpireOwned("realtime_stake", true, start, time.Minute)
+	}
+	stateExists := func() bool {
+		_, err := os.Stat(statePath)
+		return err == nil
+	}
+
+	events = []json.RawMessage{json.RawMessage(fmt.Sprintf(`{"id":"e1","type":"downtime","validatorAddress":"valA","timestamp":%q}`, now.Add(-time.Minute).Format(time.RFC3339)))}
+	ingest()
+	if !stateExists() {
+		t.Fatalf("state with a new event should be saved")
+	}
+
+	// 没有新事件、高水位推进不足 slashingSaveInterval：不重写状态文件
+	if err := os.Remove(statePath); err != nil {
+		t.Fatalf("remove state: %v", err)
+	}
+	now = now.Add(10 * time.Second)
+	ingest()
+	if stateExists() {
+		t.Fatalf("state should not be rewritten when nothing changed")
+	}
+	// 高水位不早于最新事件：时间越过 overlap 后才开始随 now 推进
+	now = now.Add(opts.Overlap + slashingSaveInterval)
+	ingest()
+	if !stateExists() {
+		t.Fatalf("state should be saved once the high water mark advanced by the save interval")
+	}
+
+	// 超过保留窗口后，最近惩罚时间与事件 key 一起被清理，series 随之删除
+	now = now.Add(slashingWindow + time.Hour)
+	ingest()
+	if len(ing.state.LastSlash) != 0 || len(ing.state.Events) != 0 {
+		t.Fatalf("expected pruned state, got last_slash=%v events=%v", ing.state.LastSlash, ing.state.Events)
+	}
+	out := root.RenderText()
+	if strings.Contains(out, "\nbiya_validator_last_slash_timestamp{") {
+		t.Fatalf("pruned last slash should not be exported:\n%s", out)
+	}
+	assertContains(t, out, "\nbiya_validator_slashing_events_total{address=\"valA\",type=\"downtime\"} 1\n")
+}
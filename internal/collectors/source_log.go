@@ -0,0 +1,18 @@
+package collectors
+
+import (
+	"log/slog"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/circuit"
+)
+
+// warnSourceErr 记录数据源调用失败。熔断打开期间的快速失败降为 debug：状态变化已由 circuit 记录，
+// 上游宕机时不必每个周期刷一条 warn。
+func warnSourceErr(log *slog.Logger, msg string, err error, args ...any) {
+	args = append(args, "err", err)
+	if circuit.IsOpen(err) {
+		log.Debug(msg, args...)
+		return
+	}
+	log.Warn(msg, args...)
+}
@@ -0,0 +1,194 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// validatorDetail 为 stake API GetValidator 中列表接口没有的字段（目前只用到 commission）。
+type validatorDetail struct {
+	commission    float64
+	hasCommission bool
+}
+
+// ValidatorDetailCache 缓存按 operator 地址查询的验证人详情：每个验证人最多每 ttl 查询一次，
+// 每次采集最多刷新 maxPerRun 个（从未查询过的优先，其次是最旧的），并发不超过 concurrency。
+// 冷启动时详情在若干个采集周期内逐步补齐，而不是每 10 秒对全部验证人各发一次请求。
+// 跨配置重载共享（见 jobDeps）：重建 collector 后不必重新补齐，参数通过 Configure 更新。
+type ValidatorDetailCache struct {
+	ttl         time.Duration
+	concurrency int
+	maxPerRun   int
+
+	mu      sync.Mutex
+	entries map[string]*validatorDetailEntry
+}
+
+type validatorDetailEntry struct {
+	detail validatorDetail
+	ok     bool
+	// attemptedAt 为最近一次查询时间（无论成败），失败的条目同样等 ttl 后再查，避免故障期间反复请求
+	attemptedAt time.Time
+}
+
+func NewValidatorDetailCache(ttl time.Duration, concurrency, maxPerRun int) *ValidatorDetailCache {
+	c := &ValidatorDetailCache{entries: map[string]*validatorDetailEntry{}}
+	c.Configure(ttl, concurrency, maxPerRun)
+	return c
+}
+
+// Configure 更新缓存时间、并发数与单次采集的查询上限（配置热加载），已缓存的详情保留。
+func (c *ValidatorDetailCache) Configure(ttl time.Duration, concurrency, maxPerRun int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl, c.concurrency, c.maxPerRun = ttl, max(concurrency, 1), maxPerRun
+}
+
+// get 返回已缓存的详情；查询从未成功过时 ok 为 false。
+func (c *ValidatorDetailCache) get(operator string) (validatorDetail, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[operator]
+	if !ok || !e.ok {
+		return validatorDetail{}, false
+	}
+	return e.detail, true
+}
+
+// refresh 刷新 operators 中过期的条目并删除已不在集合中的验证人，返回本次查询数与失败合并的错误。
+// 查询失败时保留上一次成功的详情。
+func (c *ValidatorDetailCache) refresh(ctx context.Context, operators []string, now time.Time, fetch func(context.Context, string) (validatorDetail, error)) (int, error) {
+	due, concurrency := c.due(operators, now)
+	if len(due) == 0 {
+		return 0, nil
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	jobs := make(chan string)
+	for i := 0; i < min(concurrency, len(due)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for op := range jobs {
+				d, err := fetch(ctx, op)
+				if err != nil && ctx.Err() != nil {
+					// 采集被取消/超时不算该验证人的查询，下次采集仍优先查询它
+					continue
+				}
+				c.mu.Lock()
+				e := c.entries[op]
+				if e == nil {
+					e = &validatorDetailEntry{}
+					c.entries[op] = e
+				}
+				e.attemptedAt = now
+				if err == nil {
+					e.detail, e.ok = d, true
+				}
+				c.mu.Unlock()
+				if err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+send:
+	for _, op := range due {
+		select {
+		case jobs <- op:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return len(due), errors.Join(errs...)
+}
+
+// due 返回需要查询的 operator（按上次查询时间升序，从未查询的在前，最多 maxPerRun 个）与当前并发上限，并清理已移除的验证人。
+func (c *ValidatorDetailCache) due(operators []string, now time.Time) ([]string, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current := make(map[string]struct{}, len(operators))
+	var due []string
+	for _, op := range operators {
+		if op == "" {
+			continue
+		}
+		current[op] = struct{}{}
+		if e, ok := c.entries[op]; !ok || now.Sub(e.attemptedAt) >= c.ttl {
+			due = append(due, op)
+		}
+	}
+	for op := range c.entries {
+		if _, ok := current[op]; !ok {
+			delete(c.entries, op)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return c.attemptedAt(due[i]).Before(c.attemptedAt(due[j]))
+	})
+	if c.maxPerRun > 0 && len(due) > c.maxPerRun {
+		due = due[:c.maxPerRun]
+	}
+	return due, c.concurrency
+}
+
+func (c *ValidatorDetailCache) attemptedAt(op string) time.Time {
+	if e, ok := c.entries[op]; ok {
+		return e.attemptedAt
+	}
+	return time.Time{}
+}
+
+// parseValidatorDetail 从 GetValidator 的响应中解析 commission（0-1），兼容常见的几种字段形态：
+// commissionRate / commission（标量）/ commission.commissionRates.rate / commission.commission_rates.rate / commission.rate。
+// 响应可能直接是验证人对象，也可能包在 validator 字段里。
+func parseValidatorDetail(raw json.RawMessage) (validatorDetail, error) {
+	var top struct {
+		Validator json.RawMessage `json:"validator"`
+	}
+	if err := jsonUnmarshal(raw, &top); err == nil && len(top.Validator) > 0 && top.Validator[0] == '{' {
+		raw = top.Validator
+	}
+
+	var v struct {
+		CommissionRate any `json:"commissionRate"`
+		Commission     any `json:"commission"`
+	}
+	if err := jsonUnmarshal(raw, &v); err != nil {
+		return validatorDetail{}, err
+	}
+	if r, ok := toFloat64(v.CommissionRate); ok {
+		return validatorDetail{commission: r, hasCommission: true}, nil
+	}
+	switch c := v.Commission.(type) {
+	case map[string]any:
+		for _, key := range []string{"commissionRates", "commission_rates"} {
+			if rates, ok := c[key].(map[string]any); ok {
+				if r, ok := toFloat64(rates["rate"]); ok {
+					return validatorDetail{commission: r, hasCommission: true}, nil
+				}
+			}
+		}
+		if r, ok := toFloat64(c["rate"]); ok {
+			return validatorDetail{commission: r, hasCommission: true}, nil
+		}
+	default:
+		if r, ok := toFloat64(c); ok {
+			return validatorDetail{commission: r, hasCommission: true}, nil
+		}
+	}
+	return validatorDetail{}, nil
+}
@@ -0,0 +1,257 @@
+package collectors
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/circuit"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/stake"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/tendermint"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+// validatorMappingRefresh 为共识地址 -> operator 地址映射（stake API）的刷新间隔。
+const validatorMappingRefresh = 5 * time.Minute
+
+// ValidatorSigningSubscriber 逐块统计每个验证人的出块与漏签：
+//   - 出块：header.proposer_address
+//   - 签名：last_commit.signatures（对应上一高度），BlockIDFlagAbsent 视为漏签（与 x/slashing 口径一致，nil 票算已签）
+//
+// 共识地址通过 stake.Validator.ConsensusAddress 映射到 operator 地址，输出与其它单验证人指标一致的 {address, moniker} label。
+// 计数自 exporter 启动后单调递增；映射暂缺的验证人照常计数，待映射就绪后把积压的增量一并累加。
+type ValidatorSigningSubscriber struct {
+	log *slog.Logger
+	m   *metrics.Metrics
+	tm  *tendermint.Client
+	api *stake.Client
+
+	// byConsensus：共识地址（大写 hex）-> stake 验证人
+	byConsensus map[string]stake.Validator
+	mappedAt    time.Time
+
+	// set 为最近一次拉取的 validator set（共识地址，按 commit 签名顺序）
+	set []string
+
+	stats map[string]*signingStats
+}
+
+// signingStats 中 proposed/missed 为上次 Flush 之后尚未写入 counter 的增量。
+type signingStats struct {
+	proposed   float64
+	missed     float64
+	lastActive time.Time
+}
+
+func NewValidatorSigningSubscriber(log *slog.Logger, m *metrics.Metrics, tm *tendermint.Client, api *stake.Client) *ValidatorSigningSubscriber {
+	return &ValidatorSigningSubscriber{
+		log:         log,
+		m:           m,
+		tm:          tm,
+		api:         api,
+		byConsensus: make(map[string]stake.Validator),
+		stats:       make(map[string]*signingStats),
+	}
+}
+
+func (s *ValidatorSigningSubscriber) OnBlock(ctx context.Context, b *FollowedBlock) {
+	if p := strings.ToUpper(b.Proposer); p != "" {
+		st := s.stat(p)
+		st.proposed++
+		if b.Time.After(st.lastActive) {
+			st.lastActive = b.Time
+		}
+	}
+
+	if len(b.LastCommit) == 0 {
+		return
+	}
+	signers, ok := s.signers(ctx, b)
+	if !ok {
+		return
+	}
+	for i, sig := range b.LastCommit {
+		st := s.stat(signers[i])
+		if sig.BlockIDFlag == tendermint.BlockIDFlagAbsent {
+			st.missed++
+			continue
+		}
+		at := sig.Timestamp
+		if at.IsZero() {
+			at = b.Time
+		}
+		if at.After(st.lastActive) {
+			st.lastActive = at
+		}
+	}
+}
+
+// signers 返回与 b.LastCommit 下标一一对应的共识地址。
+// 优先复用缓存的 validator set：签名数一致且所有非空地址都对得上时无需请求；否则按 LastCommitHeight 重新拉取。
+func (s *ValidatorSigningSubscriber) signers(ctx context.Context, b *FollowedBlock) ([]string, bool) {
+	if s.setMatches(b.LastCommit) {
+		return s.set, true
+	}
+	vals, err := s.tm.ValidatorSet(circuit.WithSource(ctx, "tendermint_validators"), b.LastCommitHeight)
+	if err != nil {
+		s.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "tendermint_validators"}, 0)
+		warnSourceErr(s.log, "validator set unavailable, skip signatures of block", err, "collector", "validator_signing", "height", b.LastCommitHeight)
+		return nil, false
+	}
+	s.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "tendermint_validators"}, 1)
+	set := make([]string, len(vals))
+	for i, v := range vals {
+		set[i] = strings.ToUpper(v.Address)
+	}
+	s.set = set
+	if !s.setMatches(b.LastCommit) {
+		s.log.Warn("commit signatures do not match validator set, skip block", "collector", "validator_signing", "height", b.LastCommitHeight, "signatures", len(b.LastCommit), "validators", len(set))
+		return nil, false
+	}
+	return s.set, true
+}
+
+func (s *ValidatorSigningSubscriber) setMatches(sigs []tendermint.CommitSig) bool {
+	if len(s.set) != len(sigs) {
+		return false
+	}
+	for i, sig := range sigs {
+		if sig.ValidatorAddress != "" && !strings.EqualFold(sig.ValidatorAddress, s.set[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *ValidatorSigningSubscriber) stat(consensusAddr string) *signingStats {
+	st, ok := s.stats[consensusAddr]
+	if !ok {
+		st = &signingStats{}
+		s.stats[consensusAddr] = st
+	}
+	return st
+}
+
+func (s *ValidatorSigningSubscriber) Flush(ctx context.Context) {
+	s.refreshMapping(ctx)
+	for addr, st := range s.stats {
+		v, ok := s.byConsensus[addr]
+		if !ok {
+			continue
+		}
+		labels := map[string]string{"address": v.OperatorAddress, "moniker": v.Moniker}
+		_ = s.m.AddCounter("biya_validator_blocks_proposed_total", labels, st.proposed)
+		_ = s.m.AddCounter("biya_validator_blocks_missed_total", labels, st.missed)
+		st.proposed, st.missed = 0, 0
+		if !st.lastActive.IsZero() {
+			s.m.SetGauge("biya_validator_last_active_timestamp", labels, float64(st.lastActive.Unix()))
+		}
+	}
+}
+
+func (s *ValidatorSigningSubscriber) refreshMapping(ctx context.Context) {
+	if !s.mappedAt.IsZero() && time.Since(s.mappedAt) < validatorMappingRefresh {
+		return
+	}
+	vals, err := s.api.GetValidatorsAll(circuit.WithSource(ctx, "stake_validators_for_signing"), 100, 0)
+	if err != nil {
+		s.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "stake_validators_for_signing"}, 0)
+		warnSourceErr(s.log, "validator mapping refresh failed", err, "collector", "validator_signing")
+		return
+	}
+	s.m.SetGauge("biya_exporter_source_up", map[string]string{"source": "stake_validators_for_signing"}, 1)
+	m := make(map[string]stake.Validator, len(vals))
+	for _, v := range vals {
+		key, err := consensusAddressHex(v.ConsensusAddress)
+		if err != nil {
+			s.log.Debug("skip validator with unparseable consensus address", "collector", "validator_signing", "operator", v.OperatorAddress, "consensus_address", v.ConsensusAddress, "err", err)
+			continue
+		}
+		m[key] = v
+	}
+	s.byConsensus = m
+	s.mappedAt = time.Now()
+}
+
+// consensusAddressHex 把 stake API 的共识地址统一成 CometBFT RPC 使用的大写 hex。
+// 兼容 hex（可带 0x 前缀）与 bech32（如 xxxvalcons1...）两种写法。
+func consensusAddressHex(addr string) (string, error) {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		return "", errors.New("empty consensus address")
+	}
+	raw := strings.TrimPrefix(strings.TrimPrefix(addr, "0x"), "0X")
+	if _, err := hex.DecodeString(raw); err == nil {
+		return strings.ToUpper(raw), nil
+	}
+	data, err := bech32Decode(addr)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToUpper(hex.EncodeToString(data)), nil
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// bech32Decode 解码 bech32 地址（BIP-173）并返回 8-bit 数据部分；会校验 checksum。
+func bech32Decode(s string) ([]byte, error) {
+	s = strings.ToLower(s)
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 || sep+7 > len(s) {
+		return nil, errors.New("invalid bech32 separator position")
+	}
+	hrp, dataPart := s[:sep], s[sep+1:]
+	values := make([]byte, len(dataPart))
+	for i := 0; i < len(dataPart); i++ {
+		idx := strings.IndexByte(bech32Charset, dataPart[i])
+		if idx < 0 {
+			return nil, errors.New("invalid bech32 character")
+		}
+		values[i] = byte(idx)
+	}
+
+	// checksum：polymod(hrp 展开 + data) == 1
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	step := func(v byte) {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	for i := 0; i < len(hrp); i++ {
+		step(hrp[i] >> 5)
+	}
+	step(0)
+	for i := 0; i < len(hrp); i++ {
+		step(hrp[i] & 31)
+	}
+	for _, v := range values {
+		step(v)
+	}
+	if chk != 1 {
+		return nil, errors.New("invalid bech32 checksum")
+	}
+
+	// 5-bit -> 8-bit，丢弃末尾 6 个 checksum 字符
+	var out []byte
+	acc, bits := uint32(0), uint(0)
+	for _, v := range values[:len(values)-6] {
+		acc = acc<<5 | uint32(v)
+		bits += 5
+		for bits >= 8 {
+			bits -= 8
+			out = append(out, byte(acc>>bits))
+		}
+	}
+	if bits >= 5 || acc&(1<<bits-1) != 0 {
+		return nil, errors.New("invalid bech32 padding")
+	}
+	return out, nil
+}
@@ -0,0 +1,100 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/stake"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/adapters/tendermint"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+func TestValidatorSigningSubscriber_ProposedMissedAndLastActive(t *testing.T) {
+	t.Parallel()
+
+	const (
+		valA = "AAAA000000000000000000000000000000000001"
+		valB = "BBBB000000000000000000000000000000000002"
+		valC = "CCCC000000000000000000000000000000000003"
+	)
+
+	var validatorsCalls atomic.Int64
+	tmSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/validators" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		validatorsCalls.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"result": map[string]any{
+				"validators": []any{
+					map[string]any{"address": valA, "voting_power": "10"},
+					map[string]any{"address": valB, "voting_power": "10"},
+					map[string]any{"address": valC, "voting_power": "10"},
+				},
+				"count": "3",
+				"total": "3",
+			},
+		})
+	}))
+	defer tmSrv.Close()
+
+	stakeSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"code":    0,
+			"message": "success",
+			"data": map[string]any{
+				"validators": []any{
+					// bech32 与 hex 两种写法都应能映射
+					map[string]any{"moniker": "alpha", "operatorAddress": "opA", "consensusAddress": "biyavalcons1424qqqqqqqqqqqqqqqqqqqqqqqqqqqqpajrzdc", "status": 3},
+					map[string]any{"moniker": "beta", "operatorAddress": "opB", "consensusAddress": strings.ToLower(valB), "status": 3},
+				},
+				"pagination": map[string]any{"hasNext": false},
+			},
+		})
+	}))
+	defer stakeSrv.Close()
+
+	_, m := metrics.New("biya", "dev", "none")
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, &slog.HandlerOptions{}))
+	s := NewValidatorSigningSubscriber(logger, m, tendermint.NewClient(tmSrv.URL, 2*time.Second), stake.NewClient(stakeSrv.URL, "", 2*time.Second))
+
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	s.OnBlock(ctx, &FollowedBlock{
+		Height: 2, Time: t0.Add(10 * time.Second), Proposer: valA, LastCommitHeight: 1,
+		LastCommit: []tendermint.CommitSig{
+			{BlockIDFlag: tendermint.BlockIDFlagCommit, ValidatorAddress: valA, Timestamp: t0.Add(12 * time.Second)},
+			{BlockIDFlag: tendermint.BlockIDFlagAbsent},
+			{BlockIDFlag: tendermint.BlockIDFlagCommit, ValidatorAddress: valC, Timestamp: t0.Add(9 * time.Second)},
+		},
+	})
+	s.OnBlock(ctx, &FollowedBlock{
+		Height: 3, Time: t0.Add(20 * time.Second), Proposer: valB, LastCommitHeight: 2,
+		LastCommit: []tendermint.CommitSig{
+			{BlockIDFlag: tendermint.BlockIDFlagCommit, ValidatorAddress: valA, Timestamp: t0.Add(18 * time.Second)},
+			{BlockIDFlag: tendermint.BlockIDFlagAbsent},
+			// nil 票不算漏签
+			{BlockIDFlag: tendermint.BlockIDFlagNil, ValidatorAddress: valC, Timestamp: t0.Add(19 * time.Second)},
+		},
+	})
+	s.Flush(ctx)
+
+	if got := validatorsCalls.Load(); got != 1 {
+		t.Fatalf("validator set should be cached while it matches, calls = %d", got)
+	}
+	out := m.RenderText()
+	assertContains(t, out, "\nbiya_validator_blocks_proposed_total{address=\"opA\",moniker=\"alpha\"} 1\n")
+	assertContains(t, out, "\nbiya_validator_blocks_missed_total{address=\"opA\",moniker=\"alpha\"} 0\n")
+	assertContains(t, out, "\nbiya_validator_last_active_timestamp{address=\"opA\",moniker=\"alpha\"} 1735689618\n")
+	assertContains(t, out, "\nbiya_validator_blocks_proposed_total{address=\"opB\",moniker=\"beta\"} 1\n")
+	assertContains(t, out, "\nbiya_validator_blocks_missed_total{address=\"opB\",moniker=\"beta\"} 2\n")
+	assertContains(t, out, "\nbiya_validator_last_active_timestamp{address=\"opB\",moniker=\"beta\"} 1735689620\n")
+}
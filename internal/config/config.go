@@ -1,12 +1,10 @@
 package config
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 )
@@ -20,21 +18,64 @@ type Config struct {
 	Log      LogConfig      `json:"log"`
 
 	ScrapeIntervals ScrapeIntervalsConfig `json:"scrape_intervals"`
+	Scheduler       SchedulerConfig       `json:"scheduler"`
 	HTTPClient      HTTPClientConfig      `json:"http_client"`
+	CircuitBreaker  CircuitBreakerConfig  `json:"circuit_breaker"`
+	Metrics         MetricsConfig         `json:"metrics"`
+	RemoteWrite     RemoteWriteConfig     `json:"remote_write"`
+	OTLP            OTLPConfig            `json:"otlp"`
 	Mock            MockConfig            `json:"mock"`
 }
 
 type ChainConfig struct {
 	ChainID string `json:"chain_id"`
+	// Denom 为质押币种的元数据，所有 *_byb 指标按此把基础单位换算为展示单位
+	Denom DenomConfig `json:"denom"`
+}
+
+type DenomConfig struct {
+	// Base 为链上基础单位（staking params 中的 bond_denom）；为空时以 LCD 返回的 bond_denom 为准
+	Base string `json:"base"`
+	// Display 为展示单位，即指标名中的 BYB
+	Display string `json:"display"`
+	// Exponent 为小数位数：1 Display = 10^Exponent Base
+	Exponent int `json:"exponent"`
+}
+
+func (c DenomConfig) validate() error {
+	if c.Exponent < 0 || c.Exponent > 36 {
+		return fmt.Errorf("chain.denom.exponent: %d out of range [0, 36]", c.Exponent)
+	}
+	return nil
 }
 
 type NodeConfig struct {
 	// Tendermint/CometBFT RPC，例如：https://rpc.xxx:26657
-	TendermintRPCBaseURL string `json:"tendermint_rpc_base_url"`
+	// 支持多个具名节点（sentry/RPC 等），写法见 RPCEndpoints；第一个节点作为 mempool/TPS 的主节点。
+	TendermintRPCBaseURL RPCEndpoints `json:"tendermint_rpc_base_url"`
 	// Cosmos LCD REST，例如：https://api.xxx:1317
 	LCDBaseURL string `json:"lcd_base_url"`
 	// Mempool 容量（pending tx 上限）。若未配置，默认 5000（见 provide.md）
 	MempoolCapacity int `json:"mempool_capacity"`
+	// gas 利用率滚动平均的区块数（最近 N 个区块）
+	GasWindowBlocks int `json:"gas_window_blocks"`
+	// 逐块拉取（不跳块）的并发与单轮补拉上限
+	BlockFollower BlockFollowerConfig `json:"block_follower"`
+	// CometBFT WebSocket 订阅（NewBlock），用于亚秒级更新；断线时自动回退到轮询
+	WebSocket WebSocketConfig `json:"websocket"`
+}
+
+type WebSocketConfig struct {
+	Enabled bool `json:"enabled"`
+	// 为空时由主节点 RPC 地址推导（http->ws, https->wss，路径 /websocket）
+	URL string `json:"url"`
+}
+
+type BlockFollowerConfig struct {
+	// 同时拉取的最大区块数（每个区块请求 /block 与 /block_results）
+	Concurrency int `json:"concurrency"`
+	// 单次运行最多处理的区块数：落后更多时分多轮追赶；首次启动只回看这么多区块
+	MaxBlocksPerRun int `json:"max_blocks_per_run"`
 }
 
 type ExplorerConfig struct {
@@ -43,6 +84,9 @@ type ExplorerConfig struct {
 	BaseURL string `json:"base_url"`
 	// Bearer token（即文档中的 API Key，不要带 "Bearer " 前缀）
 	APIKey string `json:"api_key"`
+	// Stream 为 true 时改用 /api/v1/stream/blocks 与 /api/v1/stream/transactions 推送更新区块/交易指标，
+	// 区块流断开期间回退到轮询 /api/v1/block/latest。
+	Stream bool `json:"stream"`
 }
 
 type StakeConfig struct {
@@ -51,10 +95,52 @@ type StakeConfig struct {
 	BaseURL string `json:"base_url"`
 	// Bearer token（即文档中的 API Key，不要带 "Bearer " 前缀）
 	APIKey string `json:"api_key"`
+	// 验证人列表的分页大小与单次采集的页数预算；达到预算仍未取完时按已取到的部分上报，
+	// 与上游 total 的差值体现在 biya_stake_validators_total_mismatch
+	ValidatorsPageSize int `json:"validators_page_size"`
+	ValidatorsMaxPages int `json:"validators_max_pages"`
+	// ValidatorDetails 为逐个验证人查询详情（commission）的缓存与并发参数
+	ValidatorDetails ValidatorDetailsConfig `json:"validator_details"`
+	// Governance 为治理提案采集（minute_governance job）的参数
+	Governance GovernanceConfig `json:"governance"`
+	// Slashing 为惩罚事件增量拉取的参数
+	Slashing SlashingConfig `json:"slashing"`
+}
+
+type SlashingConfig struct {
+	// StateFile 保存已拉取的高水位与 24h 内的事件 key，重启后从高水位继续；为空时只保存在内存中（重启后重新回看 InitialLookback）
+	StateFile string `json:"state_file"`
+	// 单次拉取的分页大小与页数预算；达到预算时不推进高水位
+	PageSize int `json:"page_size"`
+	MaxPages int `json:"max_pages"`
+	// InitialLookback 为没有高水位时回看的时长
+	InitialLookback time.Duration `json:"initial_lookback"`
+	// Overlap 为每次查询在高水位之前多回看的时长（上游索引延迟）
+	Overlap time.Duration `json:"overlap"`
+}
+
+type GovernanceConfig struct {
+	// 提案列表的分页大小与单次采集的页数预算；达到预算时不上报汇总计数，本次采集记为失败（不清理单提案 series）
+	PageSize int `json:"page_size"`
+	MaxPages int `json:"max_pages"`
+	// RecentWindow 内结束投票的提案仍输出单提案指标（投票中的提案始终输出）
+	RecentWindow time.Duration `json:"recent_window"`
+}
+
+type ValidatorDetailsConfig struct {
+	// TTL 为同一验证人两次查询的最小间隔
+	TTL time.Duration `json:"ttl"`
+	// Concurrency 为同时进行的查询数
+	Concurrency int `json:"concurrency"`
+	// MaxPerRun 为单次采集最多查询的验证人数（冷启动时分多个周期补齐）；0 表示不限制
+	MaxPerRun int `json:"max_per_run"`
 }
 
 type HTTPConfig struct {
 	ListenAddr string `json:"listen_addr"`
+	// EnableLifecycle 开启 POST /-/reload（默认关闭）。该接口与 /metrics 共用监听地址且不鉴权，
+	// 能访问 /metrics 的人都能触发重载，因此与 Prometheus 的 --web.enable-lifecycle 一样需要显式开启；SIGHUP 不受影响
+	EnableLifecycle bool `json:"enable_lifecycle"`
 }
 
 type LogConfig struct {
@@ -64,6 +150,34 @@ type LogConfig struct {
 
 type HTTPClientConfig struct {
 	Timeout time.Duration `json:"timeout"`
+	// Retry 为 explorer/stake API 的 GET 重试（网络错误、5xx、429），遵循 Retry-After
+	Retry RetryConfig `json:"retry"`
+	// RateLimit 为 explorer/stake API 按 base URL 的令牌桶限速，同一上游的全部 collector 共享
+	RateLimit RateLimitConfig `json:"rate_limit"`
+}
+
+type RetryConfig struct {
+	// MaxRetries 为 0 时不重试
+	MaxRetries int `json:"max_retries"`
+	// MinBackoff/MaxBackoff 为带随机抖动的指数退避区间；Retry-After 超过 MaxBackoff 时不再重试
+	MinBackoff time.Duration `json:"min_backoff"`
+	MaxBackoff time.Duration `json:"max_backoff"`
+}
+
+type RateLimitConfig struct {
+	// RequestsPerSecond 为 0 时不限速
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	// Burst 为令牌桶容量（允许的突发请求数）
+	Burst int `json:"burst"`
+}
+
+// CircuitBreakerConfig 为数据源熔断参数：某个 source 连续失败 FailureThreshold 次后暂停请求，
+// 经过 MinBackoff 放行一次探测，探测失败则等待时间翻倍（不超过 MaxBackoff），成功则恢复。
+type CircuitBreakerConfig struct {
+	// FailureThreshold 为 0 时关闭熔断
+	FailureThreshold int           `json:"failure_threshold"`
+	MinBackoff       time.Duration `json:"min_backoff"`
+	MaxBackoff       time.Duration `json:"max_backoff"`
 }
 
 type ScrapeIntervalsConfig struct {
@@ -72,6 +186,126 @@ type ScrapeIntervalsConfig struct {
 	Hourly   time.Duration `json:"hourly"`
 }
 
+// SchedulerConfig 为 job 的调度参数：Defaults 作用于全部 job，Jobs 按 job 名（即 source label，例如 realtime_chain）覆盖，
+// 只覆盖非零字段。
+type SchedulerConfig struct {
+	Defaults JobScheduleConfig            `json:"defaults"`
+	Jobs     map[string]JobScheduleConfig `json:"jobs"`
+}
+
+type JobScheduleConfig struct {
+	// Timeout 为单次 run 的超时，0 表示等于该 job 的采集周期
+	Timeout time.Duration `json:"timeout"`
+	// Jitter 为启动时的随机延迟上限，错开各 job 的首次运行与周期相位
+	Jitter time.Duration `json:"jitter"`
+	// MaxConcurrentRuns 为同一 job 同时进行的 run 数上限，到点时已满则跳过本次；0 表示 1
+	MaxConcurrentRuns int `json:"max_concurrent_runs"`
+}
+
+// For 返回 job 的生效调度参数（Defaults 叠加 Jobs[job]）。
+func (c SchedulerConfig) For(job string) JobScheduleConfig {
+	out := c.Defaults
+	o, ok := c.Jobs[job]
+	if !ok {
+		return out
+	}
+	if o.Timeout > 0 {
+		out.Timeout = o.Timeout
+	}
+	if o.Jitter > 0 {
+		out.Jitter = o.Jitter
+	}
+	if o.MaxConcurrentRuns > 0 {
+		out.MaxConcurrentRuns = o.MaxConcurrentRuns
+	}
+	return out
+}
+
+type MetricsConfig struct {
+	// SeriesTTL：collector 超过该时间（且至少两个采集周期）未刷新的 series 会从 /metrics 删除；0 表示不按时间过期。
+	// 单实体指标（biya_validator_*、biya_proposal_*）不受此限制，所属 collector 一次成功 run 未刷新即删除。
+	SeriesTTL time.Duration `json:"series_ttl"`
+	// Fallback 为数据源失败时指标的处理策略
+	Fallback FallbackConfig `json:"fallback"`
+}
+
+// FallbackConfig：zero 置 0；keep_last 保留上次成功值（同时输出 biya_exporter_metric_age_seconds{metric}）；
+// drop 删除 series。Default 作用于全部兜底指标，Metrics 按指标名覆盖。
+type FallbackConfig struct {
+	Default string            `json:"default"`
+	Metrics map[string]string `json:"metrics"`
+}
+
+func (c FallbackConfig) validate() error {
+	check := func(path, v string) error {
+		switch v {
+		case "zero", "keep_last", "drop":
+			return nil
+		}
+		return fmt.Errorf("%s: unknown fallback policy %q (want zero|keep_last|drop)", path, v)
+	}
+	if err := check("metrics.fallback.default", c.Default); err != nil {
+		return err
+	}
+	for metric, v := range c.Metrics {
+		if err := check("metrics.fallback.metrics."+metric, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoteWriteConfig 为可选的推送模式：周期性把 registry 快照按 Prometheus remote write 协议
+// （protobuf + snappy）POST 到各 endpoint，适用于无法被 Prometheus 主动抓取的部署。
+type RemoteWriteConfig struct {
+	// Endpoints 为空时不启用推送
+	Endpoints []RemoteWriteEndpoint `json:"endpoints"`
+	// Interval 为推送周期（每个周期一次完整快照）
+	Interval time.Duration `json:"interval"`
+	// Timeout 为单次 HTTP 请求超时
+	Timeout time.Duration `json:"timeout"`
+	// ExternalLabels 附加到每条 series；与指标自身 label 重名时以指标 label 为准
+	ExternalLabels map[string]string `json:"external_labels"`
+	// QueueSize 为每个 endpoint 最多积压的快照数（内存队列），满了丢弃最旧的
+	QueueSize int `json:"queue_size"`
+	// MaxRetries 为单个快照在一个周期内的最大重试次数（网络错误、5xx、429 才重试）
+	MaxRetries int `json:"max_retries"`
+	// MinBackoff/MaxBackoff 为重试的指数退避区间
+	MinBackoff time.Duration `json:"min_backoff"`
+	MaxBackoff time.Duration `json:"max_backoff"`
+}
+
+type RemoteWriteEndpoint struct {
+	// Name 用于指标 label（target/source），为空时使用 URL 的 host
+	Name string `json:"name"`
+	// URL 例如 http://prometheus:9090/api/v1/write 或 http://victoriametrics:8428/api/v1/write
+	URL string `json:"url"`
+	// BearerToken 非空时以 Authorization: Bearer 发送（建议用 ${ENV} 引用）
+	BearerToken string `json:"bearer_token"`
+	// Headers 为附加请求头（例如多租户的 X-Scope-OrgID）
+	Headers map[string]string `json:"headers"`
+}
+
+// OTLPConfig 为可选的 OTLP/HTTP metrics 推送：把 registry 快照转换为 OTLP（gauge / 单调 sum / 显式 bucket histogram），
+// 按周期 POST 到 OpenTelemetry Collector 等接收端；resource 属性由 chain_id、version、commit 生成。
+type OTLPConfig struct {
+	// Endpoint 例如 http://otel-collector:4318/v1/metrics；为空时不启用
+	Endpoint string `json:"endpoint"`
+	// Headers 为附加请求头（例如鉴权用的 Authorization，建议用 ${ENV} 引用）
+	Headers map[string]string `json:"headers"`
+	// Compression：gzip（默认）或 none
+	Compression string `json:"compression"`
+	// ResourceAttributes 为额外的 resource 属性（例如 deployment.environment），不覆盖内置属性
+	ResourceAttributes map[string]string `json:"resource_attributes"`
+	Interval           time.Duration     `json:"interval"`
+	Timeout            time.Duration     `json:"timeout"`
+	// QueueSize/MaxRetries/MinBackoff/MaxBackoff 含义与 remote_write 相同
+	QueueSize  int           `json:"queue_size"`
+	MaxRetries int           `json:"max_retries"`
+	MinBackoff time.Duration `json:"min_backoff"`
+	MaxBackoff time.Duration `json:"max_backoff"`
+}
+
 type MockConfig struct {
 	Enabled bool `json:"enabled"`
 	Values  struct {
@@ -86,19 +320,60 @@ type MockConfig struct {
 func Default() Config {
 	var c Config
 	c.Chain.ChainID = "biya"
-	c.Node.TendermintRPCBaseURL = ""
+	c.Chain.Denom.Display = "BYB"
+	c.Chain.Denom.Exponent = 18
+	c.Node.TendermintRPCBaseURL = nil
 	c.Node.LCDBaseURL = ""
 	c.Node.MempoolCapacity = 5000
+	c.Node.GasWindowBlocks = 100
+	c.Node.BlockFollower.Concurrency = 4
+	c.Node.BlockFollower.MaxBlocksPerRun = 50
 	c.Explorer.BaseURL = "https://prv.explorer.biya.io/demo"
 	c.Explorer.APIKey = ""
 	c.Stake.BaseURL = "https://prv.stake.biya.io/stake"
 	c.Stake.APIKey = ""
+	c.Stake.ValidatorsPageSize = 100
+	c.Stake.ValidatorsMaxPages = 20
+	c.Stake.ValidatorDetails.TTL = 10 * time.Minute
+	c.Stake.ValidatorDetails.Concurrency = 4
+	c.Stake.ValidatorDetails.MaxPerRun = 20
+	c.Stake.Governance.PageSize = 100
+	c.Stake.Governance.MaxPages = 50
+	c.Stake.Governance.RecentWindow = 7 * 24 * time.Hour
+	c.Stake.Slashing.PageSize = 100
+	c.Stake.Slashing.MaxPages = 20
+	c.Stake.Slashing.InitialLookback = 24 * time.Hour
+	c.Stake.Slashing.Overlap = 10 * time.Minute
 	c.HTTP.ListenAddr = ":9100"
 	c.Log.Level = "info"
 	c.HTTPClient.Timeout = 5 * time.Second
+	c.HTTPClient.Retry.MaxRetries = 2
+	c.HTTPClient.Retry.MinBackoff = 200 * time.Millisecond
+	c.HTTPClient.Retry.MaxBackoff = 2 * time.Second
+	c.HTTPClient.RateLimit.RequestsPerSecond = 10
+	c.HTTPClient.RateLimit.Burst = 20
+	c.CircuitBreaker.FailureThreshold = 3
+	c.CircuitBreaker.MinBackoff = 10 * time.Second
+	c.CircuitBreaker.MaxBackoff = 5 * time.Minute
 	c.ScrapeIntervals.Realtime = 10 * time.Second
 	c.ScrapeIntervals.Minute = 1 * time.Minute
 	c.ScrapeIntervals.Hourly = 1 * time.Hour
+	c.Scheduler.Defaults.MaxConcurrentRuns = 1
+	c.Metrics.SeriesTTL = 15 * time.Minute
+	c.Metrics.Fallback.Default = "keep_last"
+	c.RemoteWrite.Interval = 30 * time.Second
+	c.RemoteWrite.Timeout = 10 * time.Second
+	c.RemoteWrite.QueueSize = 10
+	c.RemoteWrite.MaxRetries = 3
+	c.RemoteWrite.MinBackoff = 500 * time.Millisecond
+	c.RemoteWrite.MaxBackoff = 5 * time.Second
+	c.OTLP.Compression = "gzip"
+	c.OTLP.Interval = 30 * time.Second
+	c.OTLP.Timeout = 10 * time.Second
+	c.OTLP.QueueSize = 10
+	c.OTLP.MaxRetries = 3
+	c.OTLP.MinBackoff = 500 * time.Millisecond
+	c.OTLP.MaxBackoff = 5 * time.Second
 	c.Mock.Enabled = true
 	c.Mock.Values.GasUtilizationRatio = 0
 	c.Mock.Values.CongestionRatio = 0
@@ -108,33 +383,39 @@ func Default() Config {
 	return c
 }
 
-// Load 仅做最小能力：如果提供 configPath 则读取 YAML 覆盖默认值。
-// 说明：后续若需要支持 env 覆盖/多环境，可再扩展，但 MVP 先保证联调可跑通。
-func Load(configPath string) (Config, error) {
+// Load 加载配置，优先级从低到高：
+//  1. Default() 内置默认值
+//  2. configPaths 依次覆盖（后者覆盖前者；支持 .yaml/.yml/.json，字段名即 json tag）
+//  3. BIYA_EXPORTER_* 环境变量覆盖（见 EnvPrefix）
+//
+// 配置文件中的字符串支持 ${ENV} / ${ENV:-default} 展开，便于 API Key 等敏感值不落盘；
+// 未知字段会直接报错（带完整路径），避免拼写错误被静默忽略。
+func Load(configPaths ...string) (Config, error) {
+	return load(configPaths, os.LookupEnv)
+}
+
+func load(configPaths []string, lookupEnv func(string) (string, bool)) (Config, error) {
 	cfg := Default()
-	if configPath == "" {
-		return cfg, nil
-	}
-	b, err := os.ReadFile(configPath)
-	if err != nil {
-		return Config{}, fmt.Errorf("read config: %w", err)
-	}
-	ext := strings.ToLower(filepath.Ext(configPath))
-	switch ext {
-	case ".json":
-		if err := json.Unmarshal(b, &cfg); err != nil {
-			return Config{}, fmt.Errorf("unmarshal json: %w", err)
+	for _, p := range configPaths {
+		if strings.TrimSpace(p) == "" {
+			continue
 		}
-	case ".yaml", ".yml":
-		if err := unmarshalYAMLMinimal(b, &cfg); err != nil {
-			return Config{}, fmt.Errorf("unmarshal yaml: %w", err)
+		if err := mergeFile(&cfg, p, lookupEnv); err != nil {
+			return Config{}, err
 		}
-	default:
-		return Config{}, fmt.Errorf("unsupported config extension: %s (supported: .yaml/.yml/.json)", ext)
+	}
+	if err := applyEnvOverrides(&cfg, lookupEnv); err != nil {
+		return Config{}, err
 	}
 	if cfg.Chain.ChainID == "" {
 		return Config{}, errors.New("chain.chain_id is required")
 	}
+	if err := cfg.Chain.Denom.validate(); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Metrics.Fallback.validate(); err != nil {
+		return Config{}, err
+	}
 	return cfg, nil
 }
 
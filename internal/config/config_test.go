@@ -0,0 +1,172 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return p
+}
+
+func fakeEnv(kv map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := kv[k]
+		return v, ok
+	}
+}
+
+func TestLoad_YAMLListsAnchorsAndEnvInterpolation(t *testing.T) {
+	t.Parallel()
+
+	p := writeFile(t, "config.yaml", `
+chain:
+  chain_id: biya-1
+node:
+  tendermint_rpc_base_url:
+    - name: sentry-1
+      url: "${RPC_HOST}:26657"
+    - &rpc
+      name: rpc-1
+      url: https://rpc-1:26657
+    - <<: *rpc
+      name: rpc-2
+stake:
+  base_url: https://stake
+  api_key: "${STAKE_KEY}"
+explorer:
+  api_key: "${EXPLORER_KEY:-}"
+scrape_intervals:
+  realtime: 15s
+mock:
+  enabled: "${MOCK:-false}"
+`)
+	cfg, err := load([]string{p}, fakeEnv(map[string]string{"RPC_HOST": "https://sentry-1", "STAKE_KEY": "secret"}))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Chain.ChainID != "biya-1" {
+		t.Fatalf("chain_id = %q", cfg.Chain.ChainID)
+	}
+	eps := cfg.Node.TendermintRPCBaseURL
+	if len(eps) != 3 || eps[0].Name != "sentry-1" || eps[0].URL != "https://sentry-1:26657" || eps[1].Name != "rpc-1" {
+		t.Fatalf("endpoints = %+v", eps)
+	}
+	// 通过 *rpc 别名合并：url 来自锚点，name 被覆盖
+	if eps[2].Name != "rpc-2" || eps[2].URL != "https://rpc-1:26657" {
+		t.Fatalf("aliased endpoint = %+v", eps[2])
+	}
+	if cfg.Stake.APIKey != "secret" || cfg.Explorer.APIKey != "" {
+		t.Fatalf("api keys = %q / %q", cfg.Stake.APIKey, cfg.Explorer.APIKey)
+	}
+	if cfg.ScrapeIntervals.Realtime != 15*time.Second || cfg.ScrapeIntervals.Minute != time.Minute {
+		t.Fatalf("intervals = %+v", cfg.ScrapeIntervals)
+	}
+	if cfg.Mock.Enabled {
+		t.Fatalf("mock.enabled should be false")
+	}
+	// 未覆盖的字段保持默认值
+	if cfg.HTTP.ListenAddr != ":9100" {
+		t.Fatalf("listen_addr = %q", cfg.HTTP.ListenAddr)
+	}
+}
+
+func TestLoad_IntegerStringsKeepFullPrecision(t *testing.T) {
+	t.Parallel()
+
+	// 2^53+1：经 float64 转换会变成 2^53
+	p := writeFile(t, "config.yaml", "node:\n  mempool_capacity: \"${CAP}\"\n")
+	cfg, err := load([]string{p}, fakeEnv(map[string]string{"CAP": "9007199254740993"}))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Node.MempoolCapacity != 9007199254740993 {
+		t.Fatalf("mempool_capacity = %d", cfg.Node.MempoolCapacity)
+	}
+	if _, err := load([]string{p}, fakeEnv(map[string]string{"CAP": "1.5"})); err == nil {
+		t.Fatalf("expected non-integer value for an int field to be rejected")
+	}
+}
+
+func TestLoad_MissingEnvReferenceIsError(t *testing.T) {
+	t.Parallel()
+
+	p := writeFile(t, "config.yaml", "stake:\n  api_key: ${STAKE_KEY}\n")
+	_, err := load([]string{p}, fakeEnv(nil))
+	if err == nil || !strings.Contains(err.Error(), "STAKE_KEY") {
+		t.Fatalf("expected missing env error, got %v", err)
+	}
+}
+
+func TestLoad_UnknownKeyIsRejected(t *testing.T) {
+	t.Parallel()
+
+	p := writeFile(t, "config.yaml", "scrape_intervals:\n  realtim: 5s\n")
+	_, err := load([]string{p}, fakeEnv(nil))
+	if err == nil || !strings.Contains(err.Error(), `"scrape_intervals.realtim"`) {
+		t.Fatalf("expected unknown key error, got %v", err)
+	}
+}
+
+func TestLoad_UnknownFallbackPolicyIsRejected(t *testing.T) {
+	t.Parallel()
+
+	p := writeFile(t, "config.yaml", "metrics:\n  fallback:\n    metrics:\n      biya_apr_annual: keep\n")
+	_, err := load([]string{p}, fakeEnv(nil))
+	if err == nil || !strings.Contains(err.Error(), "metrics.fallback.metrics.biya_apr_annual") {
+		t.Fatalf("expected fallback policy error, got %v", err)
+	}
+}
+
+func TestLoad_MultipleFilesAndEnvOverrides(t *testing.T) {
+	t.Parallel()
+
+	base := writeFile(t, "base.yaml", `
+node:
+  tendermint_rpc_base_url: https://base:26657
+  mempool_capacity: 100
+log:
+  level: debug
+`)
+	prod := writeFile(t, "prod.json", `{"node": {"mempool_capacity": 200}, "http_client": {"timeout": "3s"}}`)
+
+	cfg, err := load([]string{base, prod}, fakeEnv(map[string]string{
+		"BIYA_EXPORTER_LOG_LEVEL":                    "warn",
+		"BIYA_EXPORTER_STAKE_API_KEY":                "from-env",
+		"BIYA_EXPORTER_SCRAPE_INTERVALS_MINUTE":      "2m",
+		"BIYA_EXPORTER_NODE_TENDERMINT_RPC_BASE_URL": "a=https://a:26657,b=https://b:26657",
+	}))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Node.MempoolCapacity != 200 {
+		t.Fatalf("mempool_capacity = %d", cfg.Node.MempoolCapacity)
+	}
+	if cfg.HTTPClient.Timeout != 3*time.Second {
+		t.Fatalf("timeout = %s", cfg.HTTPClient.Timeout)
+	}
+	if cfg.Log.Level != "warn" || cfg.Stake.APIKey != "from-env" || cfg.ScrapeIntervals.Minute != 2*time.Minute {
+		t.Fatalf("env overrides not applied: %+v", cfg)
+	}
+	if eps := cfg.Node.TendermintRPCBaseURL; len(eps) != 2 || eps[1].Name != "b" {
+		t.Fatalf("endpoints = %+v", eps)
+	}
+}
+
+func TestLoad_ShippedConfigs(t *testing.T) {
+	t.Parallel()
+
+	for _, p := range []string{"../../configs/config.example.yaml", "../../configs/config.docker.yaml"} {
+		if _, err := load([]string{p}, fakeEnv(nil)); err != nil {
+			t.Fatalf("load %s: %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultEndpointName 为单地址写法时使用的节点名，与历史 node="default" label 保持一致。
+const DefaultEndpointName = "default"
+
+// RPCEndpoint 为一个具名的 Tendermint/CometBFT RPC 地址；Name 用作指标的 node label。
+type RPCEndpoint struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// RPCEndpoints 兼容三种写法：
+//   - 单个地址字符串："https://rpc.xxx:26657"（节点名为 default）
+//   - 逗号分隔的具名列表："sentry-1=https://a:26657,rpc-1=https://b:26657"
+//   - 列表（YAML/JSON）：[{"name":"sentry-1","url":"https://a:26657"}, ...]
+type RPCEndpoints []RPCEndpoint
+
+// Primary 返回第一个节点，供只需要单个 RPC 的 collector 使用（例如 mempool/TPS）。
+func (e RPCEndpoints) Primary() RPCEndpoint {
+	if len(e) == 0 {
+		return RPCEndpoint{Name: DefaultEndpointName}
+	}
+	return e[0]
+}
+
+func (e *RPCEndpoints) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		out, err := ParseRPCEndpoints(s)
+		if err != nil {
+			return err
+		}
+		*e = out
+		return nil
+	}
+	var list []RPCEndpoint
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&list); err != nil {
+		return fmt.Errorf("rpc endpoints: expect string or [{name,url}]: %w", err)
+	}
+	*e = list
+	return e.validate()
+}
+
+// ParseRPCEndpoints 解析字符串写法（单地址或 name=url 逗号分隔列表）。
+func ParseRPCEndpoints(s string) (RPCEndpoints, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	// 单地址：不含逗号，且 "=" 之前已经是 URL（允许 URL 自带 query 参数）
+	if !strings.Contains(s, ",") {
+		if name, _, ok := strings.Cut(s, "="); !ok || strings.Contains(name, "://") {
+			return RPCEndpoints{{Name: DefaultEndpointName, URL: s}}, nil
+		}
+	}
+	var out RPCEndpoints
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, u, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("rpc endpoints: invalid entry %q (expect name=url)", part)
+		}
+		out = append(out, RPCEndpoint{Name: strings.TrimSpace(name), URL: strings.TrimSpace(u)})
+	}
+	if err := out.validate(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (e RPCEndpoints) validate() error {
+	seen := make(map[string]bool, len(e))
+	for i, ep := range e {
+		if ep.Name == "" {
+			return fmt.Errorf("rpc endpoints[%d]: name is required", i)
+		}
+		if ep.URL == "" {
+			return fmt.Errorf("rpc endpoints[%d] (%s): url is required", i, ep.Name)
+		}
+		if seen[ep.Name] {
+			return fmt.Errorf("rpc endpoints: duplicate name %q", ep.Name)
+		}
+		seen[ep.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,421 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix 为环境变量覆盖的前缀：字段路径按 json tag 拼接、"." 换成 "_" 并转大写，
+// 例如 stake.api_key -> BIYA_EXPORTER_STAKE_API_KEY，scrape_intervals.realtime -> BIYA_EXPORTER_SCRAPE_INTERVALS_REALTIME。
+const EnvPrefix = "BIYA_EXPORTER_"
+
+var (
+	durationType    = reflect.TypeOf(time.Duration(0))
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+	// 仅支持 ${VAR} / ${VAR:-default}；不展开裸 $VAR，避免误伤值中本来就有的 "$"。
+	envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+)
+
+// mergeFile 读取单个配置文件并覆盖到 cfg 上（未出现的字段保持原值）。
+func mergeFile(cfg *Config, path string, lookupEnv func(string) (string, bool)) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+
+	var raw any
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("unmarshal json %s: %w", path, err)
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(b, &raw); err != nil {
+			return fmt.Errorf("unmarshal yaml %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unsupported config extension: %s (supported: .yaml/.yml/.json)", ext)
+	}
+	if raw == nil {
+		// 空文件：不覆盖任何字段
+		return nil
+	}
+
+	norm, err := normalize(raw, reflect.TypeOf(Config{}), "", lookupEnv)
+	if err != nil {
+		return fmt.Errorf("config %s: %w", path, err)
+	}
+	if err := mergeJSON(cfg, norm); err != nil {
+		return fmt.Errorf("config %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnvOverrides 对 Config 中每个叶子字段检查对应的 BIYA_EXPORTER_* 环境变量。
+// 列表/map 类字段的值按 YAML 解析（例如 '[{name: a, url: http://x}]'），其余按字段类型解析。
+func applyEnvOverrides(cfg *Config, lookupEnv func(string) (string, bool)) error {
+	overrides := map[string]any{}
+	for _, leaf := range leafFields(reflect.TypeOf(Config{}), "") {
+		name := envNameForPath(leaf.path)
+		v, ok := lookupEnv(name)
+		if !ok {
+			continue
+		}
+		var raw any = v
+		switch leaf.typ.Kind() {
+		case reflect.Slice, reflect.Map, reflect.Struct:
+			// RPCEndpoints 之类自带字符串写法的类型直接交给 UnmarshalJSON
+			if !isCustomUnmarshaler(leaf.typ) || strings.HasPrefix(strings.TrimSpace(v), "[") {
+				var parsed any
+				if err := yaml.Unmarshal([]byte(v), &parsed); err != nil {
+					return fmt.Errorf("env %s: %w", name, err)
+				}
+				raw = parsed
+			}
+		}
+		norm, err := normalize(raw, leaf.typ, leaf.path, lookupEnv)
+		if err != nil {
+			return fmt.Errorf("env %s: %w", name, err)
+		}
+		setPath(overrides, strings.Split(leaf.path, "."), norm)
+	}
+	if len(overrides) == 0 {
+		return nil
+	}
+	if err := mergeJSON(cfg, overrides); err != nil {
+		return fmt.Errorf("env overrides: %w", err)
+	}
+	return nil
+}
+
+// normalize 以 Config 的 json tag 为 schema 校验并规整原始数据：
+// - 拒绝未知 key（返回带完整路径的错误）
+// - 展开字符串中的 ${ENV}
+// - duration 支持 5s/1m/1h 与纳秒整数
+// - 字符串形式的 bool/number（常见于 ${ENV} 展开后）按字段类型转换
+func normalize(v any, t reflect.Type, path string, lookupEnv func(string) (string, bool)) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if isCustomUnmarshaler(t) {
+		return expandAll(v, path, lookupEnv)
+	}
+	if t == durationType {
+		s, err := scalarString(v, path, lookupEnv)
+		if err != nil {
+			return nil, err
+		}
+		d, err := parseDurationOrNanos(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return int64(d), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		m, err := asStringMap(v, path)
+		if err != nil {
+			return nil, err
+		}
+		fields := jsonFields(t)
+		out := make(map[string]any, len(m))
+		for k, child := range m {
+			f, ok := fields[k]
+			if !ok {
+				return nil, fmt.Errorf("unknown key %q", joinPath(path, k))
+			}
+			n, err := normalize(child, f.Type, joinPath(path, k), lookupEnv)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = n
+		}
+		return out, nil
+	case reflect.Map:
+		m, err := asStringMap(v, path)
+		if err != nil {
+			return nil, err
+		}
+		out := make(map[string]any, len(m))
+		for k, child := range m {
+			n, err := normalize(child, t.Elem(), joinPath(path, k), lookupEnv)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = n
+		}
+		return out, nil
+	case reflect.Slice:
+		list, ok := v.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: expect a list, got %T", path, v)
+		}
+		out := make([]any, 0, len(list))
+		for i, child := range list {
+			n, err := normalize(child, t.Elem(), fmt.Sprintf("%s[%d]", path, i), lookupEnv)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, n)
+		}
+		return out, nil
+	case reflect.String:
+		return scalarString(v, path, lookupEnv)
+	case reflect.Bool:
+		if s, ok := v.(string); ok {
+			s, err := expandEnv(s, path, lookupEnv)
+			if err != nil {
+				return nil, err
+			}
+			b, err := strconv.ParseBool(strings.TrimSpace(s))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			return b, nil
+		}
+		return v, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if s, ok := v.(string); ok {
+			s, err := expandEnv(s, path, lookupEnv)
+			if err != nil {
+				return nil, err
+			}
+			n, err := parseNumber(strings.TrimSpace(s), t.Kind())
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			return n, nil
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
+
+// mergeJSON 通过 json 往返把规整后的数据覆盖到 cfg；结构体字段逐个覆盖，列表整体替换。
+func mergeJSON(cfg *Config, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	return dec.Decode(cfg)
+}
+
+type leafField struct {
+	path string
+	typ  reflect.Type
+}
+
+// leafFields 列出所有可通过环境变量覆盖的字段（嵌套结构体展开，其余类型视为叶子）。
+func leafFields(t reflect.Type, path string) []leafField {
+	if t.Kind() != reflect.Struct || isCustomUnmarshaler(t) || t == durationType {
+		return []leafField{{path: path, typ: t}}
+	}
+	fields := jsonFields(t)
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var out []leafField
+	for _, k := range names {
+		out = append(out, leafFields(fields[k].Type, joinPath(path, k))...)
+	}
+	return out
+}
+
+func envNameForPath(path string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
+
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	out := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		out[name] = f
+	}
+	return out
+}
+
+func isCustomUnmarshaler(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(unmarshalerType)
+}
+
+func asStringMap(v any, path string) (map[string]any, error) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, nil
+	case map[any]any:
+		out := make(map[string]any, len(m))
+		for k, child := range m {
+			out[fmt.Sprint(k)] = child
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("%s: expect a mapping, got %T", displayPath(path), v)
+	}
+}
+
+// scalarString 把标量转为字符串（例如 YAML 中未加引号的 chain_id: 123），并展开 ${ENV}。
+func scalarString(v any, path string, lookupEnv func(string) (string, bool)) (string, error) {
+	switch x := v.(type) {
+	case string:
+		return expandEnv(x, path, lookupEnv)
+	case map[string]any, map[any]any, []any:
+		return "", fmt.Errorf("%s: expect a scalar, got %T", path, v)
+	default:
+		return fmt.Sprint(x), nil
+	}
+}
+
+// expandAll 递归展开任意结构中的字符串。
+func expandAll(v any, path string, lookupEnv func(string) (string, bool)) (any, error) {
+	switch x := v.(type) {
+	case string:
+		return expandEnv(x, path, lookupEnv)
+	case []any:
+		out := make([]any, 0, len(x))
+		for i, child := range x {
+			n, err := expandAll(child, fmt.Sprintf("%s[%d]", path, i), lookupEnv)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, n)
+		}
+		return out, nil
+	case map[string]any, map[any]any:
+		m, err := asStringMap(x, path)
+		if err != nil {
+			return nil, err
+		}
+		out := make(map[string]any, len(m))
+		for k, child := range m {
+			n, err := expandAll(child, joinPath(path, k), lookupEnv)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = n
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+// expandEnv 展开 ${VAR} 与 ${VAR:-default}；引用了未设置且无默认值的变量视为配置错误，
+// 避免 api_key 之类的字段在变量缺失时悄悄变成空串。
+func expandEnv(s, path string, lookupEnv func(string) (string, bool)) (string, error) {
+	var missing []string
+	out := envRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		sub := envRefPattern.FindStringSubmatch(ref)
+		if v, ok := lookupEnv(sub[1]); ok {
+			return v
+		}
+		if sub[2] != "" {
+			return sub[3]
+		}
+		missing = append(missing, sub[1])
+		return ""
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%s: environment variable %s is not set", path, strings.Join(missing, ", "))
+	}
+	return out, nil
+}
+
+func setPath(m map[string]any, parts []string, v any) {
+	for _, p := range parts[:len(parts)-1] {
+		child, ok := m[p].(map[string]any)
+		if !ok {
+			child = map[string]any{}
+			m[p] = child
+		}
+		m = child
+	}
+	m[parts[len(parts)-1]] = v
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "<root>"
+	}
+	return path
+}
+
+// parseNumber 按字段类型解析数字字符串：整数类型用 ParseInt/ParseUint，避免经 float64 转换后超过 2^53 的值丢失精度。
+func parseNumber(s string, k reflect.Kind) (json.Number, error) {
+	switch k {
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return "", err
+		}
+		return json.Number(strconv.FormatFloat(f, 'f', -1, 64)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return "", err
+		}
+		return json.Number(strconv.FormatUint(n, 10)), nil
+	default:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return "", err
+		}
+		return json.Number(strconv.FormatInt(n, 10)), nil
+	}
+}
+
+func parseDurationOrNanos(v string) (time.Duration, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, nil
+	}
+	// 优先按 5s/1m/1h 解析
+	if d, err := time.ParseDuration(v); err == nil {
+		return d, nil
+	}
+	// 兼容旧 JSON 的“纳秒整数”
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration: %q (expect e.g. 5s or nanoseconds int)", v)
+	}
+	return time.Duration(n), nil
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import "strconv"
+
+// FallbackPolicy 为数据源失败时 gauge 的处理方式（collector 通过 Metrics.Unavailable 触发）。
+type FallbackPolicy string
+
+const (
+	// FallbackZero 写入 0（旧行为：拿不到先返回固定值）
+	FallbackZero FallbackPolicy = "zero"
+	// FallbackKeepLast 保留上次成功值，并通过 biya_exporter_metric_age_seconds 暴露数据年龄；
+	// 保留时长受 series TTL 限制（兜底不刷新 series 的写入时间）
+	FallbackKeepLast FallbackPolicy = "keep_last"
+	// FallbackDrop 删除 series，面板与告警看到的是“无数据”而不是一个假值
+	FallbackDrop FallbackPolicy = "drop"
+)
+
+// metricAgeMetric 的 series 不落在 gauges 中，而是在输出时按 freshAt 实时计算，抓取间隔内年龄也会增长。
+const metricAgeMetric = "biya_exporter_metric_age_seconds"
+
+// SetFallbackPolicy 设置默认兜底策略与按指标名的覆盖（配置热加载时整体替换）。
+func (r *Registry) SetFallbackPolicy(def FallbackPolicy, perMetric map[string]FallbackPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallback = def
+	r.fallbackByMetric = make(map[string]FallbackPolicy, len(perMetric))
+	for k, v := range perMetric {
+		r.fallbackByMetric[k] = v
+	}
+}
+
+func (r *Registry) fallbackFor(metric string) FallbackPolicy {
+	if p, ok := r.fallbackByMetric[metric]; ok {
+		return p
+	}
+	return r.fallback
+}
+
+// unavailable 按 metric 的兜底策略处理数据源失败时的 series。
+func (r *Registry) unavailable(owner, metric string, labels map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.typ[metric] != TypeGauge {
+		return
+	}
+	seriesKey := r.seriesKeyLocked(metric, labels)
+	switch r.fallbackFor(metric) {
+	case FallbackZero:
+		if _, ok := r.gauges[metric]; !ok {
+			r.gauges[metric] = make(map[string]float64)
+		}
+		// 不更新 freshAt：0 不是上游给出的值
+		r.gauges[metric][seriesKey] = 0
+		r.touchLocked(owner, metric, seriesKey)
+		delete(r.held, metric)
+	case FallbackDrop:
+		delete(r.gauges[metric], seriesKey)
+		delete(r.owned[metric], seriesKey)
+		if len(r.gauges[metric]) == 0 {
+			delete(r.held, metric)
+		}
+	default:
+		if _, ok := r.gauges[metric][seriesKey]; ok {
+			r.held[metric] = true
+		}
+	}
+}
+
+// gaugeSeriesLocked 返回 gauge 指标的 seriesKey -> value；metric_age 为派生指标，按当前时间计算。
+func (r *Registry) gaugeSeriesLocked(metric string) map[string]float64 {
+	if metric != metricAgeMetric {
+		return r.gauges[metric]
+	}
+	now := r.now()
+	out := make(map[string]float64, len(r.held))
+	for m := range r.held {
+		// 保留的 series 可能已被 TTL/run 清理删除，此时没有可标注年龄的值
+		if len(r.gauges[m]) == 0 {
+			continue
+		}
+		age := 0.0
+		if t, ok := r.freshAt[m]; ok {
+			age = now.Sub(t).Seconds()
+		}
+		out["metric="+strconv.Quote(m)] = age
+	}
+	return out
+}
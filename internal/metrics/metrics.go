@@ -1,10 +1,18 @@
 package metrics
 
+import (
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
 // Metrics 统一收敛指标定义，避免 collectors 内零散拼接 metric 名称。
 // 注意：label 严格控制低基数；严禁把 address/tx_hash/contract 放入 label。
 type Metrics struct {
 	chainID string
 	reg     *Registry
+	// owner 非空时，经该视图写入的 series 记录归属（job 名），用于过期清理；见 Owned
+	owner string
 }
 
 // New 返回自研 registry 与 metrics facade。
@@ -14,36 +22,70 @@ func New(chainID, version, commit string) (*Registry, *Metrics) {
 	// declare metrics
 	reg.MustDeclare("biya_chain_head_block_height", TypeGauge, "Latest block height observed from the chain node.", []string{"chain_id"})
 	reg.MustDeclare("biya_chain_node_catching_up", TypeGauge, "Whether the node is catching up (1) or fully synced (0).", []string{"chain_id"})
-	reg.MustDeclare("biya_chain_block_time_seconds_avg", TypeGauge, "Average block time in seconds (EMA).", []string{"chain_id"})
-	reg.MustDeclare("biya_chain_tps_window", TypeGauge, "Approximate TPS over a rolling time window. May be mocked until explorer/indexer endpoints are ready.", []string{"chain_id"})
+	reg.MustDeclare("biya_chain_block_time_seconds_avg", TypeGauge, "Average block time in seconds over the last N followed blocks.", []string{"chain_id"})
+	reg.MustDeclare("biya_chain_tps_window", TypeGauge, "TPS over a rolling block-time window, computed from every block. Mocked only when blocks are unavailable.", []string{"chain_id"})
+	reg.MustDeclare("biya_chain_tps_block", TypeGauge, "TPS of the latest followed block (txs / interval since previous block).", []string{"chain_id"})
+	reg.MustDeclare("biya_chain_followed_block_height", TypeGauge, "Highest block height processed gap-free by the block follower.", []string{"chain_id"})
+	reg.MustDeclare("biya_chain_follower_lag_blocks", TypeGauge, "Blocks between the node head and the block follower's processed height.", []string{"chain_id"})
+	reg.MustDeclare("biya_chain_stream_events_total", TypeCounter, "Events received from the CometBFT WebSocket subscription since exporter start.", []string{"chain_id", "event"})
+	reg.MustDeclare("biya_chain_proposed_blocks_total", TypeCounter, "Blocks proposed per proposer consensus address since exporter start.", []string{"chain_id", "proposer"})
 	reg.MustDeclare("biya_chain_tx_confirm_time_seconds_avg", TypeGauge, "Average transaction confirmation time in seconds. May be mocked.", []string{"chain_id"})
-	reg.MustDeclare("biya_chain_block_gas_utilization_ratio_avg", TypeGauge, "Average gas utilization ratio (0-1). May be mocked.", []string{"chain_id"})
+	reg.MustDeclare("biya_chain_block_gas_utilization_ratio_avg", TypeGauge, "Average block gas utilization ratio (0-1) over the last N blocks. Mocked only when block results are unavailable.", []string{"chain_id"})
+	reg.MustDeclare("biya_chain_block_gas_utilization_ratio", TypeHistogram, "Per-block gas utilization ratio (gas_used/max_gas) distribution.", []string{"chain_id"})
 	reg.MustDeclare("biya_chain_mempool_pending_txs", TypeGauge, "Pending transactions in mempool. May be mocked.", []string{"chain_id"})
 	reg.MustDeclare("biya_chain_congestion_ratio", TypeGauge, "Congestion ratio (0-1). May be mocked.", []string{"chain_id"})
 
 	reg.MustDeclare("biya_stake_validators_total", TypeGauge, "Total validators returned by stake API.", []string{"chain_id"})
 	reg.MustDeclare("biya_stake_validators_bonded", TypeGauge, "Bonded validators count (status==bonded).", []string{"chain_id"})
 	reg.MustDeclare("biya_stake_validators_jailed", TypeGauge, "Jailed validators count.", []string{"chain_id"})
+	reg.MustDeclare("biya_stake_validators_by_status", TypeGauge, "Validators by staking status (bonded/unbonding/unbonded/unspecified).", []string{"chain_id", "status"})
+	reg.MustDeclare("biya_stake_validators_upstream_total", TypeGauge, "Validator count reported by the stake API pagination total.", []string{"chain_id"})
+	reg.MustDeclare("biya_stake_validators_total_mismatch", TypeGauge, "Validators ingested minus the stake API pagination total (0 when consistent; negative when pages were missed).", []string{"chain_id"})
 	reg.MustDeclare("biya_stake_validators_uptime_percentage_avg", TypeGauge, "Average uptime percentage across validators (aggregate).", []string{"chain_id"})
 	reg.MustDeclare("biya_stake_bonded_tokens", TypeGauge, "Bonded tokens from LCD staking pool (raw units).", []string{"chain_id"})
+	reg.MustDeclare("biya_stake_not_bonded_tokens", TypeGauge, "Not-bonded tokens from LCD staking pool (raw units).", []string{"chain_id"})
+	reg.MustDeclare("biya_stake_total_supply", TypeGauge, "Total supply of the bond denom from LCD bank module (raw units).", []string{"chain_id"})
+	reg.MustDeclare("biya_stake_unbonding_time_seconds", TypeGauge, "Unbonding time from LCD staking params (seconds).", nil)
+	reg.MustDeclare("biya_slashing_signed_blocks_window", TypeGauge, "SignedBlocksWindow from LCD slashing params.", nil)
+	reg.MustDeclare("biya_slashing_min_signed_per_window", TypeGauge, "MinSignedPerWindow from LCD slashing params (0-1).", nil)
+	reg.MustDeclare("biya_inflation_rate", TypeGauge, "Current annual inflation rate from LCD mint module (0-1).", nil)
+	reg.MustDeclare("biya_inflation_rate_max", TypeGauge, "InflationMax from LCD mint params (0-1).", nil)
+	reg.MustDeclare("biya_inflation_rate_min", TypeGauge, "InflationMin from LCD mint params (0-1).", nil)
 
 	reg.MustDeclare("biya_exporter_scrape_success", TypeGauge, "Whether a collector run succeeded (1) or failed (0).", []string{"source"})
 	reg.MustDeclare("biya_exporter_scrape_duration_seconds", TypeHistogram, "Collector run duration in seconds.", []string{"source"})
+	reg.MustDeclare("biya_exporter_scrape_skipped_total", TypeCounter, "Collector runs skipped because max_concurrent_runs runs were still in progress.", []string{"source"})
+	reg.MustDeclare("biya_exporter_scrape_timeout_total", TypeCounter, "Collector runs cancelled after exceeding the job timeout.", []string{"source"})
 	reg.MustDeclare("biya_exporter_build_info", TypeGauge, "Build info as a gauge with labels version/commit.", []string{"version", "commit"})
 	reg.MustDeclare("biya_exporter_source_up", TypeGauge, "Whether a concrete data source call is up (1) or down (0).", []string{"source"})
+	reg.MustDeclare("biya_exporter_source_circuit_state", TypeGauge, "Circuit breaker state per data source (0=closed, 1=half-open, 2=open).", []string{"source"})
+	reg.MustDeclare("biya_exporter_config_last_reload_success", TypeGauge, "Whether the last configuration reload attempt succeeded (1) or failed (0).", nil)
+	reg.MustDeclare("biya_exporter_config_last_reload_success_timestamp_seconds", TypeGauge, "Timestamp of the last successful configuration reload (unix seconds).", nil)
+	reg.MustDeclare("biya_exporter_metric_age_seconds", TypeGauge, "Seconds since the metric was last refreshed from its source; present only while the last value is being held after a source failure.", []string{"metric"})
+	reg.MustDeclare("biya_exporter_series_expired_total", TypeCounter, "Series removed from the registry because their owning collector stopped refreshing them.", []string{"reason"})
+	reg.MustDeclare("biya_exporter_upstream_requests_total", TypeCounter, "Outbound HTTP requests to upstream sources by templated endpoint and status code (code=error when no response).", []string{"source", "endpoint", "code"})
+	reg.MustDeclare("biya_exporter_upstream_request_duration_seconds", TypeHistogram, "Outbound HTTP request duration including reading the response body.", []string{"source", "endpoint", "code"})
+	reg.MustDeclare("biya_exporter_upstream_response_size_bytes", TypeHistogram, "Outbound HTTP response body size in bytes.", []string{"source", "endpoint"})
+	reg.MustDeclare("biya_exporter_upstream_request_errors_total", TypeCounter, "Outbound HTTP request failures by class (dns/connect/tls/timeout/connection/canceled/other).", []string{"source", "endpoint", "class"})
+	reg.MustDeclare("biya_exporter_push_sent_batches_total", TypeCounter, "Snapshots successfully pushed to a push target (remote write / OTLP).", []string{"target"})
+	reg.MustDeclare("biya_exporter_push_failed_requests_total", TypeCounter, "Push requests that failed with a retryable error (network, 5xx, 429).", []string{"target"})
+	reg.MustDeclare("biya_exporter_push_dropped_batches_total", TypeCounter, "Snapshots dropped before delivery (queue_full/rejected).", []string{"target", "reason"})
+	reg.MustDeclare("biya_exporter_push_queue_batches", TypeGauge, "Snapshots waiting in the in-memory push queue.", []string{"target"})
 
 	// ---- Metrics defined by METRICS.md (admin backend) ----
 	// 说明：
-	// - 当前 registry 为最小实现（counter 以“可写入的 counter 类型”方式输出，值通过 SetGauge 写入）
-	// - 拿不到的数据会在 collectors 侧兜底为固定值（通常为 0），避免 exporter 失效。
+	// - counter 只能通过 AddCounter/IncCounter 累加本次增量（SetGauge 写 counter 会被拒绝）
+	// - 数据源失败时按 metrics.fallback 兜底（置 0 / 保留上次值 / 删除 series），见 Metrics.Unavailable。
 	//
 	// 来源：仓库内 `METRICS.md`
 	reg.MustDeclare("biya_block_height", TypeGauge, "Current block height.", nil)
 	reg.MustDeclare("biya_block_time_seconds", TypeGauge, "Average block time (last 100 blocks).", nil)
-	reg.MustDeclare("biya_blocks_total", TypeCounter, "Total blocks produced.", nil)
+	reg.MustDeclare("biya_blocks_total", TypeCounter, "Total blocks produced (processed by the exporter since start).", nil)
 
 	reg.MustDeclare("biya_tx_total", TypeCounter, "Total transactions (success/failed).", []string{"status"})
 	reg.MustDeclare("biya_tx_24h_total", TypeGauge, "Transactions in last 24h.", nil)
+	reg.MustDeclare("biya_explorer_stream_messages_total", TypeCounter, "Messages received from the explorer streaming endpoints since exporter start.", []string{"stream"})
+	reg.MustDeclare("biya_explorer_transactions_streamed_total", TypeCounter, "Transactions received from the explorer transaction stream since exporter start (success/failed).", []string{"status"})
 	reg.MustDeclare("biya_tps_current", TypeGauge, "Current TPS.", nil)
 	reg.MustDeclare("biya_tps_24h_avg", TypeGauge, "24h average TPS.", nil)
 	reg.MustDeclare("biya_tx_success_rate", TypeGauge, "Transaction success rate (0-1).", nil)
@@ -66,6 +108,8 @@ func New(chainID, version, commit string) (*Registry, *Metrics) {
 	reg.MustDeclare("biya_node_sync_status", TypeGauge, "Node sync status (1=synced, 0=syncing).", []string{"node"})
 	reg.MustDeclare("biya_node_sync_height", TypeGauge, "Current node sync height.", []string{"node"})
 	reg.MustDeclare("biya_node_behind_blocks", TypeGauge, "Blocks behind latest.", []string{"node"})
+	reg.MustDeclare("biya_node_up", TypeGauge, "Whether the node RPC /status call succeeded (1) or failed (0).", []string{"node"})
+	reg.MustDeclare("biya_node_info", TypeGauge, "Node info from RPC /status (always 1); join on node for moniker/network.", []string{"node", "moniker", "network"})
 
 	reg.MustDeclare("biya_validators_total", TypeGauge, "Total validators (all created).", nil)
 	reg.MustDeclare("biya_validators_consensus", TypeGauge, "Validators participating in consensus (TOP N).", nil)
@@ -78,7 +122,10 @@ func New(chainID, version, commit string) (*Registry, *Metrics) {
 	reg.MustDeclare("biya_rewards_24h_total_byb", TypeGauge, "24h total rewards (BYB).", nil)
 	reg.MustDeclare("biya_apr_annual", TypeGauge, "Annual percentage rate (0-100).", nil)
 	reg.MustDeclare("biya_slashing_events_24h", TypeGauge, "Slashing events in 24h.", nil)
-	reg.MustDeclare("biya_slashing_events_total", TypeCounter, "Total slashing events by type.", []string{"type"})
+	reg.MustDeclare("biya_slashing_events_total", TypeCounter, "Total slashing events by type (each event counted once).", []string{"type"})
+	reg.MustDeclare("biya_slashing_high_water_mark_timestamp", TypeGauge, "Time up to which slashing events have been fully ingested (unix seconds).", nil)
+	reg.MustDeclare("biya_validator_slashing_events_total", TypeCounter, "Slashing events per validator and type (each event counted once).", []string{"address", "type"})
+	reg.MustDeclare("biya_validator_last_slash_timestamp", TypeGauge, "Time of the validator's most recent slashing event within the last 24h (unix seconds).", []string{"address"})
 
 	reg.MustDeclare("biya_validator_status", TypeGauge, "Validator status (1=active, 0=inactive, -1=jailed).", []string{"address", "moniker"})
 	reg.MustDeclare("biya_validator_stake_byb", TypeGauge, "Validator stake (BYB).", []string{"address", "moniker"})
@@ -98,17 +145,47 @@ func New(chainID, version, commit string) (*Registry, *Metrics) {
 	reg.MustDeclare("biya_voting_power_total", TypeGauge, "Total voting power.", nil)
 	reg.MustDeclare("biya_participation_rate_avg", TypeGauge, "Average participation rate.", nil)
 	reg.MustDeclare("biya_proposal_status", TypeGauge, "Proposal status (0-5 enum).", []string{"id", "title"})
-	reg.MustDeclare("biya_proposal_votes_yes", TypeGauge, "Yes votes.", []string{"id"})
-	reg.MustDeclare("biya_proposal_votes_no", TypeGauge, "No votes.", []string{"id"})
-	reg.MustDeclare("biya_proposal_votes_veto", TypeGauge, "NoWithVeto votes.", []string{"id"})
-	reg.MustDeclare("biya_proposal_votes_abstain", TypeGauge, "Abstain votes.", []string{"id"})
+	reg.MustDeclare("biya_proposal_votes_yes", TypeGauge, "Yes votes (BYB).", []string{"id"})
+	reg.MustDeclare("biya_proposal_votes_no", TypeGauge, "No votes (BYB).", []string{"id"})
+	reg.MustDeclare("biya_proposal_votes_veto", TypeGauge, "NoWithVeto votes (BYB).", []string{"id"})
+	reg.MustDeclare("biya_proposal_votes_abstain", TypeGauge, "Abstain votes (BYB).", []string{"id"})
+	reg.MustDeclare("biya_proposal_voting_end_timestamp", TypeGauge, "Proposal voting period end time (unix seconds).", []string{"id"})
+
+	// 时间类 histogram 同时维护 native histogram：protobuf 抓取时分辨率不依赖手选 bucket，text 抓取仍输出经典 bucket
+	reg.ConfigureHistogram("biya_exporter_scrape_duration_seconds", HistogramOpts{Buckets: defaultDurationBuckets, Native: true})
+	reg.ConfigureHistogram("biya_tx_confirm_time_seconds", HistogramOpts{Buckets: confirmTimeBuckets, Native: true})
+	reg.ConfigureHistogram("biya_exporter_upstream_request_duration_seconds", HistogramOpts{Buckets: defaultDurationBuckets, Native: true})
+	reg.ConfigureHistogram("biya_exporter_upstream_response_size_bytes", HistogramOpts{Buckets: responseSizeBuckets})
+
+	// 单实体指标只反映当前状态：验证人退出集合、moniker 改名、提案结束后，旧 series 在下一次成功 run 后删除
+	for _, metric := range []string{
+		"biya_validator_status",
+		"biya_validator_stake_byb",
+		"biya_validator_voting_power",
+		"biya_validator_commission_rate",
+		"biya_validator_blocks_proposed_total",
+		"biya_validator_blocks_missed_total",
+		"biya_validator_uptime_ratio",
+		"biya_validator_last_active_timestamp",
+		"biya_validator_rewards_24h_byb",
+		"biya_validator_jailed",
+		"biya_proposal_status",
+		"biya_proposal_votes_yes",
+		"biya_proposal_votes_no",
+		"biya_proposal_votes_veto",
+		"biya_proposal_votes_abstain",
+		"biya_proposal_voting_end_timestamp",
+		"biya_validator_last_slash_timestamp",
+	} {
+		reg.MarkRunScoped(metric)
+	}
 
 	// 关键指标默认置 0（缺失时也能看到 metric 存在；后续逐步对接接口字段）
-	reg.SetGauge("biya_tx_total", map[string]string{"status": "success"}, 0)
-	reg.SetGauge("biya_tx_total", map[string]string{"status": "failed"}, 0)
+	_ = reg.AddCounter("biya_tx_total", map[string]string{"status": "success"}, 0)
+	_ = reg.AddCounter("biya_tx_total", map[string]string{"status": "failed"}, 0)
 	reg.SetGauge("biya_block_height", nil, 0)
 	reg.SetGauge("biya_block_time_seconds", nil, 0)
-	reg.SetGauge("biya_blocks_total", nil, 0)
+	_ = reg.AddCounter("biya_blocks_total", nil, 0)
 	reg.SetGauge("biya_tx_24h_total", nil, 0)
 	reg.SetGauge("biya_tps_current", nil, 0)
 	reg.SetGauge("biya_tps_24h_avg", nil, 0)
@@ -135,21 +212,95 @@ func (m *Metrics) ChainID() string { return m.chainID }
 
 // ---- helpers for collectors ----
 
+// Owned 返回一个写入时标记归属 owner（job 名）的视图。collector 应通过它写指标，
+// 这样 scheduler 才能在 run 结束后清理该 collector 不再刷新的 series。
+func (m *Metrics) Owned(owner string) *Metrics {
+	v := *m
+	v.owner = owner
+	return &v
+}
+
+// SetSeriesTTL 设置带归属 series 的过期时间；<=0 关闭按时间过期。
+func (m *Metrics) SetSeriesTTL(ttl time.Duration) {
+	m.reg.SetSeriesTTL(ttl)
+}
+
+// ExpireOwned 在 owner 的一次 run 结束后清理其过期 series：
+// runOK 时删除本次 run（since 之后）未刷新的单实体 series；随后按 TTL（至少 minTTL）删除长时间未刷新的 series。
+func (m *Metrics) ExpireOwned(owner string, runOK bool, since time.Time, minTTL time.Duration) {
+	if runOK {
+		if n := m.reg.SweepRun(owner, since); n > 0 {
+			_ = m.reg.AddCounter("biya_exporter_series_expired_total", map[string]string{"reason": "run"}, float64(n))
+		}
+	}
+	if n := m.reg.ExpireStale(owner, minTTL, time.Now()); n > 0 {
+		_ = m.reg.AddCounter("biya_exporter_series_expired_total", map[string]string{"reason": "ttl"}, float64(n))
+	}
+}
+
+// DropOwned 删除 owner 写入的全部 series（job 被移除时调用）。
+func (m *Metrics) DropOwned(owner string) {
+	if n := m.reg.DropOwner(owner); n > 0 {
+		_ = m.reg.AddCounter("biya_exporter_series_expired_total", map[string]string{"reason": "removed"}, float64(n))
+	}
+}
+
 func (m *Metrics) SetGauge(metric string, labels map[string]string, v float64) {
-	m.reg.SetGauge(metric, labels, v)
+	m.reg.setGauge(m.owner, metric, labels, v)
 }
 
+// SetFallbackPolicy 设置数据源失败时的兜底策略，见 FallbackPolicy。
+func (m *Metrics) SetFallbackPolicy(def FallbackPolicy, perMetric map[string]FallbackPolicy) {
+	m.reg.SetFallbackPolicy(def, perMetric)
+}
+
+// Unavailable 表示本次拿不到 metric 的值：按配置置 0、保留上次成功值或删除 series。
+func (m *Metrics) Unavailable(metric string, labels map[string]string) {
+	m.reg.unavailable(m.owner, metric, labels)
+}
+
+// AddCounter 给 counter 累加 delta（必须 >= 0）；collector 应传入本次新增量而不是累计值。
+func (m *Metrics) AddCounter(metric string, labels map[string]string, delta float64) error {
+	return m.reg.addCounter(m.owner, metric, labels, delta)
+}
+
+// IncCounter 给 counter 加 1。
+func (m *Metrics) IncCounter(metric string, labels map[string]string) {
+	_ = m.reg.addCounter(m.owner, metric, labels, 1)
+}
+
+// defaultDurationBuckets 为 Prometheus 默认 buckets，作为 text 抓取时的经典 bucket。
+var defaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// responseSizeBuckets 为上游响应大小（字节）的经典 buckets：256B ~ 4MiB，按 4 倍递增。
+var responseSizeBuckets = []float64{256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304}
+
+// confirmTimeBuckets 为交易确认时间（BFT 下近似为出块间隔）的经典 buckets。
+var confirmTimeBuckets = []float64{1, 2, 3, 5, 10, 20, 30, 60, 120}
+
 func (m *Metrics) ObserveDuration(source string, seconds float64) {
-	// Prometheus 默认 buckets；这里硬编码一组常用 buckets
-	buckets := []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
-	m.reg.ObserveHistogram("biya_exporter_scrape_duration_seconds", map[string]string{"source": source}, buckets, seconds)
+	m.reg.ObserveHistogram("biya_exporter_scrape_duration_seconds", map[string]string{"source": source}, nil, seconds)
 }
 
-// ObserveHistogramMetric 提供给 collectors 使用的通用 histogram 观测封装。
+// ObserveHistogramMetric 提供给 collectors 使用的通用 histogram 观测封装；buckets 为 nil 时使用声明期配置的 buckets。
 func (m *Metrics) ObserveHistogramMetric(metric string, labels map[string]string, buckets []float64, v float64) {
-	m.reg.ObserveHistogram(metric, labels, buckets, v)
+	m.reg.observeHistogram(m.owner, metric, labels, buckets, v, nil, time.Time{})
+}
+
+// ObserveHistogramWithExemplar 在观测的同时记录 exemplar（例如 {height="123"}），OpenMetrics 抓取时随 bucket 输出。
+func (m *Metrics) ObserveHistogramWithExemplar(metric string, labels map[string]string, buckets []float64, v float64, exemplarLabels map[string]string, ts time.Time) {
+	m.reg.observeHistogram(m.owner, metric, labels, buckets, v, exemplarLabels, ts)
+}
+
+// Gather 返回当前全部指标的快照，见 Registry.Gather。
+func (m *Metrics) Gather() []*dto.MetricFamily {
+	return m.reg.Gather()
 }
 
 func (m *Metrics) RenderText() string {
 	return m.reg.RenderText()
 }
+
+func (m *Metrics) RenderOpenMetrics() string {
+	return m.reg.RenderOpenMetrics()
+}
@@ -0,0 +1,181 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// HistogramOpts 为某个 histogram 指标的声明期配置（见 Registry.ConfigureHistogram）。
+type HistogramOpts struct {
+	// Buckets 为经典 bucket 上界；Observe 时未传 buckets 则使用它。text/OpenMetrics 只能输出经典 bucket。
+	Buckets []float64
+	// Native 为 true 时同时维护 native（稀疏指数）histogram，通过 protobuf exposition 输出。
+	Native bool
+	// NativeBucketFactor 为相邻 bucket 边界之比的上限（>1），决定初始分辨率；<=1 时使用默认值 1.1（schema 3）。
+	NativeBucketFactor float64
+	// NativeMaxBuckets 为正/负 bucket 总数上限，超出时降低一级分辨率合并相邻 bucket；默认 160。
+	NativeMaxBuckets int
+	// NativeZeroThreshold 为零 bucket 的宽度（|v| <= 阈值计入零 bucket）；默认与 client_golang 一致。
+	NativeZeroThreshold float64
+}
+
+const (
+	defaultNativeBucketFactor  = 1.1
+	defaultNativeMaxBuckets    = 160
+	defaultNativeZeroThreshold = 2.938735877055719e-39 // 2^-128
+)
+
+// nativeHist 为 native histogram 的状态：bucket key i 覆盖 (base^(i-1), base^i]，base = 2^(2^-schema)。
+type nativeHist struct {
+	schema        int32
+	maxBuckets    int
+	zeroThreshold float64
+	zeroCount     uint64
+	positive      map[int]uint64
+	negative      map[int]uint64
+}
+
+func newNativeHist(opts HistogramOpts) *nativeHist {
+	factor := opts.NativeBucketFactor
+	if factor <= 1 {
+		factor = defaultNativeBucketFactor
+	}
+	h := &nativeHist{
+		schema:        pickNativeSchema(factor),
+		maxBuckets:    opts.NativeMaxBuckets,
+		zeroThreshold: opts.NativeZeroThreshold,
+		positive:      make(map[int]uint64),
+		negative:      make(map[int]uint64),
+	}
+	if h.maxBuckets <= 0 {
+		h.maxBuckets = defaultNativeMaxBuckets
+	}
+	if h.zeroThreshold <= 0 {
+		h.zeroThreshold = defaultNativeZeroThreshold
+	}
+	return h
+}
+
+// pickNativeSchema 选择边界之比不超过 factor 的最粗分辨率（与 client_golang 一致），结果限制在 [-4, 8]。
+func pickNativeSchema(factor float64) int32 {
+	floor := math.Floor(math.Log2(math.Log2(factor)))
+	switch {
+	case floor <= -8:
+		return 8
+	case floor >= 4:
+		return -4
+	default:
+		return -int32(floor)
+	}
+}
+
+func (h *nativeHist) observe(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+	if math.Abs(v) <= h.zeroThreshold {
+		h.zeroCount++
+		return
+	}
+	if v > 0 {
+		h.positive[nativeBucketKey(v, h.schema)]++
+	} else {
+		h.negative[nativeBucketKey(-v, h.schema)]++
+	}
+	for len(h.positive)+len(h.negative) > h.maxBuckets && h.schema > -4 {
+		h.reduceSchema()
+	}
+}
+
+// reduceSchema 把分辨率降低一级：schema s 的 bucket i 与 i+1（i 为奇数）合并为 schema s-1 的 bucket ceil(i/2)。
+func (h *nativeHist) reduceSchema() {
+	merge := func(m map[int]uint64) map[int]uint64 {
+		out := make(map[int]uint64, len(m)/2+1)
+		for k, c := range m {
+			out[(k+1)>>1] += c
+		}
+		return out
+	}
+	h.positive = merge(h.positive)
+	h.negative = merge(h.negative)
+	h.schema--
+}
+
+// nativeBucketKey 返回正数 v 所在 bucket 的 key（与 Prometheus client_golang 的算法一致，边界值落在较低的 bucket）。
+func nativeBucketKey(v float64, schema int32) int {
+	frac, exp := math.Frexp(v)
+	if schema > 0 {
+		bounds := nativeBounds(schema)
+		return sort.SearchFloat64s(bounds, frac) + (exp-1)*len(bounds)
+	}
+	key := exp
+	if frac == 0.5 {
+		key--
+	}
+	offset := (1 << -schema) - 1
+	return (key + offset) >> -schema
+}
+
+var nativeBoundsCache = map[int32][]float64{}
+
+// nativeBounds 返回 schema>0 时 [0.5, 1) 内的 2^schema 个 bucket 下界（frexp 的 frac 落在该区间）。
+// 只在 registry 锁内调用，因此缓存无需额外加锁。
+func nativeBounds(schema int32) []float64 {
+	if b, ok := nativeBoundsCache[schema]; ok {
+		return b
+	}
+	n := 1 << schema
+	b := make([]float64, n)
+	for j := 0; j < n; j++ {
+		b[j] = math.Exp2(float64(j)/float64(n)) / 2
+	}
+	nativeBoundsCache[schema] = b
+	return b
+}
+
+// nativeSpan 为一段连续 bucket：offset 相对上一段末尾（第一段相对 0），length 为 bucket 数。
+type nativeSpan struct {
+	offset int32
+	length uint32
+}
+
+// nativeSpansAndDeltas 把 bucket map 编码为 protobuf 使用的 span + delta 形式（delta 为与前一个 bucket 计数之差）。
+// 间隔不超过 2 的空 bucket 直接以 0 填入当前 span，比新开一段更省空间。
+func nativeSpansAndDeltas(buckets map[int]uint64) ([]nativeSpan, []int64) {
+	if len(buckets) == 0 {
+		return nil, nil
+	}
+	keys := make([]int, 0, len(buckets))
+	for k, c := range buckets {
+		if c > 0 {
+			keys = append(keys, k)
+		}
+	}
+	sort.Ints(keys)
+
+	var spans []nativeSpan
+	var deltas []int64
+	var prevCount int64
+	next := 0
+	for i, k := range keys {
+		gap := k - next
+		switch {
+		case i == 0:
+			spans = append(spans, nativeSpan{offset: int32(k)})
+		case gap <= 2:
+			for j := 0; j < gap; j++ {
+				deltas = append(deltas, -prevCount)
+				prevCount = 0
+				spans[len(spans)-1].length++
+			}
+		default:
+			spans = append(spans, nativeSpan{offset: int32(gap)})
+		}
+		c := int64(buckets[k])
+		deltas = append(deltas, c-prevCount)
+		prevCount = c
+		spans[len(spans)-1].length++
+		next = k + 1
+	}
+	return spans, deltas
+}
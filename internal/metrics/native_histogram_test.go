@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protodelim"
+)
+
+func TestNativeBucketKey(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		v      float64
+		schema int32
+		want   int
+	}{
+		// schema 0：bucket i 覆盖 (2^(i-1), 2^i]，边界值落在较低的 bucket
+		{1, 0, 0}, {2, 0, 1}, {3, 0, 2}, {4, 0, 2}, {0.5, 0, -1},
+		// schema 3：base = 2^(1/8) ≈ 1.0905
+		{1, 3, 0}, {1.05, 3, 1}, {1.1, 3, 2}, {2, 3, 8},
+		// schema -1：base = 4
+		{3, -1, 1}, {4, -1, 1}, {5, -1, 2},
+	}
+	for _, tc := range cases {
+		if got := nativeBucketKey(tc.v, tc.schema); got != tc.want {
+			t.Errorf("nativeBucketKey(%v, %d) = %d, want %d", tc.v, tc.schema, got, tc.want)
+		}
+	}
+}
+
+func TestNativeSpansAndDeltas(t *testing.T) {
+	t.Parallel()
+
+	// key 0..1 连续、key 3 与前一段间隔 1（以 0 填充）、key 10 另起一段
+	spans, deltas := nativeSpansAndDeltas(map[int]uint64{0: 2, 1: 3, 3: 1, 10: 4})
+	if got := fmt.Sprint(spans); got != "[{0 4} {6 1}]" {
+		t.Fatalf("spans = %s", got)
+	}
+	if got := fmt.Sprint(deltas); got != "[2 1 -3 1 3]" {
+		t.Fatalf("deltas = %s", got)
+	}
+}
+
+func TestNativeHist_ReducesSchemaWhenTooManyBuckets(t *testing.T) {
+	t.Parallel()
+
+	h := newNativeHist(HistogramOpts{Native: true, NativeMaxBuckets: 4})
+	for _, v := range []float64{1, 1.1, 1.2, 1.3, 1.5, 1.7, 2} {
+		h.observe(v)
+	}
+	if len(h.positive) > 4 {
+		t.Fatalf("buckets = %d, want <= 4", len(h.positive))
+	}
+	if h.schema >= pickNativeSchema(defaultNativeBucketFactor) {
+		t.Fatalf("schema should be reduced, got %d", h.schema)
+	}
+	var total uint64
+	for _, c := range h.positive {
+		total += c
+	}
+	if total != 7 {
+		t.Fatalf("observations lost while merging: %d", total)
+	}
+}
+
+func TestRegistry_WriteProtobufNativeHistogram(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	r.MustDeclare("d_seconds", TypeHistogram, "Duration.", []string{"source"})
+	r.ConfigureHistogram("d_seconds", HistogramOpts{Buckets: []float64{1}, Native: true, NativeBucketFactor: 2})
+	r.MustDeclare("c_total", TypeCounter, "Counter.", nil)
+	_ = r.IncCounter("c_total", nil)
+	for _, v := range []float64{0, 1, 3, 4} {
+		r.ObserveHistogram("d_seconds", map[string]string{"source": `a"b`}, nil, v)
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteProtobuf(&buf); err != nil {
+		t.Fatalf("WriteProtobuf err: %v", err)
+	}
+	families := map[string]*dto.MetricFamily{}
+	in := bufio.NewReader(&buf)
+	for {
+		mf := &dto.MetricFamily{}
+		if err := protodelim.UnmarshalFrom(in, mf); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			t.Fatalf("decode: %v", err)
+		}
+		families[mf.GetName()] = mf
+	}
+
+	c := families["c_total"].GetMetric()[0].GetCounter()
+	if c.GetValue() != 1 || c.GetCreatedTimestamp() == nil {
+		t.Fatalf("counter = %v", c)
+	}
+
+	m := families["d_seconds"].GetMetric()[0]
+	if got := m.GetLabel()[0].GetValue(); got != `a"b` {
+		t.Fatalf("label value = %q", got)
+	}
+	h := m.GetHistogram()
+	if h.GetSampleCount() != 4 || h.GetSchema() != 0 || h.GetZeroCount() != 1 {
+		t.Fatalf("histogram = %v", h)
+	}
+	// 经典 bucket（text 回退用）仍然存在
+	if len(h.GetBucket()) != 1 || h.GetBucket()[0].GetCumulativeCount() != 2 {
+		t.Fatalf("classic buckets = %v", h.GetBucket())
+	}
+	// 1 -> key 0，3、4 -> key 2：一个 span {0,3}，计数 1,0,2 -> delta 1,-1,2
+	if got := fmt.Sprint(h.GetPositiveDelta()); got != "[1 -1 2]" {
+		t.Fatalf("positive deltas = %s", got)
+	}
+	if sp := h.GetPositiveSpan(); len(sp) != 1 || sp[0].GetOffset() != 0 || sp[0].GetLength() != 3 {
+		t.Fatalf("positive spans = %v", sp)
+	}
+}
@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// WriteProtobuf 以 Prometheus protobuf exposition（长度前缀分隔的 io.prometheus.client.MetricFamily）输出全部指标。
+// 与 text 格式相比额外携带 native histogram、exemplar 与 created timestamp。
+func (r *Registry) WriteProtobuf(w io.Writer) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	for _, mf := range r.gatherLocked() {
+		if _, err := protodelim.MarshalTo(bw, mf); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Gather 返回当前全部指标的快照（结构与 protobuf exposition 相同），供 remote write 等推送通道序列化。
+// 返回值与 registry 不共享状态，调用方可以自由持有。
+func (r *Registry) Gather() []*dto.MetricFamily {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.gatherLocked()
+}
+
+func (r *Registry) gatherLocked() []*dto.MetricFamily {
+	metrics := make([]string, 0, len(r.typ))
+	for k := range r.typ {
+		metrics = append(metrics, k)
+	}
+	sort.Strings(metrics)
+
+	out := make([]*dto.MetricFamily, 0, len(metrics))
+	for _, metric := range metrics {
+		mf := r.metricFamilyLocked(metric)
+		if len(mf.Metric) == 0 {
+			// protobuf 格式要求每个 family 至少有一个 metric
+			continue
+		}
+		out = append(out, mf)
+	}
+	return out
+}
+
+func (r *Registry) metricFamilyLocked(metric string) *dto.MetricFamily {
+	mf := &dto.MetricFamily{Name: proto.String(metric)}
+	if help := r.help[metric]; help != "" {
+		mf.Help = proto.String(help)
+	}
+	switch r.typ[metric] {
+	case TypeGauge:
+		mf.Type = dto.MetricType_GAUGE.Enum()
+		series := r.gaugeSeriesLocked(metric)
+		for _, sk := range sortedMapKeys(series) {
+			mf.Metric = append(mf.Metric, &dto.Metric{
+				Label: parseLabelPairs(sk),
+				Gauge: &dto.Gauge{Value: proto.Float64(series[sk])},
+			})
+		}
+	case TypeCounter:
+		mf.Type = dto.MetricType_COUNTER.Enum()
+		series := r.counters[metric]
+		for _, sk := range sortedMapKeys(series) {
+			c := &dto.Counter{Value: proto.Float64(series[sk])}
+			if created, ok := r.counterCreated[metric][sk]; ok {
+				c.CreatedTimestamp = timestamppb.New(created)
+			}
+			mf.Metric = append(mf.Metric, &dto.Metric{Label: parseLabelPairs(sk), Counter: c})
+		}
+	case TypeHistogram:
+		mf.Type = dto.MetricType_HISTOGRAM.Enum()
+		series := r.histograms[metric]
+		for _, sk := range sortedMapKeys(series) {
+			mf.Metric = append(mf.Metric, &dto.Metric{Label: parseLabelPairs(sk), Histogram: histogramProto(series[sk])})
+		}
+	}
+	return mf
+}
+
+func histogramProto(h *histState) *dto.Histogram {
+	out := &dto.Histogram{
+		SampleCount:      proto.Uint64(h.count),
+		SampleSum:        proto.Float64(h.sum),
+		CreatedTimestamp: timestamppb.New(h.created),
+	}
+	var cumulative uint64
+	for i, b := range h.buckets {
+		cumulative += h.counts[i]
+		out.Bucket = append(out.Bucket, &dto.Bucket{
+			CumulativeCount: proto.Uint64(cumulative),
+			UpperBound:      proto.Float64(b),
+			Exemplar:        exemplarProto(h.exemplars[i]),
+		})
+	}
+	if h.native == nil {
+		return out
+	}
+
+	n := h.native
+	out.Schema = proto.Int32(n.schema)
+	out.ZeroThreshold = proto.Float64(n.zeroThreshold)
+	out.ZeroCount = proto.Uint64(n.zeroCount)
+	out.PositiveSpan, out.PositiveDelta = spansProto(nativeSpansAndDeltas(n.positive))
+	out.NegativeSpan, out.NegativeDelta = spansProto(nativeSpansAndDeltas(n.negative))
+	if len(out.PositiveSpan) == 0 && len(out.NegativeSpan) == 0 && n.zeroCount == 0 {
+		// 没有任何观测时放一个空 span，告诉 Prometheus 这是 native histogram（与 client_golang 的做法一致）
+		out.PositiveSpan = []*dto.BucketSpan{{Offset: proto.Int32(0), Length: proto.Uint32(0)}}
+	}
+	for _, e := range h.exemplars {
+		if pe := exemplarProto(e); pe != nil {
+			out.Exemplars = append(out.Exemplars, pe)
+		}
+	}
+	return out
+}
+
+func spansProto(spans []nativeSpan, deltas []int64) ([]*dto.BucketSpan, []int64) {
+	out := make([]*dto.BucketSpan, 0, len(spans))
+	for _, s := range spans {
+		out = append(out, &dto.BucketSpan{Offset: proto.Int32(s.offset), Length: proto.Uint32(s.length)})
+	}
+	return out, deltas
+}
+
+func exemplarProto(e *exemplar) *dto.Exemplar {
+	if e == nil {
+		return nil
+	}
+	return &dto.Exemplar{
+		Label:     parseLabelPairs(e.labels),
+		Value:     proto.Float64(e.value),
+		Timestamp: timestamppb.New(e.ts),
+	}
+}
+
+// parseLabelPairs 把 seriesKeyLocked 生成的 `k="v",...`（值为 Go 引号字符串）还原为 label 对。
+func parseLabelPairs(seriesKey string) []*dto.LabelPair {
+	var out []*dto.LabelPair
+	rest := seriesKey
+	for rest != "" {
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			break
+		}
+		name := rest[:eq]
+		quoted, err := strconv.QuotedPrefix(rest[eq+1:])
+		if err != nil {
+			break
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			break
+		}
+		out = append(out, &dto.LabelPair{Name: proto.String(name), Value: proto.String(value)})
+		rest = strings.TrimPrefix(rest[eq+1+len(quoted):], ",")
+	}
+	return out
+}
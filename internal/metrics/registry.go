@@ -3,14 +3,18 @@ package metrics
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
-// 这是一个“离线可编译”的最小 Prometheus text exposition 实现：
-// - 仅支持 gauge 与 histogram（足够覆盖 MVP）
+// 这是一个“离线可编译”的最小 Prometheus exposition 实现：
+// - 支持 gauge、counter 与 histogram（足够覆盖 MVP）
+// - 输出 Prometheus text 0.0.4（RenderText）、OpenMetrics 1.0（RenderOpenMetrics，含 exemplar / _created）
+//   与 protobuf（WriteProtobuf，额外携带 native histogram，见 protobuf.go）
 // - 仅用于 exporter 自身输出 /metrics
 // 后续如果你们恢复可用 Go Proxy，可再切回官方 prometheus/client_golang。
 
@@ -31,27 +35,78 @@ type Registry struct {
 	// metric -> seriesKey -> value
 	gauges map[string]map[string]float64
 
+	// metric -> seriesKey -> 累计值；只能通过 AddCounter/IncCounter 增加
+	counters map[string]map[string]float64
+	// metric -> seriesKey -> counter series 首次出现时间（OpenMetrics 的 _created）
+	counterCreated map[string]map[string]time.Time
+
 	// metric -> seriesKey -> histogram state
 	histograms map[string]map[string]*histState
+	// metric -> 声明期配置（默认经典 bucket、是否维护 native histogram）
+	histOpts map[string]HistogramOpts
 
 	// 记录 label key 的顺序，保证输出稳定
 	labelKeys map[string][]string
+
+	// metric -> seriesKey -> 归属与最后写入时间；只记录带 owner 写入的 series（见 Metrics.Owned），
+	// 未标记归属的 series（默认值、build_info 等）永不过期。
+	owned map[string]map[string]seriesMeta
+	// runScoped 中的指标按“所属 collector 一次成功 run 内未刷新即删除”处理（单实体指标：验证人、提案等）
+	runScoped map[string]bool
+	// seriesTTL > 0 时，超过该时间未刷新的带归属 series 会被删除
+	seriesTTL time.Duration
+
+	// 数据源失败时的兜底策略，见 fallback.go
+	fallback         FallbackPolicy
+	fallbackByMetric map[string]FallbackPolicy
+	// metric -> 最近一次写入真实值（非兜底）的时间
+	freshAt map[string]time.Time
+	// held 为当前保留着上次成功值的 metric，由 biya_exporter_metric_age_seconds 暴露其数据年龄
+	held map[string]bool
+	now  func() time.Time
+}
+
+type seriesMeta struct {
+	owner   string
+	updated time.Time
 }
 
 type histState struct {
 	buckets []float64
+	// counts[i] 为落在 (buckets[i-1], buckets[i]] 内的观测数（非累计），输出时再累加
 	counts  []uint64
 	sum     float64
 	count   uint64
+	created time.Time
+	// exemplars[i] 为 buckets[i] 最近一次带 exemplar 的观测；最后一个元素对应 +Inf
+	exemplars []*exemplar
+	// native 非空时同时维护 native histogram（仅 protobuf exposition 输出）
+	native *nativeHist
+}
+
+// exemplar 为某次观测附带的上下文（例如区块高度、tx hash），仅在 OpenMetrics 中输出。
+type exemplar struct {
+	labels string
+	value  float64
+	ts     time.Time
 }
 
 func NewRegistry() *Registry {
 	return &Registry{
-		help:       make(map[string]string),
-		typ:        make(map[string]Type),
-		gauges:     make(map[string]map[string]float64),
-		histograms: make(map[string]map[string]*histState),
-		labelKeys:  make(map[string][]string),
+		help:           make(map[string]string),
+		typ:            make(map[string]Type),
+		gauges:         make(map[string]map[string]float64),
+		counters:       make(map[string]map[string]float64),
+		counterCreated: make(map[string]map[string]time.Time),
+		histograms:     make(map[string]map[string]*histState),
+		histOpts:       make(map[string]HistogramOpts),
+		labelKeys:      make(map[string][]string),
+		owned:          make(map[string]map[string]seriesMeta),
+		runScoped:      make(map[string]bool),
+		fallback:       FallbackKeepLast,
+		freshAt:        make(map[string]time.Time),
+		held:           make(map[string]bool),
+		now:            time.Now,
 	}
 }
 
@@ -64,25 +119,108 @@ func (r *Registry) MustDeclare(metric string, t Type, help string, labelKeys []s
 	r.typ[metric] = t
 	r.help[metric] = help
 	r.labelKeys[metric] = append([]string(nil), labelKeys...)
-	if t == TypeGauge || t == TypeCounter {
+	if t == TypeGauge {
 		r.gauges[metric] = make(map[string]float64)
 	}
+	if t == TypeCounter {
+		r.counters[metric] = make(map[string]float64)
+	}
 	if t == TypeHistogram {
 		r.histograms[metric] = make(map[string]*histState)
 	}
 }
 
+// ConfigureHistogram 设置 histogram 指标的默认经典 bucket 与 native histogram 选项；
+// 需在首次 Observe 之前调用（已存在的 series 不受影响）。
+func (r *Registry) ConfigureHistogram(metric string, opts HistogramOpts) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	opts.Buckets = append([]float64(nil), opts.Buckets...)
+	r.histOpts[metric] = opts
+}
+
+// MarkRunScoped 声明 metric 为单实体指标：其 series 若在所属 collector 的一次成功 run 内没有被刷新，
+// SweepRun 会将其删除（例如验证人退出集合、moniker 改名后的旧 series）。
+func (r *Registry) MarkRunScoped(metric string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runScoped[metric] = true
+}
+
+// SetSeriesTTL 设置带归属 series 的最长保留时间；<=0 表示不按时间过期。
+func (r *Registry) SetSeriesTTL(ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seriesTTL = ttl
+}
+
+// SetGauge 写入 gauge 的当前值。counter 只能累加，对已声明为 counter 的指标调用会被忽略（请使用 AddCounter）。
 func (r *Registry) SetGauge(metric string, labels map[string]string, v float64) {
+	r.setGauge("", metric, labels, v)
+}
+
+func (r *Registry) setGauge(owner, metric string, labels map[string]string, v float64) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	if r.typ[metric] == TypeCounter {
+		return
+	}
 	seriesKey := r.seriesKeyLocked(metric, labels)
 	if _, ok := r.gauges[metric]; !ok {
 		r.gauges[metric] = make(map[string]float64)
 	}
 	r.gauges[metric][seriesKey] = v
+	r.touchLocked(owner, metric, seriesKey)
+	r.freshAt[metric] = r.now()
+	delete(r.held, metric)
+}
+
+// AddCounter 给 counter 累加 delta；delta 为负数或 NaN 时拒绝并返回错误（counter 必须单调递增，
+// 否则 increase()/rate() 会把下降误判为重置）。delta=0 可用于在首次出现前把 series 初始化为 0。
+func (r *Registry) AddCounter(metric string, labels map[string]string, delta float64) error {
+	return r.addCounter("", metric, labels, delta)
+}
+
+func (r *Registry) addCounter(owner, metric string, labels map[string]string, delta float64) error {
+	if delta < 0 || math.IsNaN(delta) {
+		return fmt.Errorf("counter %s: invalid delta %v", metric, delta)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t, ok := r.typ[metric]; ok && t != TypeCounter {
+		return fmt.Errorf("metric %s is declared as %s, not counter", metric, t)
+	}
+	seriesKey := r.seriesKeyLocked(metric, labels)
+	if _, ok := r.counters[metric]; !ok {
+		r.counters[metric] = make(map[string]float64)
+	}
+	if _, ok := r.counters[metric][seriesKey]; !ok {
+		if r.counterCreated[metric] == nil {
+			r.counterCreated[metric] = make(map[string]time.Time)
+		}
+		r.counterCreated[metric][seriesKey] = time.Now()
+	}
+	r.counters[metric][seriesKey] += delta
+	r.touchLocked(owner, metric, seriesKey)
+	return nil
+}
+
+// IncCounter 等价于 AddCounter(metric, labels, 1)。
+func (r *Registry) IncCounter(metric string, labels map[string]string) error {
+	return r.AddCounter(metric, labels, 1)
 }
 
 func (r *Registry) ObserveHistogram(metric string, labels map[string]string, buckets []float64, v float64) {
+	r.observeHistogram("", metric, labels, buckets, v, nil, time.Time{})
+}
+
+// ObserveHistogramWithExemplar 与 ObserveHistogram 相同，并把 exemplarLabels 作为该观测所在 bucket 的 exemplar
+// （每个 bucket 只保留最近一个）。OpenMetrics 限制 exemplar label 总长度不超过 128 个字符，超出时丢弃 exemplar。
+func (r *Registry) ObserveHistogramWithExemplar(metric string, labels map[string]string, buckets []float64, v float64, exemplarLabels map[string]string, ts time.Time) {
+	r.observeHistogram("", metric, labels, buckets, v, exemplarLabels, ts)
+}
+
+func (r *Registry) observeHistogram(owner, metric string, labels map[string]string, buckets []float64, v float64, exemplarLabels map[string]string, ts time.Time) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	seriesKey := r.seriesKeyLocked(metric, labels)
@@ -93,21 +231,136 @@ func (r *Registry) ObserveHistogram(metric string, labels map[string]string, buc
 	}
 	h := m[seriesKey]
 	if h == nil {
-		h = &histState{buckets: append([]float64(nil), buckets...), counts: make([]uint64, len(buckets))}
+		opts := r.histOpts[metric]
+		if buckets == nil {
+			buckets = opts.Buckets
+		}
+		h = &histState{
+			buckets:   append([]float64(nil), buckets...),
+			counts:    make([]uint64, len(buckets)),
+			created:   time.Now(),
+			exemplars: make([]*exemplar, len(buckets)+1),
+		}
+		if opts.Native {
+			h.native = newNativeHist(opts)
+		}
 		m[seriesKey] = h
 	}
+	r.touchLocked(owner, metric, seriesKey)
 
-	// update
+	// update：只计入第一个满足 v <= le 的 bucket，累计值在输出时计算
 	h.count++
 	h.sum += v
+	if h.native != nil {
+		h.native.observe(v)
+	}
+	idx := len(h.buckets)
 	for i, b := range h.buckets {
 		if v <= b {
 			h.counts[i]++
+			idx = i
+			break
+		}
+	}
+	if len(exemplarLabels) > 0 {
+		if el := formatExemplarLabels(exemplarLabels); el != "" {
+			if ts.IsZero() {
+				ts = time.Now()
+			}
+			h.exemplars[idx] = &exemplar{labels: el, value: v, ts: ts}
+		}
+	}
+}
+
+// formatExemplarLabels 按 key 排序格式化 exemplar label；超过 OpenMetrics 的 128 字符上限时返回空串。
+func formatExemplarLabels(labels map[string]string) string {
+	keys := sortedMapKeys(labels)
+	parts := make([]string, 0, len(keys))
+	n := 0
+	for _, k := range keys {
+		n += len(k) + len(labels[k])
+		parts = append(parts, fmt.Sprintf(`%s=%q`, k, labels[k]))
+	}
+	if n > 128 {
+		return ""
+	}
+	return strings.Join(parts, ",")
+}
+
+func (r *Registry) touchLocked(owner, metric, seriesKey string) {
+	if owner == "" {
+		return
+	}
+	m := r.owned[metric]
+	if m == nil {
+		m = make(map[string]seriesMeta)
+		r.owned[metric] = m
+	}
+	m[seriesKey] = seriesMeta{owner: owner, updated: time.Now()}
+}
+
+// SweepRun 删除 owner 写入、属于 MarkRunScoped 指标且自 since（本次 run 开始时间）以来未刷新的 series，返回删除数。
+func (r *Registry) SweepRun(owner string, since time.Time) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.deleteLocked(func(metric string, meta seriesMeta) bool {
+		return r.runScoped[metric] && meta.owner == owner && meta.updated.Before(since)
+	})
+}
+
+// ExpireStale 删除 owner 写入且超过 TTL 未刷新的 series，返回删除数。
+// 实际 TTL 取 max(series TTL, minTTL)：调用方按 job 间隔传入 minTTL，避免低频 job 的 series 在两次 run 之间被误删。
+func (r *Registry) ExpireStale(owner string, minTTL time.Duration, now time.Time) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.seriesTTL <= 0 {
+		return 0
+	}
+	deadline := now.Add(-max(r.seriesTTL, minTTL))
+	return r.deleteLocked(func(_ string, meta seriesMeta) bool {
+		return meta.owner == owner && meta.updated.Before(deadline)
+	})
+}
+
+// DropOwner 删除 owner 写入的全部 series（job 被移除时调用），返回删除数。
+func (r *Registry) DropOwner(owner string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.deleteLocked(func(_ string, meta seriesMeta) bool {
+		return meta.owner == owner
+	})
+}
+
+func (r *Registry) deleteLocked(expired func(metric string, meta seriesMeta) bool) int {
+	n := 0
+	for metric, series := range r.owned {
+		for sk, meta := range series {
+			if !expired(metric, meta) {
+				continue
+			}
+			delete(series, sk)
+			delete(r.gauges[metric], sk)
+			delete(r.counters[metric], sk)
+			delete(r.counterCreated[metric], sk)
+			delete(r.histograms[metric], sk)
+			n++
 		}
 	}
+	return n
 }
 
+// RenderText 输出 Prometheus text exposition 0.0.4。
 func (r *Registry) RenderText() string {
+	return r.render(false)
+}
+
+// RenderOpenMetrics 输出 OpenMetrics 1.0 text：counter family 去掉 _total 后缀并输出 _total/_created 样本，
+// 按名称后缀补充 # UNIT，histogram bucket 附带 exemplar，末尾以 # EOF 结束。
+func (r *Registry) RenderOpenMetrics() string {
+	return r.render(true)
+}
+
+func (r *Registry) render(om bool) string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -121,101 +374,112 @@ func (r *Registry) RenderText() string {
 	for _, metric := range metrics {
 		help := r.help[metric]
 		t := r.typ[metric]
+		family := metric
+		if om && t == TypeCounter {
+			family = strings.TrimSuffix(metric, "_total")
+		}
 		if help != "" {
 			buf.WriteString("# HELP ")
-			buf.WriteString(metric)
+			buf.WriteString(family)
 			buf.WriteString(" ")
-			buf.WriteString(escapeHelp(help))
+			if om {
+				buf.WriteString(escapeOpenMetrics(help))
+			} else {
+				buf.WriteString(escapeHelp(help))
+			}
 			buf.WriteString("\n")
 		}
 		buf.WriteString("# TYPE ")
-		buf.WriteString(metric)
+		buf.WriteString(family)
 		buf.WriteString(" ")
 		buf.WriteString(string(t))
 		buf.WriteString("\n")
+		if om {
+			if unit := metricUnit(family); unit != "" {
+				buf.WriteString("# UNIT ")
+				buf.WriteString(family)
+				buf.WriteString(" ")
+				buf.WriteString(unit)
+				buf.WriteString("\n")
+			}
+		}
 
 		switch t {
 		case TypeGauge:
-			fallthrough
+			series := r.gaugeSeriesLocked(metric)
+			for _, sk := range sortedMapKeys(series) {
+				writeSample(&buf, metric, sk, "", formatFloat(series[sk]))
+			}
 		case TypeCounter:
-			series := r.gauges[metric]
-			keys := sortedMapKeys(series)
-			for _, sk := range keys {
-				buf.WriteString(metric)
-				if sk != "" {
-					buf.WriteString("{")
-					buf.WriteString(sk)
-					buf.WriteString("}")
+			series := r.counters[metric]
+			for _, sk := range sortedMapKeys(series) {
+				if !om {
+					writeSample(&buf, metric, sk, "", formatFloat(series[sk]))
+					continue
+				}
+				writeSample(&buf, family+"_total", sk, "", formatFloat(series[sk]))
+				if created, ok := r.counterCreated[metric][sk]; ok {
+					writeSample(&buf, family+"_created", sk, "", formatTimestamp(created))
 				}
-				buf.WriteString(" ")
-				buf.WriteString(formatFloat(series[sk]))
-				buf.WriteString("\n")
 			}
 		case TypeHistogram:
 			series := r.histograms[metric]
-			keys := sortedMapKeys(series)
-			for _, sk := range keys {
+			for _, sk := range sortedMapKeys(series) {
 				h := series[sk]
-				// bucket/count lines use suffix _bucket
 				var cumulative uint64
 				for i, b := range h.buckets {
 					cumulative += h.counts[i]
-					buf.WriteString(metric)
-					buf.WriteString("_bucket")
-					buf.WriteString("{")
-					if sk != "" {
-						buf.WriteString(sk)
-						buf.WriteString(",")
-					}
-					buf.WriteString(`le="`)
-					buf.WriteString(formatFloat(b))
-					buf.WriteString(`"}`)
-					buf.WriteString(" ")
-					buf.WriteString(strconv.FormatUint(cumulative, 10))
-					buf.WriteString("\n")
-				}
-				// +Inf bucket
-				buf.WriteString(metric)
-				buf.WriteString("_bucket")
-				buf.WriteString("{")
-				if sk != "" {
-					buf.WriteString(sk)
-					buf.WriteString(",")
+					writeSample(&buf, metric+"_bucket", sk, `le="`+formatFloat(b)+`"`, strconv.FormatUint(cumulative, 10)+exemplarSuffix(om, h.exemplars[i]))
 				}
-				buf.WriteString(`le="+Inf"}`)
-				buf.WriteString(" ")
-				buf.WriteString(strconv.FormatUint(h.count, 10))
-				buf.WriteString("\n")
-
-				// sum
-				buf.WriteString(metric)
-				buf.WriteString("_sum")
-				if sk != "" {
-					buf.WriteString("{")
-					buf.WriteString(sk)
-					buf.WriteString("}")
+				writeSample(&buf, metric+"_bucket", sk, `le="+Inf"`, strconv.FormatUint(h.count, 10)+exemplarSuffix(om, h.exemplars[len(h.buckets)]))
+				writeSample(&buf, metric+"_sum", sk, "", formatFloat(h.sum))
+				writeSample(&buf, metric+"_count", sk, "", strconv.FormatUint(h.count, 10))
+				if om {
+					writeSample(&buf, metric+"_created", sk, "", formatTimestamp(h.created))
 				}
-				buf.WriteString(" ")
-				buf.WriteString(formatFloat(h.sum))
-				buf.WriteString("\n")
-
-				// count
-				buf.WriteString(metric)
-				buf.WriteString("_count")
-				if sk != "" {
-					buf.WriteString("{")
-					buf.WriteString(sk)
-					buf.WriteString("}")
-				}
-				buf.WriteString(" ")
-				buf.WriteString(strconv.FormatUint(h.count, 10))
-				buf.WriteString("\n")
 			}
 		}
 	}
+	if om {
+		buf.WriteString("# EOF\n")
+	}
 	return buf.String()
 }
 
+// writeSample 输出一行样本；extra 为追加在 series label 之后的 label（例如 histogram 的 le）。
+func writeSample(buf *bytes.Buffer, name, seriesKey, extra, value string) {
+	buf.WriteString(name)
+	if seriesKey != "" || extra != "" {
+		buf.WriteString("{")
+		buf.WriteString(seriesKey)
+		if seriesKey != "" && extra != "" {
+			buf.WriteString(",")
+		}
+		buf.WriteString(extra)
+		buf.WriteString("}")
+	}
+	buf.WriteString(" ")
+	buf.WriteString(value)
+	buf.WriteString("\n")
+}
+
+func exemplarSuffix(om bool, e *exemplar) string {
+	if !om || e == nil {
+		return ""
+	}
+	return " # {" + e.labels + "} " + formatFloat(e.value) + " " + formatTimestamp(e.ts)
+}
+
+// metricUnit 按 OpenMetrics 约定从 family 名称后缀推断单位（名称必须以 _<unit> 结尾才能声明 UNIT）。
+func metricUnit(family string) string {
+	for _, unit := range []string{"seconds", "bytes", "ratio"} {
+		if strings.HasSuffix(family, "_"+unit) {
+			return unit
+		}
+	}
+	return ""
+}
+
 func (r *Registry) seriesKeyLocked(metric string, labels map[string]string) string {
 	if labels == nil || len(labels) == 0 {
 		return ""
@@ -238,6 +502,11 @@ func (r *Registry) seriesKeyLocked(metric string, labels map[string]string) stri
 	return strings.Join(parts, ",")
 }
 
+// escapeOpenMetrics 按 OpenMetrics 规范转义 HELP 文本中的反斜杠、换行与双引号。
+func escapeOpenMetrics(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
+
 func escapeHelp(s string) string {
 	// Prometheus HELP 允许任意 UTF-8；这里只做最小替换
 	return strings.ReplaceAll(s, "\n", " ")
@@ -255,3 +524,8 @@ func sortedMapKeys[T any](m map[string]T) []string {
 func formatFloat(v float64) string {
 	return strconv.FormatFloat(v, 'f', -1, 64)
 }
+
+// formatTimestamp 输出 unix 秒（毫秒精度），用于 _created 与 exemplar 时间戳。
+func formatTimestamp(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixMilli())/1000, 'f', 3, 64)
+}
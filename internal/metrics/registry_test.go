@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistry_CounterAccumulatesAndRejectsNegativeDelta(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	r.MustDeclare("x_total", TypeCounter, "x", []string{"status"})
+	labels := map[string]string{"status": "ok"}
+
+	if err := r.AddCounter("x_total", labels, 2); err != nil {
+		t.Fatalf("AddCounter err: %v", err)
+	}
+	if err := r.IncCounter("x_total", labels); err != nil {
+		t.Fatalf("IncCounter err: %v", err)
+	}
+	if err := r.AddCounter("x_total", labels, -1); err == nil {
+		t.Fatalf("negative delta should be rejected")
+	}
+	// counter 不能被 SetGauge 覆盖
+	r.SetGauge("x_total", labels, 0)
+
+	if out := r.RenderText(); !strings.Contains(out, "\nx_total{status=\"ok\"} 3\n") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
+
+func TestRegistry_AddCounterRejectsNonCounterMetric(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	r.MustDeclare("g", TypeGauge, "g", nil)
+	if err := r.AddCounter("g", nil, 1); err == nil {
+		t.Fatalf("AddCounter on gauge should fail")
+	}
+}
+
+func TestMetrics_OwnedSeriesExpire(t *testing.T) {
+	t.Parallel()
+
+	reg, m := New("biya", "dev", "none")
+	reg.SetSeriesTTL(time.Minute)
+	stake := m.Owned("realtime_stake")
+	other := m.Owned("other")
+
+	start := time.Now()
+	stake.SetGauge("biya_validator_status", map[string]string{"address": "op1", "moniker": "old"}, 1)
+	stake.SetGauge("biya_exporter_source_up", map[string]string{"source": "stake_validators"}, 1)
+	other.SetGauge("biya_validator_status", map[string]string{"address": "op2", "moniker": "b"}, 1)
+
+	// 第二次 run：op1 改名为 new
+	time.Sleep(time.Millisecond)
+	second := time.Now()
+	stake.SetGauge("biya_validator_status", map[string]string{"address": "op1", "moniker": "new"}, 1)
+	m.ExpireOwned("realtime_stake", true, second, 0)
+
+	out := reg.RenderText()
+	if strings.Contains(out, `moniker="old"`) {
+		t.Fatalf("renamed validator series should be swept:\n%s", out)
+	}
+	for _, want := range []string{
+		"\nbiya_validator_status{address=\"op1\",moniker=\"new\"} 1\n",
+		// 非单实体指标不随 run 清理；其它 owner 的 series 不受影响
+		"\nbiya_exporter_source_up{source=\"stake_validators\"} 1\n",
+		"\nbiya_validator_status{address=\"op2\",moniker=\"b\"} 1\n",
+		"\nbiya_exporter_series_expired_total{reason=\"run\"} 1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+
+	// TTL：超过 TTL 未刷新的带归属 series 删除；未标记归属的 series（build_info）保留
+	if n := reg.ExpireStale("realtime_stake", 0, start.Add(2*time.Minute)); n != 2 {
+		t.Fatalf("expired = %d, want 2", n)
+	}
+	m.DropOwned("other")
+	out = reg.RenderText()
+	if strings.Contains(out, "biya_validator_status{") || strings.Contains(out, `source="stake_validators"`) {
+		t.Fatalf("expired series still rendered:\n%s", out)
+	}
+	if !strings.Contains(out, "\nbiya_exporter_build_info{") {
+		t.Fatalf("unowned series should never expire:\n%s", out)
+	}
+}
+
+func TestRegistry_OpenMetricsHistogramExemplar(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	r.MustDeclare("confirm_time_seconds", TypeHistogram, "Confirm time.", nil)
+	at := time.Unix(1735689600, 0)
+	r.ObserveHistogram("confirm_time_seconds", nil, []float64{1, 5}, 0.5)
+	r.ObserveHistogramWithExemplar("confirm_time_seconds", nil, []float64{1, 5}, 3, map[string]string{"height": "42"}, at)
+	r.ObserveHistogram("confirm_time_seconds", nil, []float64{1, 5}, 9)
+
+	text := r.RenderText()
+	for _, want := range []string{
+		"\nconfirm_time_seconds_bucket{le=\"1\"} 1\n",
+		"\nconfirm_time_seconds_bucket{le=\"5\"} 2\n",
+		"\nconfirm_time_seconds_bucket{le=\"+Inf\"} 3\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected %q in text output:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "# {") {
+		t.Fatalf("exemplars must not appear in text 0.0.4 output:\n%s", text)
+	}
+
+	om := r.RenderOpenMetrics()
+	for _, want := range []string{
+		"# UNIT confirm_time_seconds seconds\n",
+		"\nconfirm_time_seconds_bucket{le=\"5\"} 2 # {height=\"42\"} 3 1735689600.000\n",
+		"\nconfirm_time_seconds_created ",
+	} {
+		if !strings.Contains(om, want) {
+			t.Fatalf("expected %q in openmetrics output:\n%s", want, om)
+		}
+	}
+}
+
+func TestRegistry_KeepLastReportsLiveAge(t *testing.T) {
+	t.Parallel()
+
+	_, m := New("biya", "dev", "none")
+	now := time.Unix(1735689600, 0)
+	m.reg.now = func() time.Time { return now }
+
+	m.SetGauge("biya_validators_total", nil, 42)
+	now = now.Add(30 * time.Second)
+	m.Unavailable("biya_validators_total", nil)
+	// 从未有过值的 series 没有可保留的值
+	m.Unavailable("biya_staked_total_byb", nil)
+
+	now = now.Add(15 * time.Second)
+	out := m.RenderText()
+	for _, want := range []string{
+		"\nbiya_validators_total 42\n",
+		"\nbiya_exporter_metric_age_seconds{metric=\"biya_validators_total\"} 45\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "biya_staked_total_byb\"}") || strings.Contains(out, "\nbiya_staked_total_byb ") {
+		t.Fatalf("missing series should stay missing:\n%s", out)
+	}
+}
@@ -0,0 +1,224 @@
+package push
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/config"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+// otlpScope 为 instrumentation scope 名称（OTLP 要求每批指标归属一个 scope）。
+const otlpScope = "github.com/biya-coin/biya-dex-backend-exporter"
+
+// OTLPExporter 周期性把 registry 快照转换为 OTLP/HTTP metrics（protobuf）推送到 collector：
+// gauge -> Gauge，counter -> 单调累计 Sum，histogram -> 显式 bucket Histogram（累计 temporality）。
+// 指标名保持 biya_* 原样，OTel 管道与 Prometheus 抓取看到的是同一套数据。
+type OTLPExporter struct {
+	pusher
+	resource *resourcepb.Resource
+	version  string
+	gzip     bool
+	now      func() time.Time
+}
+
+func NewOTLPExporter(log *slog.Logger, m *metrics.Metrics, cfg config.OTLPConfig, version, commit string) *OTLPExporter {
+	e := &OTLPExporter{
+		pusher: pusher{
+			log:          log,
+			m:            m,
+			http:         &http.Client{Timeout: cfg.Timeout},
+			protocol:     "otlp",
+			contentType:  "application/x-protobuf",
+			extraHeaders: map[string]string{},
+			targets:      []*target{{name: targetName("", cfg.Endpoint), url: cfg.Endpoint, headers: cfg.Headers}},
+			queueSize:    cfg.QueueSize,
+			maxRetries:   cfg.MaxRetries,
+			minBackoff:   cfg.MinBackoff,
+			maxBackoff:   cfg.MaxBackoff,
+		},
+		version: version,
+		gzip:    cfg.Compression != "none",
+		now:     time.Now,
+	}
+	if e.gzip {
+		e.extraHeaders["Content-Encoding"] = "gzip"
+	}
+
+	// 内置属性优先，额外属性不能覆盖，避免同一 exporter 在管道里被误认成另一个实例
+	attrs := map[string]string{
+		"service.name":    "biya-exporter",
+		"service.version": version,
+		"biya.chain_id":   m.ChainID(),
+		"biya.commit":     commit,
+	}
+	for k, v := range cfg.ResourceAttributes {
+		if _, ok := attrs[k]; !ok {
+			attrs[k] = v
+		}
+	}
+	e.resource = &resourcepb.Resource{Attributes: otlpAttributes(attrs)}
+	return e
+}
+
+// Run 生成一次快照并尝试清空队列；失败只体现在 source_up 与 biya_exporter_push_* 指标上（见 RemoteWriter.Run）。
+func (e *OTLPExporter) Run(ctx context.Context) error {
+	// MetricsData 与 ExportMetricsServiceRequest 的 wire 格式相同（字段 1 均为 repeated ResourceMetrics），
+	// 直接编码 MetricsData 可以避免引入 collector 包带来的 gRPC 依赖。
+	req := &metricspb.MetricsData{ResourceMetrics: []*metricspb.ResourceMetrics{{
+		Resource: e.resource,
+		ScopeMetrics: []*metricspb.ScopeMetrics{{
+			Scope:   &commonpb.InstrumentationScope{Name: otlpScope, Version: e.version},
+			Metrics: otlpMetrics(e.m.Gather(), e.now()),
+		}},
+	}}}
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if e.gzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, _ = zw.Write(body)
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		body = buf.Bytes()
+	}
+	e.enqueue(body)
+	e.flush(ctx)
+	return ctx.Err()
+}
+
+func otlpMetrics(families []*dto.MetricFamily, now time.Time) []*metricspb.Metric {
+	ts := uint64(now.UnixNano())
+	out := make([]*metricspb.Metric, 0, len(families))
+	for _, mf := range families {
+		om := &metricspb.Metric{Name: mf.GetName(), Description: mf.GetHelp(), Unit: otlpUnit(mf.GetName())}
+		switch mf.GetType() {
+		case dto.MetricType_GAUGE:
+			g := &metricspb.Gauge{}
+			for _, m := range mf.GetMetric() {
+				g.DataPoints = append(g.DataPoints, &metricspb.NumberDataPoint{
+					Attributes:   otlpLabelAttributes(m.GetLabel()),
+					TimeUnixNano: ts,
+					Value:        &metricspb.NumberDataPoint_AsDouble{AsDouble: m.GetGauge().GetValue()},
+				})
+			}
+			om.Data = &metricspb.Metric_Gauge{Gauge: g}
+		case dto.MetricType_COUNTER:
+			sum := &metricspb.Sum{
+				AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+				IsMonotonic:            true,
+			}
+			for _, m := range mf.GetMetric() {
+				c := m.GetCounter()
+				sum.DataPoints = append(sum.DataPoints, &metricspb.NumberDataPoint{
+					Attributes:        otlpLabelAttributes(m.GetLabel()),
+					StartTimeUnixNano: unixNano(c.GetCreatedTimestamp().AsTime()),
+					TimeUnixNano:      ts,
+					Value:             &metricspb.NumberDataPoint_AsDouble{AsDouble: c.GetValue()},
+				})
+			}
+			om.Data = &metricspb.Metric_Sum{Sum: sum}
+		case dto.MetricType_HISTOGRAM:
+			hist := &metricspb.Histogram{AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE}
+			for _, m := range mf.GetMetric() {
+				hist.DataPoints = append(hist.DataPoints, otlpHistogramPoint(m, ts))
+			}
+			om.Data = &metricspb.Metric_Histogram{Histogram: hist}
+		default:
+			continue
+		}
+		out = append(out, om)
+	}
+	return out
+}
+
+// otlpHistogramPoint 把累计 bucket 转为 OTLP 的逐 bucket 计数：len(BucketCounts) = len(ExplicitBounds)+1，最后一个为 +Inf bucket。
+func otlpHistogramPoint(m *dto.Metric, ts uint64) *metricspb.HistogramDataPoint {
+	h := m.GetHistogram()
+	sum := h.GetSampleSum()
+	p := &metricspb.HistogramDataPoint{
+		Attributes:        otlpLabelAttributes(m.GetLabel()),
+		StartTimeUnixNano: unixNano(h.GetCreatedTimestamp().AsTime()),
+		TimeUnixNano:      ts,
+		Count:             h.GetSampleCount(),
+		Sum:               &sum,
+	}
+	var prev uint64
+	for _, b := range h.GetBucket() {
+		p.ExplicitBounds = append(p.ExplicitBounds, b.GetUpperBound())
+		p.BucketCounts = append(p.BucketCounts, b.GetCumulativeCount()-prev)
+		prev = b.GetCumulativeCount()
+		if e := b.GetExemplar(); e != nil {
+			p.Exemplars = append(p.Exemplars, &metricspb.Exemplar{
+				FilteredAttributes: otlpLabelAttributes(e.GetLabel()),
+				TimeUnixNano:       unixNano(e.GetTimestamp().AsTime()),
+				Value:              &metricspb.Exemplar_AsDouble{AsDouble: e.GetValue()},
+			})
+		}
+	}
+	p.BucketCounts = append(p.BucketCounts, h.GetSampleCount()-prev)
+	return p
+}
+
+func otlpLabelAttributes(labels []*dto.LabelPair) []*commonpb.KeyValue {
+	out := make([]*commonpb.KeyValue, 0, len(labels))
+	for _, lp := range labels {
+		out = append(out, otlpString(lp.GetName(), lp.GetValue()))
+	}
+	return out
+}
+
+func otlpAttributes(attrs map[string]string) []*commonpb.KeyValue {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]*commonpb.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, otlpString(k, attrs[k]))
+	}
+	return out
+}
+
+func otlpString(k, v string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: k, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}}
+}
+
+// otlpUnit 按指标名后缀推导 UCUM 单位（与 OpenMetrics 的 # UNIT 口径一致）。
+func otlpUnit(name string) string {
+	name = strings.TrimSuffix(name, "_total")
+	switch {
+	case strings.HasSuffix(name, "_seconds"):
+		return "s"
+	case strings.HasSuffix(name, "_bytes"):
+		return "By"
+	case strings.HasSuffix(name, "_ratio"):
+		return "1"
+	default:
+		return ""
+	}
+}
+
+// unixNano 把零值时间（未知的起始时间）编码为 0，符合 OTLP 对缺省 start time 的约定。
+func unixNano(t time.Time) uint64 {
+	if t.IsZero() || t.Unix() <= 0 {
+		return 0
+	}
+	return uint64(t.UnixNano())
+}
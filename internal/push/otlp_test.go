@@ -0,0 +1,112 @@
+package push
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/config"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+func TestOTLPExporter_TranslatesRegistry(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var got *metricspb.MetricsData
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/metrics" || r.Header.Get("Content-Type") != "application/x-protobuf" || r.Header.Get("Content-Encoding") != "gzip" {
+			t.Errorf("unexpected request %s %v", r.URL.Path, r.Header)
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Errorf("gzip: %v", err)
+			return
+		}
+		b, _ := io.ReadAll(zr)
+		var md metricspb.MetricsData
+		if err := proto.Unmarshal(b, &md); err != nil {
+			t.Errorf("unmarshal: %v", err)
+		}
+		mu.Lock()
+		got = &md
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	_, m := metrics.New("biya-1", "v1.2.3", "abc123")
+	m.SetGauge("biya_node_up", map[string]string{"node": "sentry-1"}, 1)
+	m.IncCounter("biya_tx_total", map[string]string{"status": "success"})
+	m.ObserveHistogramWithExemplar("biya_tx_confirm_time_seconds", nil, nil, 2.5, map[string]string{"height": "42"}, time.Unix(1700000000, 0))
+	m.ObserveHistogramMetric("biya_tx_confirm_time_seconds", nil, nil, 200)
+
+	cfg := config.Default().OTLP
+	cfg.Endpoint = srv.URL + "/v1/metrics"
+	// 内置属性不能被覆盖
+	cfg.ResourceAttributes = map[string]string{"deployment.environment": "test", "service.version": "fake"}
+	e := NewOTLPExporter(slog.New(slog.NewTextHandler(io.Discard, nil)), m.Owned("otlp"), cfg, "v1.2.3", "abc123")
+	if err := e.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got == nil || len(got.ResourceMetrics) != 1 {
+		t.Fatalf("no metrics received: %v", got)
+	}
+	rm := got.ResourceMetrics[0]
+	attrs := map[string]string{}
+	for _, kv := range rm.GetResource().GetAttributes() {
+		attrs[kv.GetKey()] = kv.GetValue().GetStringValue()
+	}
+	if s := fmt.Sprint(attrs); s != "map[biya.chain_id:biya-1 biya.commit:abc123 deployment.environment:test service.name:biya-exporter service.version:v1.2.3]" {
+		t.Fatalf("resource attributes = %s", s)
+	}
+
+	byName := map[string]*metricspb.Metric{}
+	for _, om := range rm.GetScopeMetrics()[0].GetMetrics() {
+		byName[om.GetName()] = om
+	}
+
+	gauge := byName["biya_node_up"].GetGauge()
+	if gauge == nil || len(gauge.DataPoints) != 1 || gauge.DataPoints[0].GetAsDouble() != 1 || gauge.DataPoints[0].Attributes[0].GetValue().GetStringValue() != "sentry-1" {
+		t.Fatalf("gauge = %v", byName["biya_node_up"])
+	}
+
+	sum := byName["biya_tx_total"].GetSum()
+	if sum == nil || !sum.IsMonotonic || sum.AggregationTemporality != metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE {
+		t.Fatalf("counter should be a monotonic cumulative sum: %v", byName["biya_tx_total"])
+	}
+	for _, p := range sum.DataPoints {
+		if p.StartTimeUnixNano == 0 || p.StartTimeUnixNano > p.TimeUnixNano {
+			t.Fatalf("bad start time: %v", p)
+		}
+	}
+
+	h := byName["biya_tx_confirm_time_seconds"]
+	if h.GetUnit() != "s" || h.GetHistogram() == nil {
+		t.Fatalf("histogram = %v", h)
+	}
+	p := h.GetHistogram().DataPoints[0]
+	// 经典 bucket 1,2,3,5,...,120：2.5 落在 (2,3]，200 落在 +Inf
+	if fmt.Sprint(p.ExplicitBounds) != "[1 2 3 5 10 20 30 60 120]" || fmt.Sprint(p.BucketCounts) != "[0 0 1 0 0 0 0 0 0 1]" {
+		t.Fatalf("bounds = %v counts = %v", p.ExplicitBounds, p.BucketCounts)
+	}
+	if p.Count != 2 || p.GetSum() != 202.5 {
+		t.Fatalf("count = %d sum = %v", p.Count, p.GetSum())
+	}
+	if len(p.Exemplars) != 1 || p.Exemplars[0].GetAsDouble() != 2.5 || p.Exemplars[0].FilteredAttributes[0].GetValue().GetStringValue() != "42" {
+		t.Fatalf("exemplars = %v", p.Exemplars)
+	}
+}
@@ -0,0 +1,200 @@
+// Package push 实现主动推送模式：周期性把 registry 快照序列化后 POST 到外部存储，
+// 与 /metrics 被动抓取并存，适用于 exporter 不能被 Prometheus 直接访问的部署。
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+// target 为一个推送目标及其待发送队列（内存中，已编码的请求体）。
+type target struct {
+	name    string
+	url     string
+	headers map[string]string
+	queue   [][]byte
+}
+
+// targetName 返回目标在指标中的名称：显式配置优先，否则取 URL 的 host。
+func targetName(name, rawURL string) string {
+	if name != "" {
+		return name
+	}
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return rawURL
+}
+
+// pusher 为各推送协议共用的发送逻辑：每个目标一个有界队列，按入队顺序发送，
+// 可重试的失败（网络错误、5xx、429）按指数退避重试，重试用尽则留在队列中等下个周期。
+type pusher struct {
+	log  *slog.Logger
+	m    *metrics.Metrics
+	http *http.Client
+	// protocol 用作 source/target label 的前缀，例如 remote_write
+	protocol    string
+	contentType string
+	// extraHeaders 为协议要求的固定请求头（例如 Content-Encoding）
+	extraHeaders map[string]string
+
+	targets    []*target
+	queueSize  int
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// errRejected 表示服务端明确拒绝（4xx，429 除外），重发同样的数据没有意义。
+var errRejected = errors.New("rejected by server")
+
+func (p *pusher) source(t *target) string {
+	return p.protocol + "_" + t.name
+}
+
+// enqueue 把同一份请求体放入每个目标的队列；队列已满时丢弃最旧的快照（新数据更有价值）。
+func (p *pusher) enqueue(body []byte) {
+	limit := max(p.queueSize, 1)
+	for _, t := range p.targets {
+		if len(t.queue) >= limit {
+			t.queue = t.queue[1:]
+			p.m.IncCounter("biya_exporter_push_dropped_batches_total", map[string]string{"target": p.source(t), "reason": "queue_full"})
+		}
+		t.queue = append(t.queue, body)
+	}
+}
+
+// flush 并发清空所有目标的队列，直到队列为空、重试用尽或 ctx 结束。
+func (p *pusher) flush(ctx context.Context) {
+	done := make(chan struct{}, len(p.targets))
+	for _, t := range p.targets {
+		go func(t *target) {
+			defer func() { done <- struct{}{} }()
+			p.flushTarget(ctx, t)
+		}(t)
+	}
+	for range p.targets {
+		<-done
+	}
+}
+
+func (p *pusher) flushTarget(ctx context.Context, t *target) {
+	labels := map[string]string{"target": p.source(t)}
+	defer func() {
+		p.m.SetGauge("biya_exporter_push_queue_batches", labels, float64(len(t.queue)))
+	}()
+
+	for len(t.queue) > 0 {
+		err := p.sendWithRetry(ctx, t, t.queue[0])
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, errRejected) {
+			// 服务端拒收的数据重发也无用，丢弃后继续发送后面的快照
+			t.queue = t.queue[1:]
+			p.m.IncCounter("biya_exporter_push_dropped_batches_total", map[string]string{"target": p.source(t), "reason": "rejected"})
+			p.m.SetGauge("biya_exporter_source_up", map[string]string{"source": p.source(t)}, 0)
+			p.log.Warn("push batch rejected, dropped", "target", p.source(t), "err", err)
+			continue
+		}
+		if err != nil {
+			p.m.SetGauge("biya_exporter_source_up", map[string]string{"source": p.source(t)}, 0)
+			p.log.Warn("push failed, keep batches for next interval", "target", p.source(t), "queued", len(t.queue), "err", err)
+			return
+		}
+		t.queue = t.queue[1:]
+		p.m.IncCounter("biya_exporter_push_sent_batches_total", labels)
+		p.m.SetGauge("biya_exporter_source_up", map[string]string{"source": p.source(t)}, 1)
+	}
+}
+
+func (p *pusher) sendWithRetry(ctx context.Context, t *target, body []byte) error {
+	backoff := p.minBackoff
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := p.send(ctx, t, body)
+		if err == nil || errors.Is(err, errRejected) || ctx.Err() != nil {
+			return err
+		}
+		p.m.IncCounter("biya_exporter_push_failed_requests_total", map[string]string{"target": p.source(t)})
+		if attempt >= p.maxRetries {
+			return err
+		}
+
+		wait := backoff
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		if wait > p.maxBackoff {
+			wait = p.maxBackoff
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > p.maxBackoff {
+			backoff = p.maxBackoff
+		}
+	}
+}
+
+// send 发送一次请求；返回服务端建议的重试等待（Retry-After，仅 429/503 时有意义）。
+func (p *pusher) send(ctx context.Context, t *target, body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errRejected, err)
+	}
+	req.Header.Set("Content-Type", p.contentType)
+	req.Header.Set("User-Agent", "biya-exporter")
+	for k, v := range p.extraHeaders {
+		req.Header.Set(k, v)
+	}
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return 0, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return parseRetryAfter(resp.Header.Get("Retry-After")), fmt.Errorf("http %d: %s", resp.StatusCode, msg)
+	default:
+		return 0, fmt.Errorf("%w: http %d: %s", errRejected, resp.StatusCode, msg)
+	}
+}
+
+// parseRetryAfter 解析秒数或 HTTP 日期两种写法；无法解析时返回 0。
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
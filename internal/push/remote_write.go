@@ -0,0 +1,164 @@
+package push
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/golang/snappy"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/config"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+// RemoteWriter 周期性把 registry 快照编码为 Prometheus remote write 1.0 的 WriteRequest（snappy 压缩），
+// 推送到配置的各 endpoint（Prometheus --web.enable-remote-write-receiver、VictoriaMetrics、Mimir 等）。
+// 实现 collectors.Collector，由 scheduler 按 remote_write.interval 调度。
+type RemoteWriter struct {
+	pusher
+	externalLabels map[string]string
+	now            func() time.Time
+}
+
+func NewRemoteWriter(log *slog.Logger, m *metrics.Metrics, cfg config.RemoteWriteConfig) *RemoteWriter {
+	w := &RemoteWriter{
+		pusher: pusher{
+			log:         log,
+			m:           m,
+			http:        &http.Client{Timeout: cfg.Timeout},
+			protocol:    "remote_write",
+			contentType: "application/x-protobuf",
+			extraHeaders: map[string]string{
+				"Content-Encoding":                  "snappy",
+				"X-Prometheus-Remote-Write-Version": "0.1.0",
+			},
+			queueSize:  cfg.QueueSize,
+			maxRetries: cfg.MaxRetries,
+			minBackoff: cfg.MinBackoff,
+			maxBackoff: cfg.MaxBackoff,
+		},
+		externalLabels: cfg.ExternalLabels,
+		now:            time.Now,
+	}
+	for _, ep := range cfg.Endpoints {
+		headers := make(map[string]string, len(ep.Headers)+1)
+		for k, v := range ep.Headers {
+			headers[k] = v
+		}
+		if ep.BearerToken != "" {
+			headers["Authorization"] = "Bearer " + ep.BearerToken
+		}
+		w.targets = append(w.targets, &target{name: targetName(ep.Name, ep.URL), url: ep.URL, headers: headers})
+	}
+	return w
+}
+
+// Run 生成一次快照并尝试清空各 endpoint 的队列。
+// 推送失败只体现在 source_up 与 biya_exporter_push_* 指标上，不返回错误：远端存储不可用不应影响 /readyz。
+func (w *RemoteWriter) Run(ctx context.Context) error {
+	series := flattenFamilies(w.m.Gather(), w.externalLabels)
+	body := snappy.Encode(nil, encodeWriteRequest(series, w.now().UnixMilli()))
+	w.enqueue(body)
+	w.flush(ctx)
+	return ctx.Err()
+}
+
+type rwLabel struct {
+	name, value string
+}
+
+type rwSeries struct {
+	labels []rwLabel
+	value  float64
+}
+
+// flattenFamilies 把快照展开为 remote write 的扁平 series：histogram 拆成 _bucket/_sum/_count，
+// 与 /metrics text 格式的样本一一对应（le 的写法也一致），抓取与推送的数据可以互相替换。
+func flattenFamilies(families []*dto.MetricFamily, external map[string]string) []rwSeries {
+	var out []rwSeries
+	add := func(name string, base []*dto.LabelPair, extra *rwLabel, v float64) {
+		labels := make([]rwLabel, 0, len(base)+len(external)+2)
+		labels = append(labels, rwLabel{name: "__name__", value: name})
+		seen := make(map[string]bool, len(base)+1)
+		for _, lp := range base {
+			if lp.GetValue() == "" {
+				continue
+			}
+			labels = append(labels, rwLabel{name: lp.GetName(), value: lp.GetValue()})
+			seen[lp.GetName()] = true
+		}
+		if extra != nil {
+			labels = append(labels, *extra)
+			seen[extra.name] = true
+		}
+		for k, v := range external {
+			if !seen[k] && v != "" {
+				labels = append(labels, rwLabel{name: k, value: v})
+			}
+		}
+		// remote write 要求 label 按名字排序
+		sort.Slice(labels, func(i, j int) bool { return labels[i].name < labels[j].name })
+		out = append(out, rwSeries{labels: labels, value: v})
+	}
+
+	for _, mf := range families {
+		name := mf.GetName()
+		for _, m := range mf.GetMetric() {
+			switch mf.GetType() {
+			case dto.MetricType_GAUGE:
+				add(name, m.GetLabel(), nil, m.GetGauge().GetValue())
+			case dto.MetricType_COUNTER:
+				add(name, m.GetLabel(), nil, m.GetCounter().GetValue())
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				for _, b := range h.GetBucket() {
+					le := rwLabel{name: "le", value: strconv.FormatFloat(b.GetUpperBound(), 'f', -1, 64)}
+					add(name+"_bucket", m.GetLabel(), &le, float64(b.GetCumulativeCount()))
+				}
+				add(name+"_bucket", m.GetLabel(), &rwLabel{name: "le", value: "+Inf"}, float64(h.GetSampleCount()))
+				add(name+"_sum", m.GetLabel(), nil, h.GetSampleSum())
+				add(name+"_count", m.GetLabel(), nil, float64(h.GetSampleCount()))
+			}
+		}
+	}
+	return out
+}
+
+// encodeWriteRequest 按 prometheus.WriteRequest 的 wire 格式手工编码（避免引入整个 prometheus/prometheus 依赖）：
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(series []rwSeries, tsMillis int64) []byte {
+	var out, ts, buf []byte
+	for _, s := range series {
+		ts = ts[:0]
+		for _, l := range s.labels {
+			buf = buf[:0]
+			buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+			buf = protowire.AppendString(buf, l.name)
+			buf = protowire.AppendTag(buf, 2, protowire.BytesType)
+			buf = protowire.AppendString(buf, l.value)
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, buf)
+		}
+		buf = buf[:0]
+		buf = protowire.AppendTag(buf, 1, protowire.Fixed64Type)
+		buf = protowire.AppendFixed64(buf, math.Float64bits(s.value))
+		buf = protowire.AppendTag(buf, 2, protowire.VarintType)
+		buf = protowire.AppendVarint(buf, uint64(tsMillis))
+		ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, buf)
+
+		out = protowire.AppendTag(out, 1, protowire.BytesType)
+		out = protowire.AppendBytes(out, ts)
+	}
+	return out
+}
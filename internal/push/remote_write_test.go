@@ -0,0 +1,208 @@
+package push
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/config"
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+func TestRemoteWriter_PushesSnapshotAndRetries(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int64
+	var mu sync.Mutex
+	var got map[string]float64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// 第一次返回 503，应退避后重试同一份数据
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Content-Encoding") != "snappy" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			t.Errorf("headers = %v", r.Header)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		compressed, _ := io.ReadAll(r.Body)
+		raw, err := snappy.Decode(nil, compressed)
+		if err != nil {
+			t.Errorf("snappy: %v", err)
+		}
+		mu.Lock()
+		got = decodeWriteRequest(t, raw)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	_, m := metrics.New("biya", "dev", "none")
+	m.SetGauge("biya_node_up", map[string]string{"node": "sentry-1"}, 1)
+	m.IncCounter("biya_tx_total", map[string]string{"status": "success"})
+	m.ObserveHistogramMetric("biya_tx_confirm_time_seconds", nil, nil, 2.5)
+
+	cfg := config.Default().RemoteWrite
+	cfg.Endpoints = []config.RemoteWriteEndpoint{{Name: "vm", URL: srv.URL, BearerToken: "secret"}}
+	// biya_node_up 自身带 node label，应保留指标的值；其余 series 附加 external label
+	cfg.ExternalLabels = map[string]string{"cluster": "prod", "node": "external"}
+	cfg.MinBackoff, cfg.MaxBackoff = 10*time.Millisecond, 20*time.Millisecond
+	w := NewRemoteWriter(slog.New(slog.NewTextHandler(io.Discard, nil)), m.Owned("remote_write"), cfg)
+
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("calls = %d, want 2 (one retry)", n)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for series, want := range map[string]float64{
+		`__name__="biya_node_up",cluster="prod",node="sentry-1"`:                                         1,
+		`__name__="biya_tx_total",cluster="prod",node="external",status="success"`:                       1,
+		`__name__="biya_tx_confirm_time_seconds_bucket",cluster="prod",le="2",node="external"`:           0,
+		`__name__="biya_tx_confirm_time_seconds_bucket",cluster="prod",le="3",node="external"`:           1,
+		`__name__="biya_tx_confirm_time_seconds_bucket",cluster="prod",le="+Inf",node="external"`:        1,
+		`__name__="biya_tx_confirm_time_seconds_sum",cluster="prod",node="external"`:                     2.5,
+		`__name__="biya_exporter_build_info",cluster="prod",commit="none",node="external",version="dev"`: 1,
+	} {
+		v, ok := got[series]
+		if !ok {
+			t.Errorf("series %s not pushed", series)
+			continue
+		}
+		if v != want {
+			t.Errorf("series %s = %v, want %v", series, v, want)
+		}
+	}
+
+	out := m.RenderText()
+	assertContains(t, out, "\nbiya_exporter_push_failed_requests_total{target=\"remote_write_vm\"} 1\n")
+	assertContains(t, out, "\nbiya_exporter_push_sent_batches_total{target=\"remote_write_vm\"} 1\n")
+	assertContains(t, out, "\nbiya_exporter_source_up{source=\"remote_write_vm\"} 1\n")
+}
+
+func TestRemoteWriter_BoundedQueueKeepsNewestUntilRecovered(t *testing.T) {
+	t.Parallel()
+
+	var down atomic.Bool
+	down.Store(true)
+	var delivered atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		delivered.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	_, m := metrics.New("biya", "dev", "none")
+	cfg := config.Default().RemoteWrite
+	cfg.Endpoints = []config.RemoteWriteEndpoint{{URL: srv.URL}}
+	cfg.QueueSize = 2
+	cfg.MaxRetries = 0
+	w := NewRemoteWriter(slog.New(slog.NewTextHandler(io.Discard, nil)), m.Owned("remote_write"), cfg)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		_ = w.Run(ctx)
+	}
+	target := "remote_write_" + strings.TrimPrefix(srv.URL, "http://")
+	out := m.RenderText()
+	assertContains(t, out, "\nbiya_exporter_push_queue_batches{target=\""+target+"\"} 2\n")
+	assertContains(t, out, "\nbiya_exporter_push_dropped_batches_total{target=\""+target+"\",reason=\"queue_full\"} 1\n")
+	assertContains(t, out, "\nbiya_exporter_source_up{source=\""+target+"\"} 0\n")
+
+	down.Store(false)
+	_ = w.Run(ctx)
+	if n := delivered.Load(); n != 2 {
+		t.Fatalf("delivered = %d, want 2 (queue size)", n)
+	}
+	out = m.RenderText()
+	assertContains(t, out, "\nbiya_exporter_push_queue_batches{target=\""+target+"\"} 0\n")
+	assertContains(t, out, "\nbiya_exporter_push_dropped_batches_total{target=\""+target+"\",reason=\"queue_full\"} 2\n")
+	assertContains(t, out, "\nbiya_exporter_source_up{source=\""+target+"\"} 1\n")
+}
+
+// decodeWriteRequest 把 WriteRequest 解码为 `k="v",...` -> value（label 已按名字排序）。
+func decodeWriteRequest(t *testing.T, b []byte) map[string]float64 {
+	t.Helper()
+	out := map[string]float64{}
+	forEachField(t, b, func(num protowire.Number, ts []byte) {
+		var labels []string
+		var value float64
+		forEachField(t, ts, func(num protowire.Number, v []byte) {
+			switch num {
+			case 1:
+				var name, val string
+				forEachField(t, v, func(num protowire.Number, s []byte) {
+					if num == 1 {
+						name = string(s)
+					} else {
+						val = string(s)
+					}
+				})
+				labels = append(labels, name+"=\""+val+"\"")
+			case 2:
+				forEachField(t, v, func(num protowire.Number, s []byte) {
+					if num == 1 {
+						bits, _ := protowire.ConsumeFixed64(s)
+						value = math.Float64frombits(bits)
+					}
+				})
+			}
+		})
+		out[strings.Join(labels, ",")] = value
+	})
+	return out
+}
+
+// forEachField 遍历消息的字段；length-delimited 字段传内容，其余传原始编码。
+func forEachField(t *testing.T, b []byte, fn func(protowire.Number, []byte)) {
+	t.Helper()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("bad tag: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+		if typ == protowire.BytesType {
+			v, m := protowire.ConsumeBytes(b)
+			if m < 0 {
+				t.Fatalf("bad bytes: %v", protowire.ParseError(m))
+			}
+			fn(num, v)
+			b = b[m:]
+			continue
+		}
+		m := protowire.ConsumeFieldValue(num, typ, b)
+		if m < 0 {
+			t.Fatalf("bad field: %v", protowire.ParseError(m))
+		}
+		fn(num, b[:m])
+		b = b[m:]
+	}
+}
+
+func assertContains(t *testing.T, s, sub string) {
+	t.Helper()
+	if !strings.Contains(s, sub) {
+		t.Fatalf("expected output to contain %q\n\nfull output:\n%s", sub, s)
+	}
+}
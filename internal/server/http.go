@@ -3,27 +3,55 @@ package server
 import (
 	"context"
 	"errors"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
 )
 
 type Server struct {
-	addr  string
-	reg   *metrics.Registry
-	ready func() bool
+	addr   string
+	reg    *metrics.Registry
+	ready  func() bool
+	reload func(ctx context.Context) error
 }
 
 func New(listenAddr string, reg *metrics.Registry, ready func() bool) *Server {
 	return &Server{addr: listenAddr, reg: reg, ready: ready}
 }
 
-func (s *Server) Start(ctx context.Context) error {
+// OnReload 注册配置热加载回调，启用 POST /-/reload（与 Prometheus 的管理接口约定一致）。
+// 该接口与 /metrics 共用监听地址且不做鉴权，调用方应只在显式开启时注册（http.enable_lifecycle）。
+func (s *Server) OnReload(fn func(ctx context.Context) error) *Server {
+	s.reload = fn
+	return s
+}
+
+// 支持的 exposition 格式（Content-Type）
+const (
+	contentTypeText        = "text/plain; version=0.0.4; charset=utf-8"
+	contentTypeOpenMetrics = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	contentTypeProtobuf    = "application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=delimited"
+)
+
+// Handler 返回 exporter 的全部 HTTP 路由。
+func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
-		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
-		_, _ = w.Write([]byte(s.reg.RenderText()))
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		ct := negotiate(r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", ct)
+		switch ct {
+		case contentTypeProtobuf:
+			_ = s.reg.WriteProtobuf(w)
+		case contentTypeOpenMetrics:
+			_, _ = w.Write([]byte(s.reg.RenderOpenMetrics()))
+		default:
+			_, _ = w.Write([]byte(s.reg.RenderText()))
+		}
 	})
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -38,10 +66,33 @@ func (s *Server) Start(ctx context.Context) error {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ready"))
 	})
+	mux.HandleFunc("/-/reload", func(w http.ResponseWriter, r *http.Request) {
+		if s.reload == nil {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("lifecycle API is not enabled (http.enable_lifecycle)"))
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			_, _ = w.Write([]byte("use POST to reload"))
+			return
+		}
+		if err := s.reload(r.Context()); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("reload failed: " + err.Error()))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("reloaded"))
+	})
+	return mux
+}
 
+func (s *Server) Start(ctx context.Context) error {
 	srv := &http.Server{
 		Addr:              s.addr,
-		Handler:           mux,
+		Handler:           s.Handler(),
 		ReadHeaderTimeout: 5 * time.Second,
 	}
 
@@ -62,3 +113,40 @@ func (s *Server) Start(ctx context.Context) error {
 		return err
 	}
 }
+
+// negotiate 按 Accept 中的 q 值在支持的格式里选出 Content-Type；q 相同时取先出现者。
+// 未带 Accept 或没有可识别的类型时回退到 text 0.0.4（与旧版 Prometheus 的默认行为一致）。
+func negotiate(accept string) string {
+	best, bestQ := contentTypeText, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		var ct string
+		switch mt {
+		case "application/vnd.google.protobuf":
+			// 只支持 delimited 的 MetricFamily 流（Prometheus 抓取 native histogram 时请求的格式）
+			if params["proto"] != "io.prometheus.client.MetricFamily" || params["encoding"] != "delimited" {
+				continue
+			}
+			ct = contentTypeProtobuf
+		case "application/openmetrics-text":
+			ct = contentTypeOpenMetrics
+		case "text/plain", "*/*":
+			ct = contentTypeText
+		default:
+			continue
+		}
+		if q > bestQ {
+			best, bestQ = ct, q
+		}
+	}
+	return best
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/biya-coin/biya-dex-backend-exporter/internal/metrics"
+)
+
+func TestMetrics_ContentNegotiation(t *testing.T) {
+	t.Parallel()
+
+	reg, m := metrics.New("biya", "dev", "none")
+	m.IncCounter("biya_blocks_total", nil)
+	srv := httptest.NewServer(New(":0", reg, nil).Handler())
+	defer srv.Close()
+
+	cases := []struct {
+		accept, contentType string
+		contains, absent    []string
+	}{
+		{
+			accept:      "",
+			contentType: "text/plain; version=0.0.4; charset=utf-8",
+			contains:    []string{"# TYPE biya_blocks_total counter\n", "\nbiya_blocks_total 1\n"},
+			absent:      []string{"# EOF", "_created"},
+		},
+		{
+			// Prometheus 3.x 的默认 Accept
+			accept:      "application/openmetrics-text;version=1.0.0;escaping=allow-utf-8;q=0.6,application/openmetrics-text;version=0.0.1;q=0.5,text/plain;version=1.0.0;escaping=allow-utf-8;q=0.4,text/plain;version=0.0.4;q=0.3,*/*;q=0.2",
+			contentType: "application/openmetrics-text; version=1.0.0; charset=utf-8",
+			contains:    []string{"# TYPE biya_blocks counter\n", "\nbiya_blocks_total 1\n", "\nbiya_blocks_created ", "# UNIT biya_exporter_scrape_duration_seconds seconds\n"},
+		},
+		{
+			// 开启 native histogram 抓取时 Prometheus 优先请求 protobuf
+			accept:      "application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.6,application/openmetrics-text;version=1.0.0;q=0.5,text/plain;version=0.0.4;q=0.3",
+			contentType: "application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=delimited",
+			contains:    []string{"biya_exporter_build_info"},
+		},
+		{
+			// 不认识的 protobuf 变体不能被选中
+			accept:      "application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=text",
+			contentType: "text/plain; version=0.0.4; charset=utf-8",
+		},
+		{
+			accept:      "text/plain;q=0.9,application/openmetrics-text;q=0.1",
+			contentType: "text/plain; version=0.0.4; charset=utf-8",
+		},
+	}
+	for _, tc := range cases {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/metrics", nil)
+		if tc.accept != "" {
+			req.Header.Set("Accept", tc.accept)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET /metrics: %v", err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		body := string(b)
+
+		if got := resp.Header.Get("Content-Type"); got != tc.contentType {
+			t.Fatalf("Accept %q: Content-Type = %q", tc.accept, got)
+		}
+		if strings.HasPrefix(tc.contentType, "application/openmetrics-text") && !strings.HasSuffix(body, "# EOF\n") {
+			t.Fatalf("openmetrics body must end with # EOF")
+		}
+		for _, want := range tc.contains {
+			if !strings.Contains(body, want) {
+				t.Fatalf("Accept %q: expected %q in body:\n%s", tc.accept, want, body)
+			}
+		}
+		for _, bad := range tc.absent {
+			if strings.Contains(body, bad) {
+				t.Fatalf("Accept %q: unexpected %q in body", tc.accept, bad)
+			}
+		}
+	}
+}
+
+func TestReload_RequiresLifecycleAndPOST(t *testing.T) {
+	t.Parallel()
+
+	reg, _ := metrics.New("biya", "dev", "none")
+	do := func(h http.Handler, method string) (int, string) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, "/-/reload", nil))
+		return rec.Code, rec.Body.String()
+	}
+
+	// 未开启 lifecycle：不注册回调，接口不可用
+	if code, _ := do(New(":0", reg, nil).Handler(), http.MethodPost); code != http.StatusNotFound {
+		t.Fatalf("reload without lifecycle: status %d, want 404", code)
+	}
+
+	var calls int
+	h := New(":0", reg, nil).OnReload(func(context.Context) error {
+		calls++
+		return nil
+	}).Handler()
+	for _, method := range []string{http.MethodGet, http.MethodPut} {
+		if code, _ := do(h, method); code != http.StatusMethodNotAllowed {
+			t.Fatalf("%s /-/reload: status %d, want 405", method, code)
+		}
+	}
+	if code, body := do(h, http.MethodPost); code != http.StatusOK || body != "reloaded" {
+		t.Fatalf("POST /-/reload: status %d body %q", code, body)
+	}
+	if calls != 1 {
+		t.Fatalf("reload calls = %d, want 1", calls)
+	}
+}